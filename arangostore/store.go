@@ -0,0 +1,136 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package arangostore
+
+import (
+	"context"
+	"time"
+
+	"github.com/arangodb/go-driver/v2/arangodb"
+	"github.com/arangodb/go-driver/v2/arangodb/shared"
+	"github.com/clevergo/captchas"
+)
+
+// Option is a function that receives a pointer of arango store.
+type Option func(s *store)
+
+// Collection sets the collection name.
+func Collection(collection string) Option {
+	return func(s *store) {
+		s.collection = collection
+	}
+}
+
+// Expiration sets the expiration.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+type document struct {
+	Key    string `json:"_key"`
+	Answer string `json:"answer"`
+	// ExpireAt is the UNIX timestamp in seconds, which is indexed by TTL index.
+	ExpireAt int64 `json:"expireAt"`
+}
+
+const removeQuery = "REMOVE @key IN @@collection OPTIONS { ignoreErrors: true } RETURN OLD"
+
+type store struct {
+	db         arangodb.Database
+	collection string
+	coll       arangodb.Collection
+	expiration time.Duration
+}
+
+// New returns an arango store, the collection should be created beforehand,
+// and a TTL index on the expireAt field will be created if not exists.
+func New(db arangodb.Database, opts ...Option) (captchas.Store, error) {
+	s := &store{
+		db:         db,
+		collection: "captchas",
+		expiration: 10 * time.Minute,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	ctx := context.Background()
+	coll, err := db.GetCollection(ctx, s.collection, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err = coll.EnsureTTLIndex(ctx, []string{"expireAt"}, 0, nil); err != nil {
+		return nil, err
+	}
+	s.coll = coll
+
+	return s, nil
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	var doc document
+	if clear {
+		// removes and returns the document in a single atomic operation.
+		cursor, err := s.db.Query(ctx, removeQuery, &arangodb.QueryOptions{
+			BindVars: map[string]interface{}{
+				"key":         id,
+				"@collection": s.collection,
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		defer cursor.Close()
+		if !cursor.HasMore() {
+			return "", captchas.ErrIncorrectCaptcha
+		}
+		if _, err = cursor.ReadDocument(ctx, &doc); err != nil {
+			return "", err
+		}
+		if doc.Key == "" {
+			return "", captchas.ErrIncorrectCaptcha
+		}
+	} else {
+		_, err := s.coll.ReadDocument(ctx, id, &doc)
+		if shared.IsNotFound(err) {
+			return "", captchas.ErrIncorrectCaptcha
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// TTL index removes expired documents periodically.
+	if time.Now().Unix() > doc.ExpireAt {
+		return "", captchas.ErrExpiredCaptcha
+	}
+
+	return doc.Answer, nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	mode := arangodb.CollectionDocumentCreateOverwriteModeReplace
+	_, err := s.coll.CreateDocumentWithOptions(ctx, document{
+		Key:      id,
+		Answer:   answer,
+		ExpireAt: time.Now().Add(s.expiration).Unix(),
+	}, &arangodb.CollectionDocumentCreateOptions{OverwriteMode: &mode})
+	return err
+}
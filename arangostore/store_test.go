@@ -0,0 +1,87 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package arangostore
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arangodb/go-driver/v2/arangodb"
+	"github.com/arangodb/go-driver/v2/connection"
+)
+
+var testDB arangodb.Database
+
+func TestMain(m *testing.M) {
+	endpoint := connection.NewRoundRobinEndpoints([]string{"http://localhost:8529"})
+	conn := connection.NewHttpConnection(connection.DefaultHTTPConfigurationWrapper(endpoint, true))
+	client := arangodb.NewClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	if db, err := client.GetDatabase(ctx, "_system", nil); err == nil {
+		exists, err := db.CollectionExists(ctx, "captchas")
+		if err != nil {
+			panic(err)
+		}
+		if !exists {
+			if _, err = db.CreateCollectionV2(ctx, "captchas", nil); err != nil {
+				panic(err)
+			}
+		}
+		testDB = db
+	}
+	cancel()
+
+	os.Exit(m.Run())
+}
+
+func TestOptions(t *testing.T) {
+	s := &store{}
+	collection := "foo"
+	expiration := 5 * time.Minute
+	Collection(collection)(s)
+	Expiration(expiration)(s)
+	if s.collection != collection {
+		t.Errorf("expected collection %s, got %s", collection, s.collection)
+	}
+	if s.expiration != expiration {
+		t.Errorf("expected expiration %v, got %v", expiration, s.expiration)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	if testDB == nil {
+		t.Skip("arangodb server is unavailable")
+	}
+
+	s, err := New(testDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package badgerstore
+
+import (
+	"errors"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/dgraph-io/badger/v4"
+)
+
+// Option is a function that receives a pointer of badger store.
+type Option func(s *store)
+
+// Prefix sets the prefix of key.
+func Prefix(prefix string) Option {
+	return func(s *store) {
+		s.prefix = prefix
+	}
+}
+
+// Expiration sets the expiration.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+type store struct {
+	db         *badger.DB
+	prefix     string
+	expiration time.Duration
+}
+
+// New returns a badger store.
+func New(db *badger.DB, opts ...Option) captchas.Store {
+	s := &store{
+		db:         db,
+		prefix:     "captchas",
+		expiration: 10 * time.Minute,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	return s
+}
+
+func (s *store) getKey(id string) []byte {
+	return []byte(s.prefix + ":" + id)
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (answer string, err error) {
+	fn := s.db.View
+	if clear {
+		fn = s.db.Update
+	}
+	err = fn(func(txn *badger.Txn) error {
+		key := s.getKey(id)
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		answer = string(value)
+		if clear {
+			return txn.Delete(key)
+		}
+		return nil
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return answer, nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetWithTTL(id, answer, s.expiration)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		entry := badger.NewEntry(s.getKey(id), []byte(answer)).WithTTL(ttl)
+		return txn.SetEntry(entry)
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package badgerstore
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/dgraph-io/badger/v4"
+)
+
+var testDB *badger.DB
+
+func TestMain(m *testing.M) {
+	var err error
+	testDB, err = badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	testDB.Close()
+	os.Exit(code)
+}
+
+func TestPrefixOption(t *testing.T) {
+	s := &store{}
+	prefix := "foo"
+	Prefix(prefix)(s)
+	if s.prefix != prefix {
+		t.Errorf("expected prefix %s, got %s", prefix, s.prefix)
+	}
+}
+
+func TestGetKey(t *testing.T) {
+	prefix := "foo"
+	s := &store{prefix: prefix}
+	key := "bar"
+	if string(s.getKey(key)) != prefix+":"+key {
+		t.Errorf("expected key %s, got %s", prefix+":"+key, s.getKey(key))
+	}
+}
+
+func TestNew(t *testing.T) {
+	expiration := 5 * time.Minute
+	prefix := "foo"
+	s, _ := New(testDB, Expiration(expiration), Prefix(prefix)).(*store)
+	if s.expiration != expiration {
+		t.Errorf("expected expiration %v, got %v", expiration, s.expiration)
+	}
+	if s.prefix != prefix {
+		t.Errorf("expected prefix %s, got %s", prefix, s.prefix)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	s := New(testDB)
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+func TestStoreExpiration(t *testing.T) {
+	s := New(testDB, Expiration(time.Second))
+	if err := s.Set("foo", "bar"); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := s.Get("foo", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
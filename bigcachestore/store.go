@@ -0,0 +1,60 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package bigcachestore
+
+import (
+	"sync"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/clevergo/captchas"
+)
+
+type store struct {
+	// mu serializes consumption, so that an answer can't be consumed twice.
+	mu    sync.Mutex
+	cache *bigcache.BigCache
+}
+
+// New returns a bigcache store, the expiration of captchas is determined
+// by the cache's LifeWindow.
+func New(cache *bigcache.BigCache) captchas.Store {
+	return &store{
+		cache: cache,
+	}
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	if clear {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	answer, resp, err := s.cache.GetWithInfo(id)
+	if err == bigcache.ErrEntryNotFound {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if clear {
+		if err = s.cache.Delete(id); err != nil {
+			return "", err
+		}
+	}
+
+	// expired entries are kept until the next clean up.
+	if resp.EntryStatus == bigcache.Expired {
+		return "", captchas.ErrExpiredCaptcha
+	}
+
+	return string(answer), nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.cache.Set(id, []byte(answer))
+}
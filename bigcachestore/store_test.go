@@ -0,0 +1,79 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package bigcachestore
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/clevergo/captchas"
+)
+
+var testCache *bigcache.BigCache
+
+func TestMain(m *testing.M) {
+	config := bigcache.DefaultConfig(time.Second)
+	config.CleanWindow = 0
+	var err error
+	testCache, err = bigcache.New(context.Background(), config)
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	testCache.Close()
+	os.Exit(code)
+}
+
+func TestNew(t *testing.T) {
+	s, _ := New(testCache).(*store)
+	if s.cache != testCache {
+		t.Errorf("expected cache %v, got %v", testCache, s.cache)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	s := New(testCache)
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+func TestStoreExpiration(t *testing.T) {
+	s := New(testCache)
+	if err := s.Set("expired", "bar"); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	time.Sleep(1100 * time.Millisecond)
+	if _, err := s.Get("expired", true); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+	if _, err := s.Get("expired", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package boltstore
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/clevergo/captchas"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Option is a function that receives a pointer of bolt store.
+type Option func(s *store)
+
+// Bucket sets the bucket name.
+func Bucket(bucket string) Option {
+	return func(s *store) {
+		s.bucket = []byte(bucket)
+	}
+}
+
+// Expiration sets expiration.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+// GCInterval sets garbage collection interval, a non-positive interval
+// disables the garbage collection.
+func GCInterval(interval time.Duration) Option {
+	return func(s *store) {
+		s.gcInterval = interval
+	}
+}
+
+type store struct {
+	db         *bolt.DB
+	bucket     []byte
+	expiration time.Duration
+	gcInterval time.Duration
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// New returns a bolt store, the bucket will be created on demand.
+func New(db *bolt.DB, opts ...Option) captchas.Store {
+	s := &store{
+		db:         db,
+		bucket:     []byte("captchas"),
+		expiration: 10 * time.Minute,
+		gcInterval: time.Minute,
+		done:       make(chan struct{}),
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	if s.gcInterval > 0 {
+		go s.gc()
+	}
+
+	return s
+}
+
+// encode encodes expiration and answer as value, the first 8 bytes
+// is the big endian expiration in nanoseconds.
+func encode(expiration int64, answer string) []byte {
+	value := make([]byte, 8+len(answer))
+	binary.BigEndian.PutUint64(value, uint64(expiration))
+	copy(value[8:], answer)
+	return value
+}
+
+func decode(value []byte) (expiration int64, answer string) {
+	if len(value) < 8 {
+		return 0, ""
+	}
+	return int64(binary.BigEndian.Uint64(value)), string(value[8:])
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (answer string, err error) {
+	fn := s.db.View
+	if clear {
+		fn = s.db.Update
+	}
+	expired := false
+	err = fn(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		if bucket == nil {
+			return captchas.ErrIncorrectCaptcha
+		}
+		value := bucket.Get([]byte(id))
+		if value == nil {
+			return captchas.ErrIncorrectCaptcha
+		}
+		var expiration int64
+		expiration, answer = decode(value)
+		if clear {
+			if err := bucket.Delete([]byte(id)); err != nil {
+				return err
+			}
+		}
+		// reports the expiration after committing, so that the expired
+		// captcha is deleted.
+		expired = time.Now().UnixNano() > expiration
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if expired {
+		return "", captchas.ErrExpiredCaptcha
+	}
+
+	return answer, nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetWithTTL(id, answer, s.expiration)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(s.bucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(id), encode(time.Now().Add(ttl).UnixNano(), answer))
+	})
+}
+
+func (s *store) gc() {
+	ticker := time.NewTicker(s.gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.deleteExpired()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Close stops the garbage collection, the database is left open.
+func (s *store) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
+
+func (s *store) deleteExpired() error {
+	now := time.Now().UnixNano()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(s.bucket)
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if expiration, _ := decode(v); now > expiration {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
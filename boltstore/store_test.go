@@ -0,0 +1,128 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package boltstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	bolt "go.etcd.io/bbolt"
+)
+
+var testDB *bolt.DB
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "boltstore")
+	if err != nil {
+		panic(err)
+	}
+	testDB, err = bolt.Open(filepath.Join(dir, "captchas.db"), 0600, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	testDB.Close()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestNew(t *testing.T) {
+	bucket := "foo"
+	expiration := 5 * time.Minute
+	gcInterval := time.Minute
+	s, _ := New(testDB, Bucket(bucket), Expiration(expiration), GCInterval(gcInterval)).(*store)
+	if string(s.bucket) != bucket {
+		t.Errorf("expected bucket %s, got %s", bucket, s.bucket)
+	}
+	if s.expiration != expiration {
+		t.Errorf("expected expiration %v, got %v", expiration, s.expiration)
+	}
+	if s.gcInterval != gcInterval {
+		t.Errorf("expected gcInterval %v, got %v", gcInterval, s.gcInterval)
+	}
+}
+
+func TestStoreCloseWithoutGC(t *testing.T) {
+	s := New(testDB, GCInterval(0))
+	if err := captchas.Close(s); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	expiration, answer := decode(encode(123, "foo"))
+	if expiration != 123 {
+		t.Errorf("expected expiration %d, got %d", 123, expiration)
+	}
+	if answer != "foo" {
+		t.Errorf("expected answer %q, got %q", "foo", answer)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	s := New(testDB, Bucket("get"))
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+func TestStoreDeleteExpired(t *testing.T) {
+	s := &store{db: testDB, bucket: []byte("expired"), expiration: -time.Second}
+	if err := s.Set("expired", "expired"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("expired", false); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+	s.expiration = time.Minute
+	if err := s.Set("active", "active"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.deleteExpired(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("expired", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected item %q to be deleted", "expired")
+	}
+	if _, err := s.Get("active", false); err != nil {
+		t.Errorf("expected item %q to be kept, got %v", "active", err)
+	}
+}
+
+func TestStoreGetExpired(t *testing.T) {
+	s := New(testDB, Bucket("get-expired"), Expiration(-time.Second))
+	s.Set("foo", "bar")
+	if _, err := s.Get("foo", true); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+	if _, err := s.Get("foo", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected the expired captcha to be deleted, got %v", err)
+	}
+}
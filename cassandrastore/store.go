@@ -0,0 +1,114 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package cassandrastore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/internal/timeutil"
+	"github.com/gocql/gocql"
+)
+
+// Option is a function that receives a pointer of cassandra store.
+type Option func(s *store)
+
+// Table sets the table name, the table should be created beforehand:
+//
+//	CREATE TABLE captchas (id text PRIMARY KEY, answer text);
+func Table(table string) Option {
+	return func(s *store) {
+		s.table = table
+	}
+}
+
+// Expiration sets the expiration, it will be rounded up to seconds
+// since Cassandra TTL is measured in seconds.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+type store struct {
+	session    *gocql.Session
+	table      string
+	expiration time.Duration
+}
+
+// New returns a cassandra store.
+func New(session *gocql.Session, opts ...Option) captchas.Store {
+	s := &store{
+		session:    session,
+		table:      "captchas",
+		expiration: 10 * time.Minute,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	return s
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	var answer string
+	query := fmt.Sprintf("SELECT answer FROM %s WHERE id = ?", s.table)
+	err := s.session.Query(query, id).WithContext(ctx).Scan(&answer)
+	if err == gocql.ErrNotFound {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if clear {
+		// lightweight transaction guarantees that only one of concurrent
+		// consumers is able to delete the captcha.
+		query = fmt.Sprintf("DELETE FROM %s WHERE id = ? IF answer = ?", s.table)
+		applied, err := s.session.Query(query, id, answer).WithContext(ctx).MapScanCAS(map[string]interface{}{})
+		if err != nil {
+			return "", err
+		}
+		if !applied {
+			return "", captchas.ErrIncorrectCaptcha
+		}
+	}
+
+	return answer, nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	return s.set(ctx, id, answer, s.expiration)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.SetWithTTLContext(context.Background(), id, answer, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	return s.set(ctx, id, answer, ttl)
+}
+
+func (s *store) set(ctx context.Context, id, answer string, ttl time.Duration) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, answer) VALUES (?, ?) USING TTL ?", s.table)
+	return s.session.Query(query, id, answer, int(timeutil.Seconds(ttl))).WithContext(ctx).Exec()
+}
@@ -0,0 +1,79 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package cassandrastore
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+var testSession *gocql.Session
+
+func TestMain(m *testing.M) {
+	cluster := gocql.NewCluster("localhost")
+	cluster.Timeout = time.Second
+	cluster.ConnectTimeout = time.Second
+	session, err := cluster.CreateSession()
+	if err == nil {
+		queries := []string{
+			"CREATE KEYSPACE IF NOT EXISTS captchas WITH replication = {'class': 'SimpleStrategy', 'replication_factor': 1}",
+			"CREATE TABLE IF NOT EXISTS captchas.captchas (id text PRIMARY KEY, answer text)",
+		}
+		for _, query := range queries {
+			if err = session.Query(query).Exec(); err != nil {
+				panic(err)
+			}
+		}
+		testSession = session
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestNew(t *testing.T) {
+	expiration := 5 * time.Minute
+	table := "foo"
+	s, _ := New(testSession, Expiration(expiration), Table(table)).(*store)
+	if s.expiration != expiration {
+		t.Errorf("expected expiration %v, got %v", expiration, s.expiration)
+	}
+	if s.table != table {
+		t.Errorf("expected table %s, got %s", table, s.table)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	if testSession == nil {
+		t.Skip("cassandra server is unavailable")
+	}
+
+	s := New(testSession, Table("captchas.captchas"))
+	_, err := s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package captchas
+
+import "encoding/json"
+
+// Codec defines how stores serialize values beyond bare answers, such as
+// metadata, so that all backends serialize them consistently.
+type Codec interface {
+	// Marshal returns the encoding of v.
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal decodes the data and stores the result in the value
+	// pointed to by v.
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSONCodec is a Codec that uses encoding/json, it is the default codec of
+// stores.
+var JSONCodec Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
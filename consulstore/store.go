@@ -0,0 +1,119 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package consulstore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/hashicorp/consul/api"
+)
+
+// Option is a function that receives a pointer of consul store.
+type Option func(s *store)
+
+// Prefix sets the prefix of key.
+func Prefix(prefix string) Option {
+	return func(s *store) {
+		s.prefix = prefix
+	}
+}
+
+// Expiration sets the expiration, Consul requires the session TTL to be
+// between 10 seconds and 24 hours.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+type store struct {
+	client     *api.Client
+	prefix     string
+	expiration time.Duration
+}
+
+// New returns a consul store.
+func New(client *api.Client, opts ...Option) captchas.Store {
+	s := &store{
+		client:     client,
+		prefix:     "captchas",
+		expiration: 10 * time.Minute,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	return s
+}
+
+func (s *store) getKey(id string) string {
+	return s.prefix + "/" + id
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	kv := s.client.KV()
+	pair, _, err := kv.Get(s.getKey(id), &api.QueryOptions{RequireConsistent: true})
+	if err != nil {
+		return "", err
+	}
+	if pair == nil {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+
+	if clear {
+		// check-and-set guarantees that only one of concurrent consumers
+		// is able to delete the captcha.
+		ok, _, err := kv.DeleteCAS(pair, nil)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return "", captchas.ErrIncorrectCaptcha
+		}
+	}
+
+	// Consul may take up to twice of the session TTL to invalidate it,
+	// the flags holds the actual expiration.
+	if time.Now().UnixNano() > int64(pair.Flags) {
+		return "", captchas.ErrExpiredCaptcha
+	}
+
+	return string(pair.Value), nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	key := s.getKey(id)
+	session, _, err := s.client.Session().Create(&api.SessionEntry{
+		Name:     key,
+		TTL:      s.expiration.String(),
+		Behavior: api.SessionBehaviorDelete,
+		// zero value means the default 15 seconds lock delay.
+		LockDelay: time.Nanosecond,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	ok, _, err := s.client.KV().Acquire(&api.KVPair{
+		Key:     key,
+		Value:   []byte(answer),
+		Flags:   uint64(time.Now().Add(s.expiration).UnixNano()),
+		Session: session,
+	}, nil)
+	if err == nil && !ok {
+		err = fmt.Errorf("failed to acquire key: %s", key)
+	}
+	if err != nil {
+		// the session is useless without the key.
+		s.client.Session().Destroy(session, nil)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package consulstore
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+var testClient *api.Client
+
+func TestMain(m *testing.M) {
+	client, err := api.NewClient(api.DefaultConfig())
+	if err != nil {
+		panic(err)
+	}
+	if _, err = client.Status().Leader(); err == nil {
+		testClient = client
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestPrefixOption(t *testing.T) {
+	s := &store{}
+	prefix := "foo"
+	Prefix(prefix)(s)
+	if s.prefix != prefix {
+		t.Errorf("expected prefix %s, got %s", prefix, s.prefix)
+	}
+}
+
+func TestGetKey(t *testing.T) {
+	prefix := "foo"
+	s := &store{prefix: prefix}
+	key := "bar"
+	if s.getKey(key) != prefix+"/"+key {
+		t.Errorf("expected key %s, got %s", prefix+"/"+key, s.getKey(key))
+	}
+}
+
+func TestNew(t *testing.T) {
+	expiration := 5 * time.Minute
+	prefix := "foo"
+	s, _ := New(testClient, Expiration(expiration), Prefix(prefix)).(*store)
+	if s.expiration != expiration {
+		t.Errorf("expected expiration %v, got %v", expiration, s.expiration)
+	}
+	if s.prefix != prefix {
+		t.Errorf("expected prefix %s, got %s", prefix, s.prefix)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	if testClient == nil {
+		t.Skip("consul agent is unavailable")
+	}
+
+	s := New(testClient)
+	_, err := s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+func TestStoreSetDestroysSession(t *testing.T) {
+	var destroyed []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/session/create":
+			io.WriteString(w, `{"ID":"session"}`)
+		case strings.HasPrefix(r.URL.Path, "/v1/session/destroy/"):
+			destroyed = append(destroyed, strings.TrimPrefix(r.URL.Path, "/v1/session/destroy/"))
+			io.WriteString(w, "true")
+		case strings.HasPrefix(r.URL.Path, "/v1/kv/"):
+			// the key is locked by another session.
+			io.WriteString(w, "false")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := api.NewClient(&api.Config{Address: srv.Listener.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = New(client).Set("foo", "bar"); err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+	if len(destroyed) != 1 || destroyed[0] != "session" {
+		t.Errorf("expected the session to be destroyed, got %v", destroyed)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package cosmosstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+	"github.com/clevergo/captchas"
+)
+
+// Option is a function that receives a pointer of cosmos store.
+type Option func(s *store)
+
+// Expiration sets the expiration, it will be rounded up to seconds
+// since Cosmos DB TTL is measured in seconds.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+type item struct {
+	ID     string `json:"id"`
+	Answer string `json:"answer"`
+	TTL    int    `json:"ttl"`
+}
+
+type store struct {
+	container  *azcosmos.ContainerClient
+	expiration time.Duration
+}
+
+// New returns a cosmos store, the container's partition key path must be
+// "/id", and time to live must be enabled, so that per-item TTL takes effect.
+func New(container *azcosmos.ContainerClient, opts ...Option) captchas.Store {
+	s := &store{
+		container:  container,
+		expiration: 10 * time.Minute,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	return s
+}
+
+func (s *store) ttl() int {
+	ttl := int(s.expiration / time.Second)
+	if s.expiration%time.Second > 0 {
+		ttl++
+	}
+	return ttl
+}
+
+func isStatus(err error, code int) bool {
+	var respErr *azcore.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == code
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	pk := azcosmos.NewPartitionKeyString(id)
+	resp, err := s.container.ReadItem(ctx, pk, id, nil)
+	if isStatus(err, http.StatusNotFound) {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var i item
+	if err = json.Unmarshal(resp.Value, &i); err != nil {
+		return "", err
+	}
+
+	if clear {
+		// the ETag guarantees that only one of concurrent consumers is
+		// able to delete the captcha.
+		_, err = s.container.DeleteItem(ctx, pk, id, &azcosmos.ItemOptions{IfMatchEtag: &resp.ETag})
+		if isStatus(err, http.StatusNotFound) || isStatus(err, http.StatusPreconditionFailed) {
+			return "", captchas.ErrIncorrectCaptcha
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return i.Answer, nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	data, err := json.Marshal(item{ID: id, Answer: answer, TTL: s.ttl()})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.container.UpsertItem(ctx, azcosmos.NewPartitionKeyString(id), data, nil)
+	return err
+}
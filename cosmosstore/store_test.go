@@ -0,0 +1,103 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package cosmosstore
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azcosmos"
+)
+
+var testContainer *azcosmos.ContainerClient
+
+func TestMain(m *testing.M) {
+	// tests run against the emulator only, e.g. "AccountEndpoint=https://localhost:8081/;AccountKey=...".
+	if conn := os.Getenv("COSMOS_CONNECTION_STRING"); conn != "" {
+		client, err := azcosmos.NewClientFromConnectionString(conn, nil)
+		if err != nil {
+			panic(err)
+		}
+		testContainer, err = client.NewContainer("captchas", "captchas")
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestNew(t *testing.T) {
+	expiration := 5 * time.Minute
+	s, _ := New(testContainer, Expiration(expiration)).(*store)
+	if s.expiration != expiration {
+		t.Errorf("expected expiration %v, got %v", expiration, s.expiration)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	tests := []struct {
+		expiration time.Duration
+		ttl        int
+	}{
+		{time.Minute, 60},
+		{1500 * time.Millisecond, 2},
+		{time.Millisecond, 1},
+	}
+	for _, test := range tests {
+		s := &store{expiration: test.expiration}
+		if s.ttl() != test.ttl {
+			t.Errorf("expected ttl %d, got %d", test.ttl, s.ttl())
+		}
+	}
+}
+
+func TestIsStatus(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &azcore.ResponseError{StatusCode: http.StatusNotFound})
+	if !isStatus(err, http.StatusNotFound) {
+		t.Errorf("expected status %d", http.StatusNotFound)
+	}
+	if isStatus(err, http.StatusPreconditionFailed) {
+		t.Errorf("unexpected status %d", http.StatusPreconditionFailed)
+	}
+	if isStatus(nil, http.StatusNotFound) {
+		t.Errorf("unexpected status %d of nil error", http.StatusNotFound)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	if testContainer == nil {
+		t.Skip("cosmos emulator is unavailable")
+	}
+
+	s := New(testContainer)
+	_, err := s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
@@ -4,8 +4,31 @@
 
 package captchas
 
+import "context"
+
 // Driver defines how to generate captchas.
 type Driver interface {
 	// Generate generates a new captcha, returns an error if failed.
 	Generate() (Captcha, error)
 }
+
+// Matcher is an optional interface that drivers can implement to compare
+// the actual value with the answer by themselves, such as drivers whose
+// answers are positions that match within a tolerance. Manager.Verify
+// delegates to it if the driver implements it, the store must not be a
+// Verifier, unless it also implements MatchVerifier.
+type Matcher interface {
+	// Match reports whether the actual value matches the answer.
+	Match(actual, answer string) bool
+}
+
+// ContextMatcher is an optional interface that drivers can implement to
+// verify the actual value by a remote service, such as the siteverify API of
+// reCAPTCHA. Manager.Verify delegates to it instead of Matcher, failures of
+// the service are returned as DriverError rather than ErrIncorrectCaptcha
+// and don't count the attempt, but the captcha is consumed if clear is true.
+type ContextMatcher interface {
+	// MatchContext reports whether the actual value matches the answer, returns
+	// an error if the service failed.
+	MatchContext(ctx context.Context, actual, answer string) (bool, error)
+}
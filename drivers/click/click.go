@@ -0,0 +1,343 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package click provides a click captcha driver, characters are scattered
+// on an image, and the user clicks the prompted ones in order, or all of
+// them in ascending order, see NewOrdering.
+//
+// The submitted value is the clicked points in order, such as "12,34;56,78",
+// see FormatPoints. The answer is the regions of the prompted characters in
+// the same format of rectangles, such as "10,30,40,60;50,70,80,100".
+package click
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"image"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/internal/imaging"
+	"github.com/mojocn/base64Captcha"
+)
+
+// Option is a function that receives a pointer of click driver.
+type Option func(*driver)
+
+// Width sets the width of image.
+func Width(width int) Option {
+	return func(d *driver) {
+		d.width = width
+	}
+}
+
+// Height sets the height of image.
+func Height(height int) Option {
+	return func(d *driver) {
+		d.height = height
+	}
+}
+
+// Count sets the number of characters on the image.
+func Count(count int) Option {
+	return func(d *driver) {
+		d.count = count
+	}
+}
+
+// Targets sets the number of characters to click, which is no more than
+// the count.
+func Targets(targets int) Option {
+	return func(d *driver) {
+		d.targets = targets
+	}
+}
+
+// Source sets the characters to choose from.
+func Source(source string) Option {
+	return func(d *driver) {
+		d.source = []rune(source)
+	}
+}
+
+// FontSize sets the font size in pixels.
+func FontSize(size float64) Option {
+	return func(d *driver) {
+		d.fontSize = size
+	}
+}
+
+// Tolerance sets how many pixels a click may miss the region of character.
+func Tolerance(tolerance int) Option {
+	return func(d *driver) {
+		d.tolerance = tolerance
+	}
+}
+
+// Ascending sets whether to click all of the characters in ascending
+// order, instead of the prompted ones, the Targets option is ignored then.
+func Ascending(ascending bool) Option {
+	return func(d *driver) {
+		d.ascending = ascending
+	}
+}
+
+type driver struct {
+	ascending bool
+	width     int
+	height    int
+	count     int
+	targets   int
+	source    []rune
+	fontSize  float64
+	tolerance int
+}
+
+// New returns a click driver.
+func New(opts ...Option) captchas.Driver {
+	d := &driver{
+		width:     300,
+		height:    200,
+		count:     6,
+		targets:   3,
+		source:    []rune("ABCDEFGHJKLMNPQRSTUVWXYZ23456789"),
+		fontSize:  36,
+		tolerance: 4,
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// NewOrdering returns a click driver that requires clicking all digits in
+// ascending order, recognizing them is not enough, since the prompt doesn't
+// reveal them.
+func NewOrdering(opts ...Option) captchas.Driver {
+	return New(append([]Option{Source("0123456789"), Count(5), Ascending(true)}, opts...)...)
+}
+
+// Generate implements Driver.Generate.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	count := min(d.count, len(d.source))
+	targets := d.targets
+	if d.ascending {
+		targets = count
+	}
+	if targets <= 0 || targets > count {
+		return nil, errors.New("click: the number of targets exceeds the number of characters")
+	}
+
+	img := imaging.Background(d.width, d.height)
+	face := imaging.Face(d.fontSize)
+	chars := make([]string, 0, count)
+	regions := make([]image.Rectangle, 0, count)
+	for _, i := range rand.Perm(len(d.source))[:count] {
+		char := string(d.source[i])
+		bounds := imaging.TextBounds(char, face)
+		// retries to place the character without overlapping others.
+		for attempt := 0; attempt < 100; attempt++ {
+			at := image.Pt(rand.IntN(max(d.width-bounds.Dx(), 1)), rand.IntN(max(d.height-bounds.Dy(), 1)))
+			region := bounds.Add(at)
+			if overlaps(region, regions) && attempt < 99 {
+				continue
+			}
+			imaging.DrawText(img, char, at, face, imaging.RandomColor())
+			chars = append(chars, char)
+			regions = append(regions, region)
+			break
+		}
+	}
+
+	c := &Captcha{
+		id:      base64Captcha.RandomId(),
+		targets: chars[:targets],
+		regions: regions[:targets],
+		image:   imaging.DataURI(img),
+	}
+	c.prompt = strings.Join(c.targets, " ")
+	if d.ascending {
+		sort.Sort(byTarget{c})
+		c.prompt = "Click the characters in ascending order"
+	}
+	return c, nil
+}
+
+func overlaps(region image.Rectangle, regions []image.Rectangle) bool {
+	for _, r := range regions {
+		if region.Overlaps(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// byTarget sorts the targets and regions of the captcha by targets.
+type byTarget struct {
+	*Captcha
+}
+
+func (c byTarget) Len() int {
+	return len(c.targets)
+}
+
+func (c byTarget) Less(i, j int) bool {
+	return c.targets[i] < c.targets[j]
+}
+
+func (c byTarget) Swap(i, j int) {
+	c.targets[i], c.targets[j] = c.targets[j], c.targets[i]
+	c.regions[i], c.regions[j] = c.regions[j], c.regions[i]
+}
+
+// Match implements Matcher.Match, the answer matches if each point is in
+// the region of the corresponding character.
+func (d *driver) Match(actual, answer string) bool {
+	points, err := ParsePoints(actual)
+	if err != nil {
+		return false
+	}
+	regions, err := parseRects(answer)
+	if err != nil || len(points) != len(regions) {
+		return false
+	}
+	for i, p := range points {
+		if !p.In(regions[i].Inset(-d.tolerance)) {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatPoints formats the points as the submitted value, such as
+// "12,34;56,78".
+func FormatPoints(points []image.Point) string {
+	s := make([]string, len(points))
+	for i, p := range points {
+		s[i] = strconv.Itoa(p.X) + "," + strconv.Itoa(p.Y)
+	}
+	return strings.Join(s, ";")
+}
+
+// ParsePoints parses the points formatted by FormatPoints.
+func ParsePoints(s string) ([]image.Point, error) {
+	values, err := parseInts(s, 2)
+	if err != nil {
+		return nil, err
+	}
+	points := make([]image.Point, len(values))
+	for i, v := range values {
+		points[i] = image.Pt(v[0], v[1])
+	}
+	return points, nil
+}
+
+func formatRects(rects []image.Rectangle) string {
+	s := make([]string, len(rects))
+	for i, r := range rects {
+		s[i] = strings.Join([]string{
+			strconv.Itoa(r.Min.X), strconv.Itoa(r.Min.Y), strconv.Itoa(r.Max.X), strconv.Itoa(r.Max.Y),
+		}, ",")
+	}
+	return strings.Join(s, ";")
+}
+
+func parseRects(s string) ([]image.Rectangle, error) {
+	values, err := parseInts(s, 4)
+	if err != nil {
+		return nil, err
+	}
+	rects := make([]image.Rectangle, len(values))
+	for i, v := range values {
+		rects[i] = image.Rect(v[0], v[1], v[2], v[3])
+	}
+	return rects, nil
+}
+
+// parseInts parses groups of n integers, groups are separated by
+// semicolons, and integers are separated by commas.
+func parseInts(s string, n int) ([][]int, error) {
+	if s == "" {
+		return nil, errors.New("click: empty value")
+	}
+	groups := strings.Split(s, ";")
+	values := make([][]int, len(groups))
+	for i, group := range groups {
+		fields := strings.Split(group, ",")
+		if len(fields) != n {
+			return nil, errors.New("click: invalid value " + strconv.Quote(s))
+		}
+		values[i] = make([]int, n)
+		for j, field := range fields {
+			v, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				return nil, err
+			}
+			values[i][j] = v
+		}
+	}
+	return values, nil
+}
+
+var tmpl = template.Must(template.New("click").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+<p>{{ .captcha.Prompt }}</p>
+<img src="{{ .image }}" />
+`))
+
+// Captcha is a click captcha.
+type Captcha struct {
+	id      string
+	prompt  string
+	targets []string
+	regions []image.Rectangle
+	image   string
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer, it is the regions of the targets.
+func (c *Captcha) Answer() string {
+	return formatRects(c.regions)
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns the data URI
+// of the image.
+func (c *Captcha) EncodeToString() string {
+	return c.image
+}
+
+// Targets returns the characters to click in order.
+func (c *Captcha) Targets() []string {
+	return c.targets
+}
+
+// Prompt returns the characters to click in order, separated by spaces,
+// or the instruction of ascending order.
+func (c *Captcha) Prompt() string {
+	return c.prompt
+}
+
+// HTMLField implements Captcha.HTMLField, scripts are expected to collect
+// the clicked points and submit them.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":   c,
+		"fieldName": fieldName,
+		"image":     template.URL(c.image),
+	})
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package click
+
+import (
+	"image"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestNew(t *testing.T) {
+	d := New(Width(200), Height(100), Count(5), Targets(2), Source("ABC"), FontSize(24), Tolerance(2)).(*driver)
+	if d.width != 200 || d.height != 100 || d.count != 5 || d.targets != 2 || string(d.source) != "ABC" || d.fontSize != 24 || d.tolerance != 2 {
+		t.Errorf("unexpected driver %+v", d)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	c, err := New().Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := c.(*Captcha)
+	if len(cc.Targets()) != 3 || cc.Prompt() != strings.Join(cc.Targets(), " ") {
+		t.Errorf("unexpected targets %v", cc.Targets())
+	}
+	regions, err := parseRects(c.Answer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := image.Rect(0, 0, 300, 200)
+	points := make([]image.Point, len(regions))
+	for i, r := range regions {
+		if !r.In(bounds) {
+			t.Errorf("expected the region %v in the image", r)
+		}
+		points[i] = r.Min.Add(r.Max).Div(2)
+	}
+	if !New().(captchas.Matcher).Match(FormatPoints(points), c.Answer()) {
+		t.Errorf("expected the centers of regions match %q", c.Answer())
+	}
+	if !strings.HasPrefix(c.EncodeToString(), "data:image/png;base64,") {
+		t.Errorf("expected a PNG data URI, got %.30s", c.EncodeToString())
+	}
+	html := string(c.HTMLField("captcha_id"))
+	if !strings.Contains(html, c.ID()) || !strings.Contains(html, cc.Prompt()) || !strings.Contains(html, `src="data:image/png;base64,`) {
+		t.Errorf("unexpected HTML %.200s", html)
+	}
+
+	if _, err := New(Count(2), Targets(3)).Generate(); err == nil {
+		t.Error("expected an error if targets exceed count")
+	}
+}
+
+func TestNewOrdering(t *testing.T) {
+	d := NewOrdering(Count(4)).(*driver)
+	if !d.ascending || d.count != 4 || string(d.source) != "0123456789" {
+		t.Errorf("unexpected driver %+v", d)
+	}
+	c, err := d.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := c.(*Captcha)
+	targets := cc.Targets()
+	if len(targets) != 4 || !sort.StringsAreSorted(targets) {
+		t.Errorf("expected 4 targets in ascending order, got %v", targets)
+	}
+	if strings.ContainsAny(cc.Prompt(), "0123456789") {
+		t.Errorf("expected the prompt doesn't reveal the digits, got %q", cc.Prompt())
+	}
+	regions, err := parseRects(c.Answer())
+	if err != nil || len(regions) != 4 {
+		t.Fatalf("unexpected regions %v, %v", regions, err)
+	}
+	points := make([]image.Point, len(regions))
+	for i, r := range regions {
+		points[i] = r.Min.Add(r.Max).Div(2)
+	}
+	if !d.Match(FormatPoints(points), c.Answer()) {
+		t.Errorf("expected the centers of regions match %q", c.Answer())
+	}
+	points[0], points[1] = points[1], points[0]
+	if d.Match(FormatPoints(points), c.Answer()) {
+		t.Error("expected clicking out of order doesn't match")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	m := New(Tolerance(2)).(captchas.Matcher)
+	answer := "10,10,20,20;30,30,40,40"
+	tests := []struct {
+		actual string
+		match  bool
+	}{
+		{"15,15;35,35", true},
+		{"8,8;41,41", true},
+		{"15,15;42,42", false},
+		{"7,15;35,35", false},
+		{"35,35;15,15", false},
+		{"15,15", false},
+		{"15,15;35,35;35,35", false},
+		{"15,x;35,35", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if match := m.Match(test.actual, answer); match != test.match {
+			t.Errorf("expected %q matches %t, got %t", test.actual, test.match, match)
+		}
+	}
+}
+
+func TestPoints(t *testing.T) {
+	points := []image.Point{image.Pt(1, 2), image.Pt(30, 40)}
+	s := FormatPoints(points)
+	if s != "1,2;30,40" {
+		t.Errorf("unexpected points %q", s)
+	}
+	parsed, err := ParsePoints(s)
+	if err != nil || len(parsed) != 2 || parsed[0] != points[0] || parsed[1] != points[1] {
+		t.Errorf("unexpected points %v, %v", parsed, err)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package email provides an email one-time code driver, which generates a
+// numeric code, the code is saved by the manager as usual, and delivered by
+// Captcha.Send through a Sender. Combine it with captchas.MaxAttempts to
+// limit guesses.
+package email
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"net/mail"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/internal/otp"
+	"github.com/mojocn/base64Captcha"
+)
+
+// Option is a function that receives a pointer of email driver.
+type Option func(*driver)
+
+// Length sets the number of digits of codes.
+func Length(length int) Option {
+	return func(d *driver) {
+		d.length = length
+	}
+}
+
+// Subject sets the subject of messages.
+func Subject(subject string) Option {
+	return func(d *driver) {
+		d.subject = subject
+	}
+}
+
+// Body sets the template of the message body, which receives the code as
+// ".Code".
+func Body(tmpl *texttemplate.Template) Option {
+	return func(d *driver) {
+		d.body = tmpl
+	}
+}
+
+var defaultBody = texttemplate.Must(texttemplate.New("body").Parse("Your verification code is {{ .Code }}.\n"))
+
+type driver struct {
+	sender  Sender
+	length  int
+	subject string
+	body    *texttemplate.Template
+}
+
+// New returns an email driver of the sender.
+func New(sender Sender, opts ...Option) captchas.Driver {
+	d := &driver{
+		sender:  sender,
+		length:  6,
+		subject: "Verification code",
+		body:    defaultBody,
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// Generate implements Driver.Generate, the code is not sent until calling
+// Captcha.Send, so that it is saved before delivery.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	code, err := otp.Code(d.length)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Captcha{
+		id:     base64Captcha.RandomId(),
+		code:   code,
+		driver: d,
+	}, nil
+}
+
+// Match implements Matcher.Match, codes are compared in constant time.
+func (d *driver) Match(actual, answer string) bool {
+	return otp.Match(actual, answer)
+}
+
+var tmpl = template.Must(template.New("email").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+`))
+
+// Captcha is an email captcha.
+type Captcha struct {
+	id     string
+	code   string
+	driver *driver
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer, it is the code.
+func (c *Captcha) Answer() string {
+	return c.code
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns an empty
+// string, since the code is delivered by email only.
+func (c *Captcha) EncodeToString() string {
+	return ""
+}
+
+// Send sends the code to the email address.
+func (c *Captcha) Send(ctx context.Context, to string) error {
+	addr, err := mail.ParseAddress(to)
+	if err != nil {
+		return err
+	}
+	body := &strings.Builder{}
+	if err := c.driver.body.Execute(body, map[string]interface{}{"Code": c.code}); err != nil {
+		return err
+	}
+	return c.driver.sender.Send(ctx, Message{
+		To:      addr.Address,
+		Subject: c.driver.subject,
+		Body:    body.String(),
+	})
+}
+
+// HTMLField implements Captcha.HTMLField, it renders the hidden ID only.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":   c,
+		"fieldName": fieldName,
+	})
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package email
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/memstore"
+)
+
+func TestNew(t *testing.T) {
+	body := template.Must(template.New("body").Parse("{{ .Code }}"))
+	d := New(nil, Length(4), Subject("Code"), Body(body)).(*driver)
+	if d.length != 4 || d.subject != "Code" || d.body != body {
+		t.Errorf("unexpected driver %+v", d)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var sent []Message
+	sender := SenderFunc(func(ctx context.Context, msg Message) error {
+		sent = append(sent, msg)
+		return nil
+	})
+	manager := captchas.New(memstore.New(), New(sender, Length(8)))
+	c, err := manager.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Answer()) != 8 || c.EncodeToString() != "" || strings.Contains(string(c.HTMLField("captcha_id")), c.Answer()) {
+		t.Errorf("unexpected captcha %+v", c)
+	}
+	if err := c.(*Captcha).Send(context.Background(), "Foo <foo@example.com>"); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || sent[0].To != "foo@example.com" || sent[0].Subject != "Verification code" || !strings.Contains(sent[0].Body, c.Answer()) {
+		t.Errorf("unexpected messages %+v", sent)
+	}
+	if err := c.(*Captcha).Send(context.Background(), "invalid"); err == nil {
+		t.Error("expected an error of invalid address")
+	}
+	if err := manager.Verify(c.ID(), c.Answer(), true); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	failed := errors.New("failed")
+	c, _ = New(SenderFunc(func(ctx context.Context, msg Message) error {
+		return failed
+	})).Generate()
+	if err := c.(*Captcha).Send(context.Background(), "foo@example.com"); err != failed {
+		t.Errorf("expected error %v, got %v", failed, err)
+	}
+}
+
+func TestSMTPSenderMessage(t *testing.T) {
+	s := NewSMTPSender("localhost:25", nil, "noreply@example.com").(*smtpSender)
+	msg := string(s.message(Message{To: "foo@example.com", Subject: "验证码", Body: "123456"}))
+	for _, line := range []string{"From: noreply@example.com\r\n", "To: foo@example.com\r\n", "Subject: =?utf-8?q?", "\r\n\r\n123456"} {
+		if !strings.Contains(msg, line) {
+			t.Errorf("expected %q in message %q", line, msg)
+		}
+	}
+}
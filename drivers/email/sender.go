@@ -0,0 +1,63 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package email
+
+import (
+	"bytes"
+	"context"
+	"mime"
+	"net/smtp"
+)
+
+// Message is an email message.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender sends email messages, such as SMTP and SES.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SenderFunc is an adapter to use ordinary functions as senders.
+type SenderFunc func(ctx context.Context, msg Message) error
+
+// Send implements Sender.Send.
+func (f SenderFunc) Send(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+type smtpSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPSender returns a sender that sends plain text messages through the
+// SMTP server of the address, such as "smtp.example.com:587".
+func NewSMTPSender(addr string, auth smtp.Auth, from string) Sender {
+	return &smtpSender{addr: addr, auth: auth, from: from}
+}
+
+func (s *smtpSender) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, s.message(msg))
+}
+
+func (s *smtpSender) message(msg Message) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("From: " + s.from + "\r\n")
+	buf.WriteString("To: " + msg.To + "\r\n")
+	buf.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(msg.Body)
+	return buf.Bytes()
+}
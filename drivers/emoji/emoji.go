@@ -0,0 +1,168 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package emoji provides an emoji captcha driver, the user picks the
+// prompted sequence of emoji from a shuffled palette. The emoji are rendered
+// by browsers as text, so that it is friendly to mobile users, but not as
+// hard for bots as the image drivers.
+package emoji
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/clevergo/captchas"
+	"github.com/mojocn/base64Captcha"
+)
+
+// DefaultSource is the default emoji to choose from.
+var DefaultSource = []string{
+	"🐶", "🐱", "🐭", "🐰", "🦊", "🐻", "🐼", "🐨",
+	"🐯", "🦁", "🐮", "🐷", "🐸", "🐵", "🐔", "🐧",
+	"🍎", "🍌", "🍇", "🍓", "🍒", "🍍", "🥕", "🌽",
+	"⚽", "🏀", "🚗", "🚲", "✈", "🚀", "⏰", "🎈",
+}
+
+// Option is a function that receives a pointer of emoji driver.
+type Option func(*driver)
+
+// Length sets the number of emoji to pick.
+func Length(length int) Option {
+	return func(d *driver) {
+		d.length = length
+	}
+}
+
+// PaletteSize sets the number of emoji of the palette, which includes the
+// prompted ones.
+func PaletteSize(size int) Option {
+	return func(d *driver) {
+		d.paletteSize = size
+	}
+}
+
+// Source sets the emoji to choose from.
+func Source(source []string) Option {
+	return func(d *driver) {
+		d.source = source
+	}
+}
+
+type driver struct {
+	length      int
+	paletteSize int
+	source      []string
+}
+
+// New returns an emoji driver.
+func New(opts ...Option) captchas.Driver {
+	d := &driver{
+		length:      4,
+		paletteSize: 8,
+		source:      DefaultSource,
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// Generate implements Driver.Generate.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	size := max(d.paletteSize, d.length)
+	if d.length <= 0 || size > len(d.source) {
+		return nil, errors.New("emoji: the palette size exceeds the number of source")
+	}
+	palette := make([]string, size)
+	for i, index := range rand.Perm(len(d.source))[:size] {
+		palette[i] = d.source[index]
+	}
+	// the sequence may repeat emoji of the palette.
+	sequence := make([]string, d.length)
+	for i := range sequence {
+		sequence[i] = palette[rand.IntN(size)]
+	}
+	rand.Shuffle(size, func(i, j int) {
+		palette[i], palette[j] = palette[j], palette[i]
+	})
+
+	return &Captcha{
+		id:       base64Captcha.RandomId(),
+		sequence: sequence,
+		palette:  palette,
+	}, nil
+}
+
+// Match implements Matcher.Match, the answer matches if the sequences are
+// the same, regardless of whitespaces and variation selectors.
+func (d *driver) Match(actual, answer string) bool {
+	return normalize(actual) == normalize(answer)
+}
+
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\uFE0E' || r == '\uFE0F' || r == ' ' || r == ',' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+var tmpl = template.Must(template.New("emoji").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+<p>{{ .captcha.EncodeToString }}</p>
+<div>{{ range .captcha.Palette }}<button type="button" value="{{ . }}">{{ . }}</button>{{ end }}</div>
+`))
+
+// Captcha is an emoji captcha.
+type Captcha struct {
+	id       string
+	sequence []string
+	palette  []string
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer, it is the sequence of emoji.
+func (c *Captcha) Answer() string {
+	return strings.Join(c.sequence, "")
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns the sequence
+// separated by spaces.
+func (c *Captcha) EncodeToString() string {
+	return strings.Join(c.sequence, " ")
+}
+
+// Sequence returns the sequence of emoji to pick.
+func (c *Captcha) Sequence() []string {
+	return slices.Clone(c.sequence)
+}
+
+// Palette returns the shuffled emoji to pick from.
+func (c *Captcha) Palette() []string {
+	return slices.Clone(c.palette)
+}
+
+// HTMLField implements Captcha.HTMLField, scripts are expected to collect
+// the picked emoji and submit them.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":   c,
+		"fieldName": fieldName,
+	})
+	return template.HTML(buf.String())
+}
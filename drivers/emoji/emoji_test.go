@@ -0,0 +1,68 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package emoji
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestNew(t *testing.T) {
+	source := []string{"🐶", "🐱"}
+	d := New(Length(2), PaletteSize(2), Source(source)).(*driver)
+	if d.length != 2 || d.paletteSize != 2 || !slices.Equal(d.source, source) {
+		t.Errorf("unexpected driver %+v", d)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	c, err := New().Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec := c.(*Captcha)
+	sequence, palette := ec.Sequence(), ec.Palette()
+	if len(sequence) != 4 || len(palette) != 8 {
+		t.Fatalf("unexpected sequence %v and palette %v", sequence, palette)
+	}
+	for _, e := range sequence {
+		if !slices.Contains(palette, e) {
+			t.Errorf("expected %s in the palette %v", e, palette)
+		}
+	}
+	if c.Answer() != strings.Join(sequence, "") || c.EncodeToString() != strings.Join(sequence, " ") {
+		t.Errorf("unexpected answer %q", c.Answer())
+	}
+	html := string(c.HTMLField("captcha_id"))
+	if !strings.Contains(html, c.ID()) || strings.Count(html, "<button") != 8 {
+		t.Errorf("unexpected HTML %.300s", html)
+	}
+
+	if _, err := New(PaletteSize(3), Source([]string{"🐶", "🐱"})).Generate(); err == nil {
+		t.Error("expected an error if the palette size exceeds the number of source")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	m := New().(captchas.Matcher)
+	tests := []struct {
+		actual string
+		match  bool
+	}{
+		{"🐶🐱✈", true},
+		{"🐶 🐱 ✈️", true},
+		{"🐶,🐱,✈", true},
+		{"🐱🐶✈", false},
+		{"🐶🐱", false},
+	}
+	for _, test := range tests {
+		if match := m.Match(test.actual, "🐶🐱✈"); match != test.match {
+			t.Errorf("expected %q matches %t, got %t", test.actual, test.match, match)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package drivers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"math/rand/v2"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/internal/imaging"
+	"github.com/mojocn/base64Captcha"
+)
+
+// GIFOption is a function that receives a pointer of GIF driver.
+type GIFOption func(*gifDriver)
+
+// GIFStringOptions sets the options of string driver, such as size, length,
+// source, noise count and background color, fonts are not supported.
+func GIFStringOptions(opts ...StringOption) GIFOption {
+	return func(d *gifDriver) {
+		for _, f := range opts {
+			f(d.str)
+		}
+	}
+}
+
+// GIFFrames sets the number of frames, two at least.
+func GIFFrames(frames int) GIFOption {
+	return func(d *gifDriver) {
+		d.frames = frames
+	}
+}
+
+// GIFDelay sets the delay of each frame.
+func GIFDelay(delay time.Duration) GIFOption {
+	return func(d *gifDriver) {
+		d.delay = delay
+	}
+}
+
+type gifDriver struct {
+	str    *str
+	frames int
+	delay  time.Duration
+}
+
+// NewGIF returns a GIF driver, each frame shows a part of the characters,
+// and the noise moves between frames, so that no single frame reveals the
+// answer.
+func NewGIF(opts ...GIFOption) captchas.Driver {
+	d := &gifDriver{
+		str: &str{
+			height:     80,
+			width:      220,
+			noiseCount: 30,
+			length:     4,
+			source:     defaultStringSource,
+		},
+		frames: 6,
+		delay:  200 * time.Millisecond,
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+type gifDot struct {
+	x, y, dx, dy int
+	c            color.RGBA
+}
+
+// Generate implements Driver.Generate.
+func (d *gifDriver) Generate() (captchas.Captcha, error) {
+	s := d.str
+	answer := base64Captcha.RandText(s.length, s.source)
+	chars := []rune(answer)
+	bgColor := color.RGBA{R: 200 + uint8(rand.IntN(56)), G: 200 + uint8(rand.IntN(56)), B: 200 + uint8(rand.IntN(56)), A: 0xff}
+	if s.bgColor != nil {
+		bgColor = *s.bgColor
+	}
+	face := imaging.Face(float64(s.height) * 0.6)
+	slot := s.width / max(len(chars), 1)
+	colors := make([]color.RGBA, len(chars))
+	for i := range colors {
+		colors[i] = darkColor()
+	}
+	dots := make([]gifDot, s.noiseCount)
+	for i := range dots {
+		dots[i] = gifDot{rand.IntN(s.width), rand.IntN(s.height), rand.IntN(7) - 3, rand.IntN(7) - 3, darkColor()}
+	}
+
+	g := &gif.GIF{}
+	bounds := image.Rect(0, 0, s.width, s.height)
+	for f := 0; f < max(d.frames, 2); f++ {
+		img := image.NewRGBA(bounds)
+		draw.Draw(img, bounds, image.NewUniform(bgColor), image.Point{}, draw.Src)
+		for i, char := range chars {
+			// each frame shows every other character, in turns.
+			if (i+f)%2 != 0 {
+				continue
+			}
+			text := string(char)
+			b := imaging.TextBounds(text, face)
+			at := image.Pt(i*slot+(slot-b.Dx())/2+rand.IntN(7)-3, (s.height-b.Dy())/2+rand.IntN(7)-3)
+			imaging.DrawText(img, text, at, face, colors[i])
+		}
+		for i := range dots {
+			dots[i].x = (dots[i].x + dots[i].dx + s.width) % s.width
+			dots[i].y = (dots[i].y + dots[i].dy + s.height) % s.height
+			imaging.FillCircle(img, dots[i].x, dots[i].y, 2, dots[i].c)
+		}
+		frame := image.NewPaletted(bounds, palette.Plan9)
+		draw.Draw(frame, bounds, img, image.Point{}, draw.Src)
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, int(d.delay/(10*time.Millisecond)))
+	}
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return nil, err
+	}
+
+	return newCaptcha(base64Captcha.RandomId(), answer, htmlTagIMG, &encodedItem{mimeType: "image/gif", data: buf.Bytes()}), nil
+}
+
+func darkColor() color.RGBA {
+	return color.RGBA{R: uint8(rand.IntN(150)), G: uint8(rand.IntN(150)), B: uint8(rand.IntN(150)), A: 0xff}
+}
+
+// encodedItem is an encoded image, which implements base64Captcha.Item.
+type encodedItem struct {
+	mimeType string
+	data     []byte
+}
+
+func (item *encodedItem) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(item.data)
+	return int64(n), err
+}
+
+func (item *encodedItem) EncodeB64string() string {
+	return "data:" + item.mimeType + ";base64," + base64.StdEncoding.EncodeToString(item.data)
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package drivers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/gif"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewGIF(t *testing.T) {
+	d := NewGIF(GIFFrames(4), GIFDelay(time.Second), GIFStringOptions(StringLength(5), StringWidth(200))).(*gifDriver)
+	if d.frames != 4 || d.delay != time.Second || d.str.length != 5 || d.str.width != 200 || d.str.height != 80 {
+		t.Errorf("unexpected driver %+v", d)
+	}
+}
+
+func TestGIFGenerate(t *testing.T) {
+	d := NewGIF(GIFFrames(3), GIFStringOptions(StringSource("0123456789"), StringLength(6)))
+	c, err := d.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Answer()) != 6 || strings.Trim(c.Answer(), "0123456789") != "" {
+		t.Errorf("unexpected answer %q", c.Answer())
+	}
+	uri := c.EncodeToString()
+	if !strings.HasPrefix(uri, "data:image/gif;base64,") {
+		t.Fatalf("expected a GIF data URI, got %.30s", uri)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(uri, "data:image/gif;base64,"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Image) != 3 || g.Delay[0] != 20 {
+		t.Errorf("expected 3 frames of delay 20, got %d frames of delay %v", len(g.Image), g.Delay)
+	}
+	if !strings.Contains(string(c.HTMLField("captcha_id")), `src="data:image/gif;base64,`) {
+		t.Error("expected an image of GIF")
+	}
+}
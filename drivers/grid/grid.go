@@ -0,0 +1,215 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package grid provides an image grid captcha driver, the user selects all
+// tiles of the prompted label, such as "select all triangles". Tiles are
+// indexed from 0, left to right and top to bottom, the submitted value and
+// the answer are the indexes separated by commas, such as "0,4,7".
+package grid
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"image"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/internal/imaging"
+	"github.com/clevergo/captchas/drivers/tiles"
+	"github.com/mojocn/base64Captcha"
+)
+
+// Option is a function that receives a pointer of grid driver.
+type Option func(*driver)
+
+// Size sets the number of rows and columns of the grid, which is at least 2.
+func Size(size int) Option {
+	return func(d *driver) {
+		d.size = max(size, 2)
+	}
+}
+
+// TileSize sets the width and height of tiles in pixels.
+func TileSize(size int) Option {
+	return func(d *driver) {
+		d.tileSize = size
+	}
+}
+
+// Gap sets the gap between tiles in pixels.
+func Gap(gap int) Option {
+	return func(d *driver) {
+		d.gap = gap
+	}
+}
+
+// TileBank sets the bank of tiles, defaults to tiles.NewShapeBank.
+func TileBank(bank tiles.Bank) Option {
+	return func(d *driver) {
+		d.bank = bank
+	}
+}
+
+type driver struct {
+	size     int
+	tileSize int
+	gap      int
+	bank     tiles.Bank
+}
+
+// New returns a grid driver.
+func New(opts ...Option) captchas.Driver {
+	d := &driver{
+		size:     3,
+		tileSize: 80,
+		gap:      4,
+		bank:     tiles.NewShapeBank(),
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// Generate implements Driver.Generate.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	labels := d.bank.Labels()
+	if len(labels) < 2 {
+		return nil, errors.New("grid: the bank must have two labels at least")
+	}
+	label := labels[rand.IntN(len(labels))]
+	others := slices.DeleteFunc(slices.Clone(labels), func(s string) bool {
+		return s == label
+	})
+
+	count := d.size * d.size
+	// one to half of tiles are of the label.
+	indexes := rand.Perm(count)[:1+rand.IntN(max(count/2, 1))]
+	slices.Sort(indexes)
+
+	images := make([]image.Image, count)
+	for i := range images {
+		tileLabel := label
+		if !slices.Contains(indexes, i) {
+			tileLabel = others[rand.IntN(len(others))]
+		}
+		tile, err := d.bank.Tile(tileLabel, d.tileSize)
+		if err != nil {
+			return nil, err
+		}
+		images[i] = tile
+	}
+	img := tiles.Compose(images, d.size, d.tileSize, d.gap)
+
+	return &Captcha{
+		id:      base64Captcha.RandomId(),
+		label:   label,
+		size:    d.size,
+		indexes: indexes,
+		image:   imaging.DataURI(img),
+	}, nil
+}
+
+// Match implements Matcher.Match, the answer matches if the same tiles are
+// selected, regardless of order.
+func (d *driver) Match(actual, answer string) bool {
+	a, err := ParseIndexes(actual)
+	if err != nil {
+		return false
+	}
+	b, err := ParseIndexes(answer)
+	if err != nil {
+		return false
+	}
+	return slices.Equal(a, b)
+}
+
+// FormatIndexes formats the indexes of tiles, such as "0,4,7".
+func FormatIndexes(indexes []int) string {
+	s := make([]string, len(indexes))
+	for i, index := range indexes {
+		s[i] = strconv.Itoa(index)
+	}
+	return strings.Join(s, ",")
+}
+
+// ParseIndexes parses the indexes formatted by FormatIndexes, and returns
+// them sorted without duplicates.
+func ParseIndexes(s string) ([]int, error) {
+	if s == "" {
+		return nil, errors.New("grid: empty value")
+	}
+	fields := strings.Split(s, ",")
+	indexes := make([]int, len(fields))
+	for i, field := range fields {
+		index, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		indexes[i] = index
+	}
+	slices.Sort(indexes)
+	return slices.Compact(indexes), nil
+}
+
+var tmpl = template.Must(template.New("grid").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+<p>{{ .captcha.Label }}</p>
+<img src="{{ .image }}" data-size="{{ .captcha.Size }}" />
+`))
+
+// Captcha is a grid captcha.
+type Captcha struct {
+	id      string
+	label   string
+	size    int
+	indexes []int
+	image   string
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer, it is the indexes of tiles of the label.
+func (c *Captcha) Answer() string {
+	return FormatIndexes(c.indexes)
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns the data URI
+// of the grid.
+func (c *Captcha) EncodeToString() string {
+	return c.image
+}
+
+// Label returns the label of tiles to select.
+func (c *Captcha) Label() string {
+	return c.label
+}
+
+// Size returns the number of rows and columns of the grid.
+func (c *Captcha) Size() int {
+	return c.size
+}
+
+// HTMLField implements Captcha.HTMLField, scripts are expected to collect
+// the selected tiles and submit the indexes.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":   c,
+		"fieldName": fieldName,
+		"image":     template.URL(c.image),
+	})
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package grid
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/tiles"
+)
+
+func TestNew(t *testing.T) {
+	bank := tiles.NewImageBank(nil)
+	d := New(Size(4), TileSize(60), Gap(2), TileBank(bank)).(*driver)
+	if d.size != 4 || d.tileSize != 60 || d.gap != 2 || d.bank != bank {
+		t.Errorf("unexpected driver %+v", d)
+	}
+
+	for _, size := range []int{-1, 0, 1} {
+		d := New(Size(size)).(*driver)
+		if d.size != 2 {
+			t.Errorf("expected size %d to be clamped to %d, got %d", size, 2, d.size)
+		}
+		if _, err := d.Generate(); err != nil {
+			t.Errorf("size %d: expected non error, got %s", size, err)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	bank := tiles.NewImageBank(map[string][]image.Image{
+		"red":  {image.NewUniform(color.RGBA{R: 0xff, A: 0xff})},
+		"blue": {image.NewUniform(color.RGBA{B: 0xff, A: 0xff})},
+	})
+	for _, d := range []captchas.Driver{New(), New(TileBank(bank))} {
+		c, err := d.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		gc := c.(*Captcha)
+		indexes, err := ParseIndexes(c.Answer())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(indexes) < 1 || len(indexes) > 4 || indexes[0] < 0 || indexes[len(indexes)-1] > 8 {
+			t.Errorf("unexpected indexes %v", indexes)
+		}
+		if gc.Label() == "" || gc.Size() != 3 {
+			t.Errorf("unexpected label %q and size %d", gc.Label(), gc.Size())
+		}
+		if !strings.HasPrefix(c.EncodeToString(), "data:image/png;base64,") {
+			t.Errorf("expected a PNG data URI, got %.30s", c.EncodeToString())
+		}
+		html := string(c.HTMLField("captcha_id"))
+		if !strings.Contains(html, c.ID()) || !strings.Contains(html, gc.Label()) {
+			t.Errorf("unexpected HTML %.200s", html)
+		}
+	}
+
+	if _, err := New(TileBank(tiles.NewImageBank(nil))).Generate(); err == nil {
+		t.Error("expected an error if the bank has less than two labels")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	m := New().(captchas.Matcher)
+	tests := []struct {
+		actual string
+		match  bool
+	}{
+		{"0,4,7", true},
+		{"7,0,4", true},
+		{"0, 4, 7, 7", true},
+		{"0,4", false},
+		{"0,4,7,8", false},
+		{"0,4,x", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if match := m.Match(test.actual, "0,4,7"); match != test.match {
+			t.Errorf("expected %q matches %t, got %t", test.actual, test.match, match)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package hcaptcha provides an adapter driver of hCaptcha, which delegates
+// verification to the siteverify API of hcaptcha.com, so that deployments
+// swap providers behind Manager.Generate and Manager.Verify. The token of
+// "h-captcha-response" is the captcha value.
+package hcaptcha
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/internal/siteverify"
+	"github.com/mojocn/base64Captcha"
+)
+
+// VerifyURL is the default URL of siteverify API.
+const VerifyURL = "https://api.hcaptcha.com/siteverify"
+
+// Option is a function that receives a pointer of hCaptcha driver.
+type Option func(*driver)
+
+// MaxScore sets the threshold of risk scores of hCaptcha Enterprise in
+// [0, 1], tokens of higher scores are rejected, scores are not checked by
+// default.
+func MaxScore(score float64) Option {
+	return func(d *driver) {
+		d.verifier.MaxScore = score
+	}
+}
+
+// Hostname sets the expected hostname of the site.
+func Hostname(hostname string) Option {
+	return func(d *driver) {
+		d.verifier.Hostname = hostname
+	}
+}
+
+// HTTPClient sets the HTTP client, defaults to http.DefaultClient.
+func HTTPClient(client *http.Client) Option {
+	return func(d *driver) {
+		d.verifier.Client = client
+	}
+}
+
+// Timeout sets the timeout of verification.
+func Timeout(timeout time.Duration) Option {
+	return func(d *driver) {
+		d.verifier.Timeout = timeout
+	}
+}
+
+// URL sets the URL of siteverify API.
+func URL(url string) Option {
+	return func(d *driver) {
+		d.verifier.URL = url
+	}
+}
+
+type driver struct {
+	siteKey  string
+	verifier *siteverify.Verifier
+}
+
+// New returns a hCaptcha driver of the site key and the secret key.
+func New(siteKey, secret string, opts ...Option) captchas.Driver {
+	d := &driver{
+		siteKey: siteKey,
+		verifier: &siteverify.Verifier{
+			URL:     VerifyURL,
+			Secret:  secret,
+			SiteKey: siteKey,
+			Timeout: 10 * time.Second,
+		},
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// Generate implements Driver.Generate.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	return &Captcha{
+		id:      base64Captcha.RandomId(),
+		siteKey: d.siteKey,
+	}, nil
+}
+
+// Match implements Matcher.Match, the actual value is the token, which is
+// verified by the siteverify API, errors are treated as mismatches.
+func (d *driver) Match(actual, answer string) bool {
+	return answer == "hcaptcha" && d.verifier.Match(actual)
+}
+
+// MatchContext implements ContextMatcher.MatchContext, which is preferred by
+// Manager.Verify, errors of the siteverify API are returned, so that they are
+// distinguishable from mismatches.
+func (d *driver) MatchContext(ctx context.Context, actual, answer string) (bool, error) {
+	if answer != "hcaptcha" {
+		return false, nil
+	}
+	return d.verifier.MatchContext(ctx, actual)
+}
+
+var tmpl = template.Must(template.New("hcaptcha").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+<script src="https://js.hcaptcha.com/1/api.js" async defer></script>
+<div class="h-captcha" data-sitekey="{{ .captcha.SiteKey }}"></div>
+`))
+
+// Captcha is a hCaptcha captcha.
+type Captcha struct {
+	id      string
+	siteKey string
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer, it is the constant "hcaptcha", the
+// token is verified by hCaptcha.
+func (c *Captcha) Answer() string {
+	return "hcaptcha"
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns the site key.
+func (c *Captcha) EncodeToString() string {
+	return c.siteKey
+}
+
+// SiteKey returns the site key.
+func (c *Captcha) SiteKey() string {
+	return c.siteKey
+}
+
+// HTMLField implements Captcha.HTMLField, it renders the widget.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":   c,
+		"fieldName": fieldName,
+	})
+	return template.HTML(buf.String())
+}
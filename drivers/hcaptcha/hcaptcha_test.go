@@ -0,0 +1,97 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package hcaptcha
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/memstore"
+)
+
+func TestNew(t *testing.T) {
+	client := &http.Client{}
+	d := New("site", "secret", MaxScore(0.5), Hostname("example.com"), HTTPClient(client), Timeout(time.Second), URL("url")).(*driver)
+	v := d.verifier
+	if d.siteKey != "site" || v.SiteKey != "site" || v.Secret != "secret" || v.MaxScore != 0.5 || v.Hostname != "example.com" ||
+		v.Client != client || v.Timeout != time.Second || v.URL != "url" {
+		t.Errorf("unexpected driver %+v", v)
+	}
+	if New("site", "secret").(*driver).verifier.URL != VerifyURL {
+		t.Error("expected the default URL")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	c, err := New("site", "secret").Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(c.HTMLField("captcha_id"))
+	for _, s := range []string{c.ID(), `class="h-captcha"`, `data-sitekey="site"`} {
+		if !strings.Contains(html, s) {
+			t.Errorf("expected %q in HTML %s", s, html)
+		}
+	}
+	if c.EncodeToString() != "site" || c.Answer() != "hcaptcha" {
+		t.Errorf("unexpected captcha %+v", c)
+	}
+}
+
+func TestManager(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.PostFormValue("response")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": r.PostFormValue("secret") == "secret" && r.PostFormValue("sitekey") == "site" && token != "invalid",
+			"score":   map[string]float64{"human": 0.1, "bot": 0.9}[token],
+		})
+	}))
+	defer srv.Close()
+
+	manager := captchas.New(memstore.New(), New("site", "secret", URL(srv.URL), MaxScore(0.5)))
+	tests := map[string]error{
+		"human":   nil,
+		"bot":     captchas.ErrIncorrectCaptcha,
+		"invalid": captchas.ErrIncorrectCaptcha,
+	}
+	for token, expected := range tests {
+		c, err := manager.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := manager.Verify(c.ID(), token, true); err != expected {
+			t.Errorf("expected error %v of token %q, got %v", expected, token, err)
+		}
+	}
+}
+
+func TestManagerUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("response") == "broken" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+	}))
+	defer srv.Close()
+
+	manager := captchas.New(memstore.New(), New("site", "secret", URL(srv.URL)), captchas.MaxAttempts(1))
+	c, err := manager.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Verify(c.ID(), "broken", false); !errors.Is(err, captchas.ErrDriverUnavailable) {
+		t.Errorf("expected error %v, got %v", captchas.ErrDriverUnavailable, err)
+	}
+	if err := manager.Verify(c.ID(), "token", true); err != nil {
+		t.Errorf("expected the captcha is still valid, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package honeypot provides an invisible captcha driver, which emits hidden
+// form fields instead of an image, and verifies that the honeypot field,
+// which is invisible to humans, is empty, and that the form wasn't submitted
+// faster than a threshold. It is meant for low-risk forms.
+//
+// The fields of the field name "captcha" are "captcha" of the ID,
+// "captcha_token" of the token and "captcha_website" of the honeypot, Value
+// returns the captcha value of the submitted form.
+package honeypot
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"html/template"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/mojocn/base64Captcha"
+)
+
+// Option is a function that receives a pointer of honeypot driver.
+type Option func(*driver)
+
+// MinDelay sets the minimum duration between generating the captcha and
+// submitting the form.
+func MinDelay(d time.Duration) Option {
+	return func(dr *driver) {
+		dr.minDelay = d
+	}
+}
+
+type driver struct {
+	minDelay time.Duration
+	now      func() time.Time
+}
+
+// New returns a honeypot driver.
+func New(opts ...Option) captchas.Driver {
+	d := &driver{
+		minDelay: 3 * time.Second,
+		now:      time.Now,
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// Generate implements Driver.Generate.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return &Captcha{
+		id:       base64Captcha.RandomId(),
+		token:    hex.EncodeToString(b),
+		issuedAt: d.now(),
+	}, nil
+}
+
+// Match implements Matcher.Match, the answer matches if the token is the
+// same, the honeypot is empty, and the minimum delay has passed.
+func (d *driver) Match(actual, answer string) bool {
+	token, honeypot, _ := strings.Cut(actual, ":")
+	issuedAt, expected, ok := strings.Cut(answer, ":")
+	if !ok || honeypot != "" {
+		return false
+	}
+	ms, err := strconv.ParseInt(issuedAt, 10, 64)
+	if err != nil || d.now().Sub(time.UnixMilli(ms)) < d.minDelay {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// Value returns the captcha value of the submitted form, which consists of
+// the token and the honeypot.
+func Value(form url.Values, fieldName string) string {
+	return form.Get(fieldName+"_token") + ":" + form.Get(fieldName+"_website")
+}
+
+var tmpl = template.Must(template.New("honeypot").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+<input type="hidden" name="{{ .fieldName }}_token" value="{{ .captcha.Token }}">
+<div style="position: absolute; left: -9999px" aria-hidden="true">
+<input type="text" name="{{ .fieldName }}_website" value="" tabindex="-1" autocomplete="off">
+</div>
+`))
+
+// Captcha is a honeypot captcha.
+type Captcha struct {
+	id       string
+	token    string
+	issuedAt time.Time
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer, it is the issued time in milliseconds
+// and the token separated by a colon.
+func (c *Captcha) Answer() string {
+	return strconv.FormatInt(c.issuedAt.UnixMilli(), 10) + ":" + c.token
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns the token.
+func (c *Captcha) EncodeToString() string {
+	return c.token
+}
+
+// Token returns the token.
+func (c *Captcha) Token() string {
+	return c.token
+}
+
+// HTMLField implements Captcha.HTMLField, it renders the hidden fields.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":   c,
+		"fieldName": fieldName,
+	})
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package honeypot
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	d := New(MinDelay(time.Second)).(*driver)
+	if d.minDelay != time.Second {
+		t.Errorf("unexpected driver %+v", d)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	c, err := New().Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc := c.(*Captcha)
+	if len(hc.Token()) != 32 || c.EncodeToString() != hc.Token() || !strings.HasSuffix(c.Answer(), ":"+hc.Token()) {
+		t.Errorf("unexpected captcha %+v", hc)
+	}
+	html := string(c.HTMLField("captcha"))
+	for _, s := range []string{c.ID(), hc.Token(), `name="captcha_token"`, `name="captcha_website"`} {
+		if !strings.Contains(html, s) {
+			t.Errorf("expected %q in HTML %s", s, html)
+		}
+	}
+	if strings.Contains(html, "<img") {
+		t.Error("expected no image")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Now()
+	d := New(MinDelay(3 * time.Second)).(*driver)
+	d.now = func() time.Time {
+		return now
+	}
+	c, _ := d.Generate()
+	token := c.(*Captcha).Token()
+	form := url.Values{"captcha_token": {token}}
+
+	if d.Match(Value(form, "captcha"), c.Answer()) {
+		t.Error("expected submitting too fast doesn't match")
+	}
+	now = now.Add(3 * time.Second)
+	if !d.Match(Value(form, "captcha"), c.Answer()) {
+		t.Error("expected matches after the minimum delay")
+	}
+	form.Set("captcha_website", "http://spam.example")
+	if d.Match(Value(form, "captcha"), c.Answer()) {
+		t.Error("expected the filled honeypot doesn't match")
+	}
+	for _, actual := range []string{"", ":", "other:"} {
+		if d.Match(actual, c.Answer()) {
+			t.Errorf("expected %q doesn't match", actual)
+		}
+	}
+	if d.Match(token+":", token) {
+		t.Error("expected the invalid answer doesn't match")
+	}
+}
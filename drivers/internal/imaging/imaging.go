@@ -0,0 +1,153 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package imaging provides image helpers of the interactive drivers.
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/rand/v2"
+)
+
+// DataURI encodes the image as a PNG data URI.
+func DataURI(img image.Image) string {
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// Background returns a random background of gradient and circles, which is
+// hard to be matched by templates.
+func Background(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	from, to := RandomColor(), RandomColor()
+	for x := 0; x < width; x++ {
+		c := blend(from, to, float64(x)/float64(max(width-1, 1)))
+		for y := 0; y < height; y++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	for i := 0; i < 12; i++ {
+		cx, cy := rand.IntN(width), rand.IntN(height)
+		r := 4 + rand.IntN(max(height/4, 1))
+		c := RandomColor()
+		c.A = 0x80
+		FillCircle(img, cx, cy, r, c)
+	}
+	return img
+}
+
+// RandomColor returns a random opaque color.
+func RandomColor() color.RGBA {
+	return color.RGBA{R: uint8(rand.IntN(256)), G: uint8(rand.IntN(256)), B: uint8(rand.IntN(256)), A: 0xff}
+}
+
+// FillCircle fills a circle, the color is blended with the image by its
+// alpha.
+func FillCircle(img *image.RGBA, cx, cy, r int, c color.RGBA) {
+	for y := cy - r; y <= cy+r; y++ {
+		for x := cx - r; x <= cx+r; x++ {
+			if (x-cx)*(x-cx)+(y-cy)*(y-cy) <= r*r && image.Pt(x, y).In(img.Rect) {
+				img.SetRGBA(x, y, blend(img.RGBAAt(x, y), c, float64(c.A)/0xff))
+			}
+		}
+	}
+}
+
+// Shade darkens the pixel by the factor in [0, 1].
+func Shade(img *image.RGBA, x, y int, factor float64) {
+	c := img.RGBAAt(x, y)
+	img.SetRGBA(x, y, blend(c, color.RGBA{A: 0xff}, factor))
+}
+
+func blend(from, to color.RGBA, t float64) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t)
+	}
+	return color.RGBA{R: mix(from.R, to.R), G: mix(from.G, to.G), B: mix(from.B, to.B), A: 0xff}
+}
+
+// Scale scales the image to fill the given size.
+func Scale(src image.Image, width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	b := src.Bounds()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, src.At(b.Min.X+x*b.Dx()/width, b.Min.Y+y*b.Dy()/height))
+		}
+	}
+	return img
+}
+
+// Rotate rotates the image clockwise by the degrees around its center, and
+// crops it to a circle, the pixels outside of which are transparent.
+func Rotate(src *image.RGBA, degrees float64) *image.RGBA {
+	b := src.Bounds()
+	img := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	cx, cy := float64(b.Dx())/2, float64(b.Dy())/2
+	r := min(cx, cy)
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			dx, dy := float64(x)+0.5-cx, float64(y)+0.5-cy
+			if dx*dx+dy*dy > r*r {
+				continue
+			}
+			// samples the source pixel by rotating backwards.
+			sx, sy := dx*cos+dy*sin+cx, -dx*sin+dy*cos+cy
+			p := image.Pt(b.Min.X+int(sx), b.Min.Y+int(sy))
+			if p.In(b) {
+				img.SetRGBA(x, y, src.RGBAAt(p.X, p.Y))
+			}
+		}
+	}
+	return img
+}
+
+// CutPiece copies the puzzle piece of the given size at the point, and
+// shades the hole that it leaves, the piece is a square with tabs on the top
+// and the right.
+func CutPiece(img *image.RGBA, at image.Point, size int) *image.RGBA {
+	piece := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if !inPiece(x, y, size) {
+				continue
+			}
+			piece.SetRGBA(x, y, img.RGBAAt(at.X+x, at.Y+y))
+			if isEdge(x, y, size) {
+				piece.SetRGBA(x, y, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+			}
+		}
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if inPiece(x, y, size) {
+				Shade(img, at.X+x, at.Y+y, 0.6)
+			}
+		}
+	}
+	return piece
+}
+
+func inPiece(x, y, size int) bool {
+	r := size / 5
+	body := size - r
+	if x < body && y >= r {
+		return true
+	}
+	tab := func(cx, cy int) bool {
+		return (x-cx)*(x-cx)+(y-cy)*(y-cy) <= r*r
+	}
+	return tab(body/2, r) || tab(body, r+body/2)
+}
+
+func isEdge(x, y, size int) bool {
+	return !inPiece(x-1, y, size) || !inPiece(x+1, y, size) || !inPiece(x, y-1, size) || !inPiece(x, y+1, size)
+}
@@ -0,0 +1,35 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestInPiece(t *testing.T) {
+	if !inPiece(10, 30, 50) || inPiece(45, 5, 50) {
+		t.Error("unexpected piece shape")
+	}
+}
+
+func TestCutPiece(t *testing.T) {
+	img := Scale(image.NewUniform(color.White), 100, 100)
+	before := img.RGBAAt(30, 40)
+	piece := CutPiece(img, image.Pt(20, 10), 50)
+	if piece.Bounds().Dx() != 50 || piece.Bounds().Dy() != 50 {
+		t.Errorf("unexpected piece bounds %v", piece.Bounds())
+	}
+	if piece.RGBAAt(10, 30) != before {
+		t.Error("expected the piece copied from the image")
+	}
+	if piece.RGBAAt(45, 5).A != 0 {
+		t.Error("expected the pixels outside of the piece transparent")
+	}
+	if img.RGBAAt(30, 40) == before {
+		t.Error("expected the hole shaded")
+	}
+}
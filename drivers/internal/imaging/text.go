@@ -0,0 +1,69 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"unicode"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+var goBold, _ = opentype.Parse(gobold.TTF)
+
+// Face returns a face of the Go bold font of the given size in pixels.
+func Face(size float64) font.Face {
+	face, _ := opentype.NewFace(goBold, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	return face
+}
+
+// TextBounds returns the bounds of the text drawn with its top-left corner
+// at the origin.
+func TextBounds(text string, face font.Face) image.Rectangle {
+	bounds, _ := font.BoundString(face, text)
+	ascent := face.Metrics().Ascent
+	return image.Rect(0, 0, (bounds.Max.X - bounds.Min.X).Ceil(), (bounds.Max.Y + ascent).Ceil())
+}
+
+// DrawText draws the text with its top-left corner at the given point, and
+// returns the bounds of the text.
+func DrawText(img draw.Image, text string, at image.Point, face font.Face, c color.Color) image.Rectangle {
+	bounds, _ := font.BoundString(face, text)
+	d := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(at.X, at.Y).Sub(fixed.Point26_6{X: bounds.Min.X}).Add(fixed.Point26_6{Y: face.Metrics().Ascent}),
+	}
+	d.DrawString(text)
+	return TextBounds(text, face).Add(at)
+}
+
+// Font returns the Go bold font, which is used to draw the outlines of
+// glyphs.
+func Font() *opentype.Font {
+	return goBold
+}
+
+// Covers reports whether the Go bold font has glyphs of all the characters
+// of the text except spaces.
+func Covers(text string) bool {
+	var buf sfnt.Buffer
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		if i, err := goBold.GlyphIndex(&buf, r); err != nil || i == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,35 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package otp provides the one-time code helpers of the email and SMS
+// drivers.
+package otp
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"math/big"
+	"strings"
+)
+
+// Code returns a random numeric code of the length.
+func Code(length int) (string, error) {
+	b := make([]byte, length)
+	ten := big.NewInt(10)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, ten)
+		if err != nil {
+			return "", err
+		}
+		b[i] = byte('0' + n.Int64())
+	}
+	return string(b), nil
+}
+
+// Match compares the code with the answer in constant time, spaces and
+// dashes are ignored, such as "123 456" and "123-456".
+func Match(actual, answer string) bool {
+	actual = strings.NewReplacer(" ", "", "-", "").Replace(actual)
+	return subtle.ConstantTimeCompare([]byte(actual), []byte(answer)) == 1
+}
@@ -0,0 +1,38 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package otp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCode(t *testing.T) {
+	code, err := Code(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 8 || strings.Trim(code, "0123456789") != "" {
+		t.Errorf("unexpected code %q", code)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		actual string
+		match  bool
+	}{
+		{"123456", true},
+		{"123 456", true},
+		{"123-456", true},
+		{"123457", false},
+		{"12345", false},
+	}
+	for _, test := range tests {
+		if match := Match(test.actual, "123456"); match != test.match {
+			t.Errorf("expected %q matches %t, got %t", test.actual, test.match, match)
+		}
+	}
+}
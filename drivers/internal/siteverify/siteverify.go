@@ -0,0 +1,117 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package siteverify provides the client of siteverify APIs, which are
+// shared by reCAPTCHA, hCaptcha and Turnstile.
+package siteverify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Response is the response of siteverify APIs, fields that are not
+// supported by the provider are zero.
+type Response struct {
+	Success     bool     `json:"success"`
+	Score       float64  `json:"score"`
+	Action      string   `json:"action"`
+	Hostname    string   `json:"hostname"`
+	ChallengeTS string   `json:"challenge_ts"`
+	ErrorCodes  []string `json:"error-codes"`
+}
+
+// Verifier verifies tokens.
+type Verifier struct {
+	URL     string
+	Secret  string
+	Client  *http.Client
+	Timeout time.Duration
+
+	// SiteKey is sent if it is not empty, which is recommended by hCaptcha.
+	SiteKey string
+
+	// Hostname, Action, MinScore and MaxScore are checked if they are not
+	// zero. Scores of reCAPTCHA are the higher the better, while risk
+	// scores of hCaptcha are the lower the better.
+	Hostname string
+	Action   string
+	MinScore float64
+	MaxScore float64
+}
+
+// Verify sends the token to the siteverify API, and returns the response.
+func (v *Verifier) Verify(ctx context.Context, token, remoteIP string) (*Response, error) {
+	if v.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.Timeout)
+		defer cancel()
+	}
+	form := url.Values{"secret": {v.Secret}, "response": {token}}
+	if v.SiteKey != "" {
+		form.Set("sitekey", v.SiteKey)
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("siteverify: unexpected status %s", resp.Status)
+	}
+	r := &Response{}
+	if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Match verifies the token, and reports whether it is successful and passes
+// the checks, errors are treated as failures.
+func (v *Verifier) Match(token string) bool {
+	ok, _ := v.MatchContext(context.Background(), token)
+	return ok
+}
+
+// MatchContext is the context-aware version of Match, it returns the error of
+// transport, unexpected status and malformed response instead.
+func (v *Verifier) MatchContext(ctx context.Context, token string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+	r, err := v.Verify(ctx, token, "")
+	if err != nil {
+		return false, err
+	}
+	if !r.Success {
+		return false, nil
+	}
+	if v.Hostname != "" && r.Hostname != v.Hostname {
+		return false, nil
+	}
+	if v.Action != "" && r.Action != v.Action {
+		return false, nil
+	}
+	if v.MaxScore > 0 && r.Score > v.MaxScore {
+		return false, nil
+	}
+	return r.Score >= v.MinScore, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package siteverify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVerifier(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("secret") != "secret" {
+			json.NewEncoder(w).Encode(Response{ErrorCodes: []string{"invalid-input-secret"}})
+			return
+		}
+		if r.PostFormValue("sitekey") == "other" {
+			json.NewEncoder(w).Encode(Response{ErrorCodes: []string{"sitekey-secret-mismatch"}})
+			return
+		}
+		switch r.PostFormValue("response") {
+		case "human":
+			json.NewEncoder(w).Encode(Response{Success: true, Score: 0.9, Action: "login", Hostname: "example.com", ChallengeTS: r.PostFormValue("remoteip")})
+		case "bot":
+			json.NewEncoder(w).Encode(Response{Success: true, Score: 0.1, Action: "login", Hostname: "example.com"})
+		case "broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			json.NewEncoder(w).Encode(Response{ErrorCodes: []string{"invalid-input-response"}})
+		}
+	}))
+	defer srv.Close()
+
+	v := &Verifier{URL: srv.URL, Secret: "secret"}
+	r, err := v.Verify(context.Background(), "human", "127.0.0.1")
+	if err != nil || !r.Success || r.Score != 0.9 || r.ChallengeTS != "127.0.0.1" {
+		t.Errorf("unexpected response %+v, %v", r, err)
+	}
+	if _, err := v.Verify(context.Background(), "broken", ""); err == nil {
+		t.Error("expected an error of unexpected status")
+	}
+
+	tests := []struct {
+		verifier Verifier
+		token    string
+		match    bool
+	}{
+		{Verifier{}, "human", true},
+		{Verifier{}, "bot", true},
+		{Verifier{}, "invalid", false},
+		{Verifier{}, "broken", false},
+		{Verifier{}, "", false},
+		{Verifier{MinScore: 0.5}, "human", true},
+		{Verifier{MinScore: 0.5}, "bot", false},
+		{Verifier{Hostname: "example.com", Action: "login"}, "human", true},
+		{Verifier{Hostname: "example.org"}, "human", false},
+		{Verifier{Action: "signup"}, "human", false},
+		{Verifier{MaxScore: 0.5}, "human", false},
+		{Verifier{MaxScore: 0.5}, "bot", true},
+		{Verifier{SiteKey: "site"}, "human", true},
+		{Verifier{SiteKey: "other"}, "human", false},
+		{Verifier{Secret: "other"}, "human", false},
+	}
+	for _, test := range tests {
+		v := test.verifier
+		v.URL = srv.URL
+		if v.Secret == "" {
+			v.Secret = "secret"
+		}
+		if match := v.Match(test.token); match != test.match {
+			t.Errorf("expected %q matches %t with %+v, got %t", test.token, test.match, test.verifier, match)
+		}
+	}
+
+	if _, err := v.MatchContext(context.Background(), "broken"); err == nil {
+		t.Error("expected an error of unexpected status")
+	}
+	if ok, err := v.MatchContext(context.Background(), "invalid"); ok || err != nil {
+		t.Errorf("expected a mismatch without error, got %t, %v", ok, err)
+	}
+}
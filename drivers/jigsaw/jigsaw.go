@@ -0,0 +1,214 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package jigsaw provides a jigsaw captcha driver, the user drags a puzzle
+// piece and drops it into the hole of the background. Unlike slider, the
+// hole may be anywhere, the answer is the drop position such as "120,45".
+package jigsaw
+
+import (
+	"bytes"
+	"html/template"
+	"image"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/internal/imaging"
+	"github.com/mojocn/base64Captcha"
+)
+
+// Option is a function that receives a pointer of jigsaw driver.
+type Option func(*driver)
+
+// Width sets the width of background.
+func Width(width int) Option {
+	return func(d *driver) {
+		d.width = width
+	}
+}
+
+// Height sets the height of background.
+func Height(height int) Option {
+	return func(d *driver) {
+		d.height = height
+	}
+}
+
+// PieceSize sets the size of puzzle piece.
+func PieceSize(size int) Option {
+	return func(d *driver) {
+		d.pieceSize = size
+	}
+}
+
+// Tolerance sets the maximum distance in pixels between the drop position
+// and the answer.
+func Tolerance(tolerance int) Option {
+	return func(d *driver) {
+		d.tolerance = tolerance
+	}
+}
+
+// Backgrounds sets the background images, one of which is picked randomly
+// for each captcha, the images are scaled to fill the background. Random
+// backgrounds are generated by default.
+func Backgrounds(images ...image.Image) Option {
+	return func(d *driver) {
+		d.backgrounds = images
+	}
+}
+
+type driver struct {
+	width       int
+	height      int
+	pieceSize   int
+	tolerance   int
+	backgrounds []image.Image
+}
+
+// New returns a jigsaw driver.
+func New(opts ...Option) captchas.Driver {
+	d := &driver{
+		width:     300,
+		height:    200,
+		pieceSize: 50,
+		tolerance: 6,
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// Generate implements Driver.Generate.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	bg := d.background()
+	at := image.Pt(rand.IntN(max(d.width-d.pieceSize, 1)), rand.IntN(max(d.height-d.pieceSize, 1)))
+	piece := imaging.CutPiece(bg, at, d.pieceSize)
+
+	return &Captcha{
+		id:         base64Captcha.RandomId(),
+		position:   at,
+		background: imaging.DataURI(bg),
+		piece:      imaging.DataURI(piece),
+	}, nil
+}
+
+func (d *driver) background() *image.RGBA {
+	if len(d.backgrounds) == 0 {
+		return imaging.Background(d.width, d.height)
+	}
+	return imaging.Scale(d.backgrounds[rand.IntN(len(d.backgrounds))], d.width, d.height)
+}
+
+// Match implements Matcher.Match, the answer matches if the distance between
+// the positions is within the tolerance.
+func (d *driver) Match(actual, answer string) bool {
+	a, err := ParsePosition(actual)
+	if err != nil {
+		return false
+	}
+	b, err := ParsePosition(answer)
+	if err != nil {
+		return false
+	}
+	// rejects positions out of the background, so that squares never overflow.
+	bounds := image.Rect(0, 0, d.width+1, d.height+1)
+	if !a.In(bounds) || !b.In(bounds) {
+		return false
+	}
+	delta := a.Sub(b)
+	if abs(delta.X) > d.tolerance || abs(delta.Y) > d.tolerance {
+		return false
+	}
+	return delta.X*delta.X+delta.Y*delta.Y <= d.tolerance*d.tolerance
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// FormatPosition formats the position of the top-left corner of the piece,
+// such as "120,45".
+func FormatPosition(p image.Point) string {
+	return strconv.Itoa(p.X) + "," + strconv.Itoa(p.Y)
+}
+
+// ParsePosition parses the position formatted by FormatPosition.
+func ParsePosition(s string) (image.Point, error) {
+	x, y, _ := strings.Cut(s, ",")
+	px, err := strconv.Atoi(strings.TrimSpace(x))
+	if err != nil {
+		return image.Point{}, err
+	}
+	py, err := strconv.Atoi(strings.TrimSpace(y))
+	if err != nil {
+		return image.Point{}, err
+	}
+	return image.Pt(px, py), nil
+}
+
+var tmpl = template.Must(template.New("jigsaw").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+<div style="position: relative">
+<img src="{{ .background }}" />
+<img src="{{ .piece }}" draggable="true" />
+</div>
+`))
+
+// Captcha is a jigsaw captcha.
+type Captcha struct {
+	id         string
+	position   image.Point
+	background string
+	piece      string
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer, it is the position of the hole.
+func (c *Captcha) Answer() string {
+	return FormatPosition(c.position)
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns the data URI
+// of the background.
+func (c *Captcha) EncodeToString() string {
+	return c.background
+}
+
+// Piece returns the data URI of the puzzle piece.
+func (c *Captcha) Piece() string {
+	return c.piece
+}
+
+// Images returns the data URIs of the background and the piece.
+func (c *Captcha) Images() (background, piece string) {
+	return c.background, c.piece
+}
+
+// HTMLField implements Captcha.HTMLField, scripts are expected to drag the
+// piece and submit the drop position.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":    c,
+		"fieldName":  fieldName,
+		"background": template.URL(c.background),
+		"piece":      template.URL(c.piece),
+	})
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package jigsaw
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestNew(t *testing.T) {
+	d := New(Width(200), Height(100), PieceSize(40), Tolerance(3)).(*driver)
+	if d.width != 200 || d.height != 100 || d.pieceSize != 40 || d.tolerance != 3 {
+		t.Errorf("unexpected driver %+v", d)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	bg := image.NewUniform(color.RGBA{R: 0xff, A: 0xff})
+	for _, d := range []captchas.Driver{New(), New(Backgrounds(bg))} {
+		c, err := d.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := ParsePosition(c.Answer())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !image.Rect(p.X, p.Y, p.X+50, p.Y+50).In(image.Rect(0, 0, 300, 200)) {
+			t.Errorf("expected the piece in the background, got %v", p)
+		}
+		if c.ID() == "" {
+			t.Error("expected a non-empty ID")
+		}
+		background, piece := c.(*Captcha).Images()
+		if background != c.EncodeToString() || piece != c.(*Captcha).Piece() {
+			t.Error("unexpected images")
+		}
+		for _, uri := range []string{background, piece} {
+			if !strings.HasPrefix(uri, "data:image/png;base64,") {
+				t.Errorf("expected a PNG data URI, got %.30s", uri)
+			}
+		}
+		html := string(c.HTMLField("captcha_id"))
+		if !strings.Contains(html, c.ID()) || !strings.Contains(html, `src="data:image/png;base64,`) {
+			t.Errorf("unexpected HTML %.200s", html)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	m := New(Tolerance(5)).(captchas.Matcher)
+	tests := []struct {
+		actual string
+		match  bool
+	}{
+		{"100,50", true},
+		{"103,54", true},
+		{" 96, 47", true},
+		{"105,50", true},
+		{"104,54", false},
+		{"100", false},
+		{"abc,50", false},
+		// squares of out-of-bounds coordinates overflow.
+		{"4000000000,0", false},
+		{"-4000000000,50", false},
+		{"3037000500,3037000500", false},
+		{"9223372036854775807,50", false},
+	}
+	for _, test := range tests {
+		if match := m.Match(test.actual, "100,50"); match != test.match {
+			t.Errorf("expected %q matches %t, got %t", test.actual, test.match, match)
+		}
+	}
+}
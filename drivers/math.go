@@ -61,6 +61,8 @@ type math struct {
 	// background color.
 	bgColor *color.RGBA
 	fonts   []string
+	// words of spelled-out questions.
+	words *MathWords
 }
 
 // NewMath return a math driver.
@@ -0,0 +1,138 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package drivers
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/png"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/internal/imaging"
+	"github.com/mojocn/base64Captcha"
+)
+
+// MathWords are the words of numbers and operators of spelled-out math
+// questions, such as "seven minus two".
+type MathWords struct {
+	// Numbers are the words of numbers from zero, which limit the operands.
+	Numbers []string
+	Plus    string
+	Minus   string
+	Times   string
+}
+
+// EnglishMathWords are the English words of numbers from zero to twenty.
+var EnglishMathWords = MathWords{
+	Numbers: []string{
+		"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+		"eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen", "twenty",
+	},
+	Plus:  "plus",
+	Minus: "minus",
+	Times: "times",
+}
+
+// MathSpelled renders operators and operands as words, which defeats bots
+// that recognize and evaluate the expression, the answer is still a number.
+// The words are drawn by the Go font, which covers Latin, Greek and Cyrillic,
+// words of other scripts, such as Chinese, are drawn by the fonts set by
+// MathFonts, Generate fails if the Go font doesn't cover the words and no
+// fonts are set.
+func MathSpelled(words MathWords) MathOption {
+	return func(m *math) {
+		m.words = &words
+	}
+}
+
+// Generate implements Driver.Generate.
+func (m *math) Generate() (captchas.Captcha, error) {
+	if m.words == nil || len(m.words.Numbers) == 0 {
+		return m.driver.Generate()
+	}
+
+	question, answer := m.spelledQuestion()
+	if len(m.fonts) > 0 {
+		item, err := m.driver.driver.DrawCaptcha(question)
+		if err != nil {
+			return nil, err
+		}
+		return newCaptcha(base64Captcha.RandomId(), strconv.Itoa(answer), htmlTagIMG, item), nil
+	}
+	if !imaging.Covers(question) {
+		return nil, errors.New("drivers: the Go font doesn't cover the words, fonts must be set by MathFonts")
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, m.drawWords(strings.Fields(question))); err != nil {
+		return nil, err
+	}
+
+	return newCaptcha(base64Captcha.RandomId(), strconv.Itoa(answer), htmlTagIMG, &encodedItem{mimeType: "image/png", data: buf.Bytes()}), nil
+}
+
+func (m *math) spelledQuestion() (string, int) {
+	numbers := m.words.Numbers
+	a, b := rand.IntN(len(numbers)), rand.IntN(len(numbers))
+	switch rand.IntN(3) {
+	case 0:
+		return numbers[a] + " " + m.words.Plus + " " + numbers[b], a + b
+	case 1:
+		a, b = max(a, b), min(a, b)
+		return numbers[a] + " " + m.words.Minus + " " + numbers[b], a - b
+	default:
+		a, b = a%10, b%10
+		return numbers[a] + " " + m.words.Times + " " + numbers[b], a * b
+	}
+}
+
+// drawWords draws the words side by side, each word has its own color and
+// offset, and the noise characters are drawn underneath.
+func (m *math) drawWords(words []string) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, m.width, m.height))
+	bgColor := base64Captcha.RandLightColor()
+	if m.bgColor != nil {
+		bgColor = *m.bgColor
+	}
+	draw.Draw(img, img.Bounds(), image.NewUniform(bgColor), image.Point{}, draw.Src)
+
+	noise := imaging.Face(float64(m.height) / 3)
+	for i := 0; i < m.noiseCount; i++ {
+		c := base64Captcha.RandLightColor()
+		at := image.Pt(rand.IntN(max(m.width, 1)), rand.IntN(max(m.height, 1)))
+		imaging.DrawText(img, strconv.Itoa(rand.IntN(10)), at, noise, c)
+	}
+
+	// shrinks the font until the words fit the width.
+	size := float64(m.height) / 2
+	face := imaging.Face(size)
+	gap := int(size / 3)
+	width := func() int {
+		w := gap * (len(words) - 1)
+		for _, word := range words {
+			w += imaging.TextBounds(word, face).Dx()
+		}
+		return w
+	}
+	for size > 8 && width() > m.width*9/10 {
+		size--
+		face = imaging.Face(size)
+		gap = int(size / 3)
+	}
+
+	x := max((m.width-width())/2, 0)
+	for _, word := range words {
+		b := imaging.TextBounds(word, face)
+		y := (m.height-b.Dy())/2 + rand.IntN(max(m.height/5, 1)) - m.height/10
+		imaging.DrawText(img, word, image.Pt(x, y), face, base64Captcha.RandDeepColor())
+		x += b.Dx() + gap
+	}
+	return img
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package drivers
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/clevergo/captchas/drivers/internal/imaging"
+)
+
+func TestMathSpelled(t *testing.T) {
+	m := &math{}
+	MathSpelled(EnglishMathWords)(m)
+	if m.words == nil || len(m.words.Numbers) != 21 {
+		t.Errorf("unexpected words %v", m.words)
+	}
+}
+
+func TestMathSpelledQuestion(t *testing.T) {
+	m := NewMath(MathSpelled(EnglishMathWords)).(*math)
+	index := make(map[string]int)
+	for i, word := range EnglishMathWords.Numbers {
+		index[word] = i
+	}
+	for i := 0; i < 100; i++ {
+		question, answer := m.spelledQuestion()
+		words := strings.Fields(question)
+		if len(words) != 3 {
+			t.Fatalf("unexpected question %q", question)
+		}
+		a, b := index[words[0]], index[words[2]]
+		expected := map[string]int{"plus": a + b, "minus": a - b, "times": a * b}[words[1]]
+		if answer != expected || answer < 0 {
+			t.Errorf("expected answer %d of %q, got %d", expected, question, answer)
+		}
+	}
+}
+
+func TestMathSpelledGenerate(t *testing.T) {
+	d := NewMath(MathSpelled(EnglishMathWords), MathWidth(320), MathNoiseCount(5))
+	c, err := d.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := strconv.Atoi(c.Answer()); err != nil {
+		t.Errorf("expected a numeric answer, got %q", c.Answer())
+	}
+	if !strings.HasPrefix(c.EncodeToString(), "data:image/png;base64,") {
+		t.Errorf("expected a PNG data URI, got %.30s", c.EncodeToString())
+	}
+}
+
+func TestMathSpelledFonts(t *testing.T) {
+	words := MathWords{
+		Numbers: []string{"零", "一", "二", "三", "四", "五", "六", "七", "八", "九", "十"},
+		Plus:    "加",
+		Minus:   "减",
+		Times:   "乘",
+	}
+	if _, err := NewMath(MathSpelled(words)).Generate(); err == nil {
+		t.Error("expected an error of uncovered words")
+	}
+
+	c, err := NewMath(MathSpelled(words), MathFonts([]string{"wqy-microhei.ttc"}), MathWidth(320)).Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := strconv.Atoi(c.Answer()); err != nil {
+		t.Errorf("expected a numeric answer, got %q", c.Answer())
+	}
+
+	for _, text := range []string{"seven minus two", "семь минус два", "επτά"} {
+		if !imaging.Covers(text) {
+			t.Errorf("expected the Go font covers %q", text)
+		}
+	}
+}
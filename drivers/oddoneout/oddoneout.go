@@ -0,0 +1,185 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package oddoneout provides an odd-one-out captcha driver, all tiles are of
+// the same label except one, and the user selects the outlier. Tiles are
+// indexed from 0, left to right and top to bottom, the submitted value is
+// either the index, such as "4", or the clicked point of the image, such as
+// "120,45".
+package oddoneout
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"image"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/internal/imaging"
+	"github.com/clevergo/captchas/drivers/tiles"
+	"github.com/mojocn/base64Captcha"
+)
+
+// Option is a function that receives a pointer of odd-one-out driver.
+type Option func(*driver)
+
+// Count sets the number of tiles.
+func Count(count int) Option {
+	return func(d *driver) {
+		d.count = count
+	}
+}
+
+// Columns sets the number of columns.
+func Columns(columns int) Option {
+	return func(d *driver) {
+		d.columns = columns
+	}
+}
+
+// TileSize sets the width and height of tiles in pixels.
+func TileSize(size int) Option {
+	return func(d *driver) {
+		d.tileSize = size
+	}
+}
+
+// Gap sets the gap between tiles in pixels.
+func Gap(gap int) Option {
+	return func(d *driver) {
+		d.gap = gap
+	}
+}
+
+// TileBank sets the bank of tiles, defaults to tiles.NewShapeBank.
+func TileBank(bank tiles.Bank) Option {
+	return func(d *driver) {
+		d.bank = bank
+	}
+}
+
+type driver struct {
+	count    int
+	columns  int
+	tileSize int
+	gap      int
+	bank     tiles.Bank
+}
+
+// New returns an odd-one-out driver.
+func New(opts ...Option) captchas.Driver {
+	d := &driver{
+		count:    6,
+		columns:  3,
+		tileSize: 80,
+		gap:      4,
+		bank:     tiles.NewShapeBank(),
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// Generate implements Driver.Generate.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	labels := d.bank.Labels()
+	if len(labels) < 2 {
+		return nil, errors.New("oddoneout: the bank must have two labels at least")
+	}
+	if d.count < 3 {
+		return nil, errors.New("oddoneout: three tiles at least")
+	}
+	perm := rand.Perm(len(labels))
+	label, outlier := labels[perm[0]], labels[perm[1]]
+	index := rand.IntN(d.count)
+
+	images := make([]image.Image, d.count)
+	for i := range images {
+		tileLabel := label
+		if i == index {
+			tileLabel = outlier
+		}
+		tile, err := d.bank.Tile(tileLabel, d.tileSize)
+		if err != nil {
+			return nil, err
+		}
+		images[i] = tile
+	}
+
+	return &Captcha{
+		id:    base64Captcha.RandomId(),
+		index: index,
+		image: imaging.DataURI(tiles.Compose(images, d.columns, d.tileSize, d.gap)),
+	}, nil
+}
+
+// Match implements Matcher.Match, the answer matches if the index, or the
+// tile of the point, is the outlier.
+func (d *driver) Match(actual, answer string) bool {
+	index, err := strconv.Atoi(answer)
+	if err != nil {
+		return false
+	}
+	if x, y, ok := strings.Cut(actual, ","); ok {
+		px, err := strconv.Atoi(strings.TrimSpace(x))
+		if err != nil {
+			return false
+		}
+		py, err := strconv.Atoi(strings.TrimSpace(y))
+		if err != nil {
+			return false
+		}
+		return tiles.Index(image.Pt(px, py), d.count, d.columns, d.tileSize, d.gap) == index
+	}
+	i, err := strconv.Atoi(strings.TrimSpace(actual))
+	return err == nil && i == index
+}
+
+var tmpl = template.Must(template.New("oddoneout").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+<img src="{{ .image }}" />
+`))
+
+// Captcha is an odd-one-out captcha.
+type Captcha struct {
+	id    string
+	index int
+	image string
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer, it is the index of the outlier.
+func (c *Captcha) Answer() string {
+	return strconv.Itoa(c.index)
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns the data URI
+// of the tiles.
+func (c *Captcha) EncodeToString() string {
+	return c.image
+}
+
+// HTMLField implements Captcha.HTMLField, scripts are expected to submit
+// the index or the point of the selected tile.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":   c,
+		"fieldName": fieldName,
+		"image":     template.URL(c.image),
+	})
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,78 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package oddoneout
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/tiles"
+)
+
+func TestNew(t *testing.T) {
+	bank := tiles.NewImageBank(nil)
+	d := New(Count(4), Columns(2), TileSize(60), Gap(2), TileBank(bank)).(*driver)
+	if d.count != 4 || d.columns != 2 || d.tileSize != 60 || d.gap != 2 || d.bank != bank {
+		t.Errorf("unexpected driver %+v", d)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	bank := tiles.NewImageBank(map[string][]image.Image{
+		"red":  {image.NewUniform(color.RGBA{R: 0xff, A: 0xff})},
+		"blue": {image.NewUniform(color.RGBA{B: 0xff, A: 0xff})},
+	})
+	for _, d := range []captchas.Driver{New(), New(TileBank(bank))} {
+		c, err := d.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		index, err := strconv.Atoi(c.Answer())
+		if err != nil || index < 0 || index >= 6 {
+			t.Errorf("unexpected index %q, %v", c.Answer(), err)
+		}
+		if !strings.HasPrefix(c.EncodeToString(), "data:image/png;base64,") {
+			t.Errorf("expected a PNG data URI, got %.30s", c.EncodeToString())
+		}
+		html := string(c.HTMLField("captcha_id"))
+		if !strings.Contains(html, c.ID()) || !strings.Contains(html, `src="data:image/png;base64,`) {
+			t.Errorf("unexpected HTML %.200s", html)
+		}
+	}
+
+	if _, err := New(TileBank(tiles.NewImageBank(nil))).Generate(); err == nil {
+		t.Error("expected an error if the bank has less than two labels")
+	}
+	if _, err := New(Count(2)).Generate(); err == nil {
+		t.Error("expected an error of less than three tiles")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	m := New(TileSize(10), Gap(2)).(captchas.Matcher)
+	tests := []struct {
+		actual string
+		match  bool
+	}{
+		{"4", true},
+		{" 4 ", true},
+		{"3", false},
+		{"16,16", true},
+		{"16, 20", true},
+		{"4,4", false},
+		{"12,12", false},
+		{"x,16", false},
+		{"abc", false},
+	}
+	for _, test := range tests {
+		if match := m.Match(test.actual, "4"); match != test.match {
+			t.Errorf("expected %q matches %t, got %t", test.actual, test.match, match)
+		}
+	}
+}
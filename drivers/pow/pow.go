@@ -0,0 +1,173 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package pow provides a proof-of-work captcha driver, which issues a
+// hashcash puzzle instead of an image, the client finds a nonce so that the
+// SHA-256 hash of the challenge followed by the nonce has the given number
+// of leading zero bits, and submits the nonce as the captcha value.
+package pow
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"html/template"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/clevergo/captchas"
+	"github.com/mojocn/base64Captcha"
+)
+
+// Option is a function that receives a pointer of proof-of-work driver.
+type Option func(*driver)
+
+// Difficulty sets the number of leading zero bits of hashes, clients
+// compute 2^difficulty hashes on average.
+func Difficulty(difficulty int) Option {
+	return func(d *driver) {
+		d.difficulty = difficulty
+	}
+}
+
+// ChallengeSize sets the number of random bytes of challenges.
+func ChallengeSize(size int) Option {
+	return func(d *driver) {
+		d.challengeSize = size
+	}
+}
+
+type driver struct {
+	difficulty    int
+	challengeSize int
+}
+
+// New returns a proof-of-work driver.
+func New(opts ...Option) captchas.Driver {
+	d := &driver{
+		difficulty:    18,
+		challengeSize: 16,
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// Generate implements Driver.Generate.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	b := make([]byte, d.challengeSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return &Captcha{
+		id:         base64Captcha.RandomId(),
+		challenge:  hex.EncodeToString(b),
+		difficulty: d.difficulty,
+	}, nil
+}
+
+// Match implements Matcher.Match, the answer is the puzzle, and the actual
+// value is the nonce.
+func (d *driver) Match(actual, answer string) bool {
+	difficulty, challenge, ok := strings.Cut(answer, ":")
+	if !ok {
+		return false
+	}
+	n, err := strconv.Atoi(difficulty)
+	if err != nil {
+		return false
+	}
+	return Verify(challenge, n, actual)
+}
+
+// Verify reports whether the SHA-256 hash of the challenge followed by the
+// nonce has the given number of leading zero bits.
+func Verify(challenge string, difficulty int, nonce string) bool {
+	// nonces are expected to be decimal numbers.
+	if nonce == "" || len(nonce) > 20 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(challenge + nonce))
+	return leadingZeros(sum[:]) >= difficulty
+}
+
+// Solve finds the nonce of the puzzle, which is useful for non-browser
+// clients and tests.
+func Solve(challenge string, difficulty int) string {
+	for i := uint64(0); ; i++ {
+		nonce := strconv.FormatUint(i, 10)
+		if Verify(challenge, difficulty, nonce) {
+			return nonce
+		}
+	}
+}
+
+func leadingZeros(b []byte) int {
+	n := 0
+	for _, v := range b {
+		if v != 0 {
+			return n + bits.LeadingZeros8(v)
+		}
+		n += 8
+	}
+	return n
+}
+
+var tmpl = template.Must(template.New("pow").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+<div data-challenge="{{ .captcha.Challenge }}" data-difficulty="{{ .captcha.Difficulty }}"></div>
+`))
+
+// Captcha is a proof-of-work captcha.
+type Captcha struct {
+	id         string
+	challenge  string
+	difficulty int
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer, it is the puzzle, the difficulty and
+// the challenge separated by a colon.
+func (c *Captcha) Answer() string {
+	return c.EncodeToString()
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns the puzzle,
+// such as "18:9f86d081884c7d65".
+func (c *Captcha) EncodeToString() string {
+	return strconv.Itoa(c.difficulty) + ":" + c.challenge
+}
+
+// Challenge returns the challenge.
+func (c *Captcha) Challenge() string {
+	return c.challenge
+}
+
+// Difficulty returns the number of leading zero bits.
+func (c *Captcha) Difficulty() int {
+	return c.difficulty
+}
+
+// HTMLField implements Captcha.HTMLField, scripts are expected to solve the
+// puzzle and submit the nonce.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":   c,
+		"fieldName": fieldName,
+	})
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package pow
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestNew(t *testing.T) {
+	d := New(Difficulty(10), ChallengeSize(8)).(*driver)
+	if d.difficulty != 10 || d.challengeSize != 8 {
+		t.Errorf("unexpected driver %+v", d)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	c, err := New(Difficulty(8), ChallengeSize(8)).Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc := c.(*Captcha)
+	if len(pc.Challenge()) != 16 || pc.Difficulty() != 8 || c.Answer() != "8:"+pc.Challenge() || c.EncodeToString() != c.Answer() {
+		t.Errorf("unexpected captcha %+v", pc)
+	}
+	html := string(c.HTMLField("captcha_id"))
+	if !strings.Contains(html, c.ID()) || !strings.Contains(html, pc.Challenge()) {
+		t.Errorf("unexpected HTML %s", html)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	m := New().(captchas.Matcher)
+	nonce := Solve("challenge", 12)
+	if !m.Match(nonce, "12:challenge") {
+		t.Errorf("expected nonce %s matches", nonce)
+	}
+	for _, answer := range []string{"24:challenge", "challenge", "x:challenge"} {
+		if m.Match(nonce, answer) {
+			t.Errorf("expected nonce %s doesn't match %q", nonce, answer)
+		}
+	}
+	if m.Match("", "0:challenge") || m.Match(strings.Repeat("1", 21), "0:challenge") {
+		t.Error("expected invalid nonces don't match")
+	}
+}
+
+func TestLeadingZeros(t *testing.T) {
+	tests := []struct {
+		b []byte
+		n int
+	}{
+		{[]byte{0x80}, 0},
+		{[]byte{0x01}, 7},
+		{[]byte{0x00, 0x10}, 11},
+		{[]byte{0x00, 0x00}, 16},
+	}
+	for _, test := range tests {
+		if n := leadingZeros(test.b); n != test.n {
+			t.Errorf("expected %d leading zeros of %x, got %d", test.n, test.b, n)
+		}
+	}
+}
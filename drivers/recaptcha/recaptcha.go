@@ -0,0 +1,173 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package recaptcha provides an adapter driver of Google reCAPTCHA v2 and
+// v3, which delegates verification to the siteverify API, so that
+// applications switch between self-hosted captchas and reCAPTCHA without
+// changing Manager.Generate and Manager.Verify call sites. The token of
+// "g-recaptcha-response" is the captcha value.
+package recaptcha
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/internal/siteverify"
+	"github.com/mojocn/base64Captcha"
+)
+
+// VerifyURL is the default URL of siteverify API.
+const VerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+
+// Option is a function that receives a pointer of reCAPTCHA driver.
+type Option func(*driver)
+
+// MinScore sets the minimum score of reCAPTCHA v3 in [0, 1], scores are not
+// checked by default, which is required by v2.
+func MinScore(score float64) Option {
+	return func(d *driver) {
+		d.verifier.MinScore = score
+	}
+}
+
+// Action sets the expected action of reCAPTCHA v3.
+func Action(action string) Option {
+	return func(d *driver) {
+		d.verifier.Action = action
+	}
+}
+
+// Hostname sets the expected hostname of the site.
+func Hostname(hostname string) Option {
+	return func(d *driver) {
+		d.verifier.Hostname = hostname
+	}
+}
+
+// HTTPClient sets the HTTP client, defaults to http.DefaultClient.
+func HTTPClient(client *http.Client) Option {
+	return func(d *driver) {
+		d.verifier.Client = client
+	}
+}
+
+// Timeout sets the timeout of verification.
+func Timeout(timeout time.Duration) Option {
+	return func(d *driver) {
+		d.verifier.Timeout = timeout
+	}
+}
+
+// URL sets the URL of siteverify API, such as
+// "https://www.recaptcha.net/recaptcha/api/siteverify".
+func URL(url string) Option {
+	return func(d *driver) {
+		d.verifier.URL = url
+	}
+}
+
+type driver struct {
+	siteKey  string
+	verifier *siteverify.Verifier
+}
+
+// New returns a reCAPTCHA driver of the site key and the secret key.
+func New(siteKey, secret string, opts ...Option) captchas.Driver {
+	d := &driver{
+		siteKey: siteKey,
+		verifier: &siteverify.Verifier{
+			URL:     VerifyURL,
+			Secret:  secret,
+			Timeout: 10 * time.Second,
+		},
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// Generate implements Driver.Generate.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	return &Captcha{
+		id:      base64Captcha.RandomId(),
+		siteKey: d.siteKey,
+		action:  d.verifier.Action,
+	}, nil
+}
+
+// Match implements Matcher.Match, the actual value is the token, which is
+// verified by the siteverify API, errors are treated as mismatches.
+func (d *driver) Match(actual, answer string) bool {
+	return answer == "recaptcha" && d.verifier.Match(actual)
+}
+
+// MatchContext implements ContextMatcher.MatchContext, which is preferred by
+// Manager.Verify, errors of the siteverify API are returned, so that they are
+// distinguishable from mismatches.
+func (d *driver) MatchContext(ctx context.Context, actual, answer string) (bool, error) {
+	if answer != "recaptcha" {
+		return false, nil
+	}
+	return d.verifier.MatchContext(ctx, actual)
+}
+
+var tmpl = template.Must(template.New("recaptcha").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+<script src="https://www.google.com/recaptcha/api.js" async defer></script>
+<div class="g-recaptcha" data-sitekey="{{ .captcha.SiteKey }}"{{ with .captcha.Action }} data-action="{{ . }}"{{ end }}></div>
+`))
+
+// Captcha is a reCAPTCHA captcha.
+type Captcha struct {
+	id      string
+	siteKey string
+	action  string
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer, it is the constant "recaptcha", the
+// token is verified by reCAPTCHA.
+func (c *Captcha) Answer() string {
+	return "recaptcha"
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns the site key.
+func (c *Captcha) EncodeToString() string {
+	return c.siteKey
+}
+
+// SiteKey returns the site key.
+func (c *Captcha) SiteKey() string {
+	return c.siteKey
+}
+
+// Action returns the expected action.
+func (c *Captcha) Action() string {
+	return c.action
+}
+
+// HTMLField implements Captcha.HTMLField, it renders the widget of
+// reCAPTCHA v2, reCAPTCHA v3 expects scripts to execute the action and
+// submit the token instead.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":   c,
+		"fieldName": fieldName,
+	})
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,172 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package rotate provides a rotation captcha driver, the image is rotated
+// randomly, and the user rotates it back upright. The answer is the degrees
+// to rotate the image clockwise, in [0, 360).
+package rotate
+
+import (
+	"bytes"
+	"html/template"
+	"image"
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/internal/imaging"
+	"github.com/mojocn/base64Captcha"
+)
+
+// Option is a function that receives a pointer of rotation driver.
+type Option func(*driver)
+
+// Size sets the width and height of image.
+func Size(size int) Option {
+	return func(d *driver) {
+		d.size = size
+	}
+}
+
+// MinAngle sets the minimum degrees that the image is rotated in either
+// direction, so that it is never nearly upright.
+func MinAngle(degrees int) Option {
+	return func(d *driver) {
+		d.minAngle = degrees
+	}
+}
+
+// Tolerance sets the maximum difference in degrees between the submitted
+// angle and the answer.
+func Tolerance(tolerance int) Option {
+	return func(d *driver) {
+		d.tolerance = tolerance
+	}
+}
+
+// Images sets the upright images, one of which is picked randomly for each
+// captcha, the images are scaled to fill the size. Random images of text
+// are generated by default.
+func Images(images ...image.Image) Option {
+	return func(d *driver) {
+		d.images = images
+	}
+}
+
+// Source sets the characters of text of the generated images.
+func Source(source string) Option {
+	return func(d *driver) {
+		d.source = []rune(source)
+	}
+}
+
+type driver struct {
+	size      int
+	minAngle  int
+	tolerance int
+	images    []image.Image
+	source    []rune
+}
+
+// New returns a rotation driver.
+func New(opts ...Option) captchas.Driver {
+	d := &driver{
+		size:      160,
+		minAngle:  30,
+		tolerance: 10,
+		source:    []rune("ABCDEFGHJKLMNPQRTUVWY"),
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// Generate implements Driver.Generate.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	angle := d.minAngle + rand.IntN(max(360-2*d.minAngle, 1))
+	img := imaging.Rotate(d.image(), float64(angle))
+
+	return &Captcha{
+		id:    base64Captcha.RandomId(),
+		angle: (360 - angle) % 360,
+		image: imaging.DataURI(img),
+	}, nil
+}
+
+func (d *driver) image() *image.RGBA {
+	if len(d.images) > 0 {
+		return imaging.Scale(d.images[rand.IntN(len(d.images))], d.size, d.size)
+	}
+	img := imaging.Background(d.size, d.size)
+	text := make([]rune, 3)
+	for i := range text {
+		text[i] = d.source[rand.IntN(len(d.source))]
+	}
+	face := imaging.Face(float64(d.size) / 4)
+	bounds := imaging.TextBounds(string(text), face)
+	at := image.Pt((d.size-bounds.Dx())/2, (d.size-bounds.Dy())/2)
+	imaging.DrawText(img, string(text), at, face, imaging.RandomColor())
+	return img
+}
+
+// Match implements Matcher.Match, the answer matches if the angle is within
+// the tolerance, angles are compared modulo 360.
+func (d *driver) Match(actual, answer string) bool {
+	a, err := strconv.Atoi(actual)
+	if err != nil {
+		return false
+	}
+	b, err := strconv.Atoi(answer)
+	if err != nil {
+		return false
+	}
+	diff := ((a-b)%360 + 360) % 360
+	return min(diff, 360-diff) <= d.tolerance
+}
+
+var tmpl = template.Must(template.New("rotate").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+<img src="{{ .image }}" style="border-radius: 50%" />
+`))
+
+// Captcha is a rotation captcha.
+type Captcha struct {
+	id    string
+	angle int
+	image string
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer, it is the degrees to rotate the image
+// clockwise.
+func (c *Captcha) Answer() string {
+	return strconv.Itoa(c.angle)
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns the data URI
+// of the rotated image.
+func (c *Captcha) EncodeToString() string {
+	return c.image
+}
+
+// HTMLField implements Captcha.HTMLField, scripts are expected to rotate the
+// image and submit the degrees.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":   c,
+		"fieldName": fieldName,
+		"image":     template.URL(c.image),
+	})
+	return template.HTML(buf.String())
+}
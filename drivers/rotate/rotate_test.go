@@ -0,0 +1,73 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package rotate
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestNew(t *testing.T) {
+	d := New(Size(100), MinAngle(20), Tolerance(5), Source("AB")).(*driver)
+	if d.size != 100 || d.minAngle != 20 || d.tolerance != 5 || string(d.source) != "AB" {
+		t.Errorf("unexpected driver %+v", d)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	img := image.NewUniform(color.RGBA{R: 0xff, A: 0xff})
+	for _, d := range []captchas.Driver{New(), New(Images(img))} {
+		c, err := d.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		angle, err := strconv.Atoi(c.Answer())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if angle < 30 || angle > 330 {
+			t.Errorf("expected the angle in [30, 330], got %d", angle)
+		}
+		if c.ID() == "" {
+			t.Error("expected a non-empty ID")
+		}
+		if !strings.HasPrefix(c.EncodeToString(), "data:image/png;base64,") {
+			t.Errorf("expected a PNG data URI, got %.30s", c.EncodeToString())
+		}
+		html := string(c.HTMLField("captcha_id"))
+		if !strings.Contains(html, c.ID()) || !strings.Contains(html, `src="data:image/png;base64,`) {
+			t.Errorf("unexpected HTML %.200s", html)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	m := New(Tolerance(10)).(captchas.Matcher)
+	tests := []struct {
+		actual string
+		answer string
+		match  bool
+	}{
+		{"90", "90", true},
+		{"80", "90", true},
+		{"100", "90", true},
+		{"79", "90", false},
+		{"355", "5", true},
+		{"-5", "5", true},
+		{"365", "5", true},
+		{"180", "5", false},
+		{"abc", "5", false},
+	}
+	for _, test := range tests {
+		if match := m.Match(test.actual, test.answer); match != test.match {
+			t.Errorf("expected %q matches %q %t, got %t", test.actual, test.answer, test.match, match)
+		}
+	}
+}
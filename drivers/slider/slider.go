@@ -0,0 +1,186 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package slider provides a slider captcha driver, the user drags a puzzle
+// piece horizontally into the hole of the background, and the answer is the
+// X offset of the hole.
+package slider
+
+import (
+	"bytes"
+	"html/template"
+	"image"
+	"math/rand/v2"
+	"strconv"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/internal/imaging"
+	"github.com/mojocn/base64Captcha"
+)
+
+// Option is a function that receives a pointer of slider driver.
+type Option func(*driver)
+
+// Width sets the width of background.
+func Width(width int) Option {
+	return func(d *driver) {
+		d.width = width
+	}
+}
+
+// Height sets the height of background.
+func Height(height int) Option {
+	return func(d *driver) {
+		d.height = height
+	}
+}
+
+// PieceSize sets the size of puzzle piece.
+func PieceSize(size int) Option {
+	return func(d *driver) {
+		d.pieceSize = size
+	}
+}
+
+// Tolerance sets the maximum difference in pixels between the submitted
+// offset and the answer.
+func Tolerance(tolerance int) Option {
+	return func(d *driver) {
+		d.tolerance = tolerance
+	}
+}
+
+// Backgrounds sets the background images, one of which is picked randomly
+// for each captcha, the images are scaled to fill the background. Random
+// backgrounds are generated by default.
+func Backgrounds(images ...image.Image) Option {
+	return func(d *driver) {
+		d.backgrounds = images
+	}
+}
+
+type driver struct {
+	width       int
+	height      int
+	pieceSize   int
+	tolerance   int
+	backgrounds []image.Image
+}
+
+// New returns a slider driver.
+func New(opts ...Option) captchas.Driver {
+	d := &driver{
+		width:     300,
+		height:    150,
+		pieceSize: 50,
+		tolerance: 5,
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// Generate implements Driver.Generate.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	bg := d.background()
+	size := d.pieceSize
+	// the hole is kept away from the start of the piece.
+	x := size + rand.IntN(max(d.width-2*size, 1))
+	y := rand.IntN(max(d.height-size, 1))
+
+	piece := imaging.CutPiece(bg, image.Pt(x, y), size)
+
+	return &Captcha{
+		id:         base64Captcha.RandomId(),
+		x:          x,
+		y:          y,
+		background: imaging.DataURI(bg),
+		piece:      imaging.DataURI(piece),
+	}, nil
+}
+
+// Match implements Matcher.Match, the answer matches if the offset is within
+// the tolerance.
+func (d *driver) Match(actual, answer string) bool {
+	return withinTolerance(actual, answer, d.tolerance)
+}
+
+func (d *driver) background() *image.RGBA {
+	if len(d.backgrounds) == 0 {
+		return imaging.Background(d.width, d.height)
+	}
+	return imaging.Scale(d.backgrounds[rand.IntN(len(d.backgrounds))], d.width, d.height)
+}
+
+func withinTolerance(actual, answer string, tolerance int) bool {
+	a, err := strconv.Atoi(actual)
+	if err != nil {
+		return false
+	}
+	b, err := strconv.Atoi(answer)
+	if err != nil {
+		return false
+	}
+	return a-b <= tolerance && b-a <= tolerance
+}
+
+var tmpl = template.Must(template.New("slider").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+<div style="position: relative">
+<img src="{{ .background }}" />
+<img src="{{ .piece }}" data-y="{{ .captcha.Y }}" style="position: absolute; left: 0; top: {{ .captcha.Y }}px" />
+</div>
+`))
+
+// Captcha is a slider captcha.
+type Captcha struct {
+	id         string
+	x, y       int
+	background string
+	piece      string
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer, it is the X offset of the hole.
+func (c *Captcha) Answer() string {
+	return strconv.Itoa(c.x)
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns the data URI
+// of the background.
+func (c *Captcha) EncodeToString() string {
+	return c.background
+}
+
+// Piece returns the data URI of the puzzle piece.
+func (c *Captcha) Piece() string {
+	return c.piece
+}
+
+// Y returns the Y offset of the piece.
+func (c *Captcha) Y() int {
+	return c.y
+}
+
+// HTMLField implements Captcha.HTMLField, the piece is placed at the left
+// of the background, scripts are expected to move it and submit the offset.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":    c,
+		"fieldName":  fieldName,
+		"background": template.URL(c.background),
+		"piece":      template.URL(c.piece),
+	})
+	return template.HTML(buf.String())
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package slider
+
+import (
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestNew(t *testing.T) {
+	d := New(Width(200), Height(100), PieceSize(40), Tolerance(3)).(*driver)
+	if d.width != 200 || d.height != 100 || d.pieceSize != 40 || d.tolerance != 3 {
+		t.Errorf("unexpected driver %+v", d)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	bg := image.NewUniform(color.RGBA{R: 0xff, A: 0xff})
+	for _, d := range []captchas.Driver{New(), New(Backgrounds(bg))} {
+		c, err := d.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc := c.(*Captcha)
+		x, err := strconv.Atoi(c.Answer())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x < 50 || x+50 > 300 || sc.Y() < 0 || sc.Y()+50 > 150 {
+			t.Errorf("expected the piece in the background, got (%d, %d)", x, sc.Y())
+		}
+		if c.ID() == "" {
+			t.Error("expected a non-empty ID")
+		}
+		for _, uri := range []string{c.EncodeToString(), sc.Piece()} {
+			if !strings.HasPrefix(uri, "data:image/png;base64,") {
+				t.Errorf("expected a PNG data URI, got %.30s", uri)
+			}
+		}
+		html := string(c.HTMLField("captcha_id"))
+		if !strings.Contains(html, c.ID()) || !strings.Contains(html, `src="data:image/png;base64,`) {
+			t.Errorf("unexpected HTML %.200s", html)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	m := New(Tolerance(5)).(captchas.Matcher)
+	tests := []struct {
+		actual string
+		match  bool
+	}{
+		{"100", true},
+		{"95", true},
+		{"105", true},
+		{"94", false},
+		{"106", false},
+		{"abc", false},
+	}
+	for _, test := range tests {
+		if match := m.Match(test.actual, "100"); match != test.match {
+			t.Errorf("expected %q matches %t, got %t", test.actual, test.match, match)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sms
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Gateway sends SMS messages, such as Twilio and SNS, the destination is in
+// E.164 format.
+type Gateway interface {
+	Send(ctx context.Context, to, body string) error
+}
+
+// GatewayFunc is an adapter to use ordinary functions as gateways.
+type GatewayFunc func(ctx context.Context, to, body string) error
+
+// Send implements Gateway.Send.
+func (f GatewayFunc) Send(ctx context.Context, to, body string) error {
+	return f(ctx, to, body)
+}
+
+// ErrInvalidNumber is returned if the phone number is not a valid E.164
+// number.
+var ErrInvalidNumber = errors.New("sms: invalid phone number")
+
+// NormalizeE164 normalizes the phone number to E.164 format, such as
+// "+14155552671", spaces, dashes, dots and parentheses are removed, and the
+// international prefix "00" is replaced by "+".
+func NormalizeE164(number string) (string, error) {
+	number = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, number)
+	if strings.HasPrefix(number, "00") {
+		number = "+" + number[2:]
+	}
+	digits, ok := strings.CutPrefix(number, "+")
+	if !ok || len(digits) < 2 || len(digits) > 15 || digits[0] == '0' {
+		return "", ErrInvalidNumber
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidNumber
+		}
+	}
+	return number, nil
+}
+
+// RateLimiter limits messages sent to destinations, it is called before
+// sending.
+type RateLimiter interface {
+	// Allow returns an error if sending to the destination is not allowed,
+	// such as ErrRateLimited.
+	Allow(ctx context.Context, to string) error
+}
+
+// RateLimiterFunc is an adapter to use ordinary functions as rate limiters.
+type RateLimiterFunc func(ctx context.Context, to string) error
+
+// Allow implements RateLimiter.Allow.
+func (f RateLimiterFunc) Allow(ctx context.Context, to string) error {
+	return f(ctx, to)
+}
+
+// ErrRateLimited is returned if too many messages are sent to the
+// destination.
+var ErrRateLimited = errors.New("sms: rate limited")
+
+type window struct {
+	start time.Time
+	count int
+}
+
+type memoryRateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	period  time.Duration
+	windows map[string]*window
+}
+
+// NewMemoryRateLimiter returns an in-memory rate limiter, which allows the
+// limit of messages to each destination per period, windows of instances
+// are not shared.
+func NewMemoryRateLimiter(limit int, period time.Duration) RateLimiter {
+	return &memoryRateLimiter{
+		limit:   limit,
+		period:  period,
+		windows: make(map[string]*window),
+	}
+}
+
+func (l *memoryRateLimiter) Allow(ctx context.Context, to string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	for k, w := range l.windows {
+		if now.Sub(w.start) >= l.period {
+			delete(l.windows, k)
+		}
+	}
+	w, ok := l.windows[to]
+	if !ok {
+		w = &window{start: now}
+		l.windows[to] = w
+	}
+	if w.count >= l.limit {
+		return ErrRateLimited
+	}
+	w.count++
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sms
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/memstore"
+)
+
+func TestNew(t *testing.T) {
+	body := template.Must(template.New("body").Parse("{{ .Code }}"))
+	limiter := NewMemoryRateLimiter(1, time.Minute)
+	d := New(nil, Length(4), Body(body), RateLimit(limiter)).(*driver)
+	if d.length != 4 || d.body != body || d.limiter != limiter {
+		t.Errorf("unexpected driver %+v", d)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var sent []string
+	gateway := GatewayFunc(func(ctx context.Context, to, body string) error {
+		sent = append(sent, to+" "+body)
+		return nil
+	})
+	manager := captchas.New(memstore.New(), New(gateway, RateLimit(NewMemoryRateLimiter(2, time.Minute))))
+	c, err := manager.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Answer()) != 6 || c.EncodeToString() != "" {
+		t.Errorf("unexpected captcha %+v", c)
+	}
+	sc := c.(*Captcha)
+	if err := sc.Send(context.Background(), "+1 (415) 555-2671"); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 1 || !strings.HasPrefix(sent[0], "+14155552671 ") || !strings.Contains(sent[0], c.Answer()) {
+		t.Errorf("unexpected messages %v", sent)
+	}
+	if err := sc.Send(context.Background(), "0014155552671"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.Send(context.Background(), "+14155552671"); err != ErrRateLimited {
+		t.Errorf("expected error %v, got %v", ErrRateLimited, err)
+	}
+	if err := sc.Send(context.Background(), "4155552671"); err != ErrInvalidNumber {
+		t.Errorf("expected error %v, got %v", ErrInvalidNumber, err)
+	}
+	if err := manager.Verify(c.ID(), c.Answer(), true); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestNormalizeE164(t *testing.T) {
+	tests := []struct {
+		number string
+		valid  string
+	}{
+		{"+14155552671", "+14155552671"},
+		{"+44 20 7183 8750", "+442071838750"},
+		{"0086.138.0013.8000", "+8613800138000"},
+		{"4155552671", ""},
+		{"+0123", ""},
+		{"+1", ""},
+		{"+1234567890123456", ""},
+		{"+1415abc2671", ""},
+	}
+	for _, test := range tests {
+		number, err := NormalizeE164(test.number)
+		if test.valid == "" {
+			if err != ErrInvalidNumber {
+				t.Errorf("expected error %v of %q, got %v", ErrInvalidNumber, test.number, err)
+			}
+			continue
+		}
+		if err != nil || number != test.valid {
+			t.Errorf("expected %q of %q, got %q, %v", test.valid, test.number, number, err)
+		}
+	}
+}
+
+func TestMemoryRateLimiter(t *testing.T) {
+	l := NewMemoryRateLimiter(1, 50*time.Millisecond)
+	ctx := context.Background()
+	if err := l.Allow(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Allow(ctx, "a"); err != ErrRateLimited {
+		t.Errorf("expected error %v, got %v", ErrRateLimited, err)
+	}
+	if err := l.Allow(ctx, "b"); err != nil {
+		t.Errorf("unexpected error %v of another destination", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if err := l.Allow(ctx, "a"); err != nil {
+		t.Errorf("unexpected error %v after the period", err)
+	}
+}
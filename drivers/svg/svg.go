@@ -0,0 +1,255 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package svg provides a SVG captcha driver, the characters are rendered as
+// the outlines of glyphs, which are rotated and jittered, without any text or
+// raster image in the markup.
+package svg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"math"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/drivers/internal/imaging"
+	"github.com/mojocn/base64Captcha"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// Option is a function that receives a pointer of SVG driver.
+type Option func(*driver)
+
+// Width sets the width of image.
+func Width(width int) Option {
+	return func(d *driver) {
+		d.width = width
+	}
+}
+
+// Height sets the height of image.
+func Height(height int) Option {
+	return func(d *driver) {
+		d.height = height
+	}
+}
+
+// Length sets the number of characters.
+func Length(length int) Option {
+	return func(d *driver) {
+		d.length = length
+	}
+}
+
+// Source sets the characters to choose from.
+func Source(source string) Option {
+	return func(d *driver) {
+		d.source = source
+	}
+}
+
+// FontSize sets the font size in pixels, defaults to 60% of the height.
+func FontSize(size float64) Option {
+	return func(d *driver) {
+		d.fontSize = size
+	}
+}
+
+// Jitter sets the maximum offset in pixels of each point of paths.
+func Jitter(jitter float64) Option {
+	return func(d *driver) {
+		d.jitter = jitter
+	}
+}
+
+// NoiseCount sets the number of noise curves.
+func NoiseCount(count int) Option {
+	return func(d *driver) {
+		d.noiseCount = count
+	}
+}
+
+type driver struct {
+	width      int
+	height     int
+	length     int
+	source     string
+	fontSize   float64
+	jitter     float64
+	noiseCount int
+}
+
+// New returns a SVG driver.
+func New(opts ...Option) captchas.Driver {
+	d := &driver{
+		width:      200,
+		height:     70,
+		length:     4,
+		source:     "ABCDEFGHJKMNPQRSTUVWXYZabdefghijkmnpqrstuvwxyz23456789",
+		jitter:     1.5,
+		noiseCount: 4,
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// Generate implements Driver.Generate.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	answer := base64Captcha.RandText(d.length, d.source)
+	chars := []rune(answer)
+	size := d.fontSize
+	if size <= 0 {
+		size = float64(d.height) * 0.6
+	}
+	slot := float64(d.width) / float64(max(len(chars), 1))
+
+	var paths []string
+	f := imaging.Font()
+	buf := &sfnt.Buffer{}
+	for i, char := range chars {
+		index, err := f.GlyphIndex(buf, char)
+		if err != nil {
+			return nil, err
+		}
+		segments, err := f.LoadGlyph(buf, index, fixed.Int26_6(size*64), nil)
+		if err != nil {
+			return nil, err
+		}
+		b := segments.Bounds()
+		cx, cy := float64(b.Min.X+b.Max.X)/128, float64(b.Min.Y+b.Max.Y)/128
+		// moves the center of glyph to the center of slot, and rotates it.
+		t := transform{
+			dx:     slot*(float64(i)+0.5) + (rand.Float64()-0.5)*slot/4 - cx,
+			dy:     float64(d.height)/2 + (rand.Float64()-0.5)*float64(d.height)/8 - cy,
+			cx:     cx,
+			cy:     cy,
+			angle:  (rand.Float64() - 0.5) * math.Pi / 4,
+			jitter: d.jitter,
+		}
+		paths = append(paths, fmt.Sprintf(`<path d="%s" fill="%s"/>`, t.path(segments), randomColor()))
+	}
+	for i := 0; i < d.noiseCount; i++ {
+		paths = append(paths, d.noise())
+	}
+	// shuffles the paths, so that the order of characters is not revealed
+	// by the markup.
+	rand.Shuffle(len(paths), func(i, j int) {
+		paths[i], paths[j] = paths[j], paths[i]
+	})
+
+	svg := fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">%s</svg>`,
+		d.width, d.height, d.width, d.height, strings.Join(paths, ""))
+
+	return &Captcha{
+		id:     base64Captcha.RandomId(),
+		answer: answer,
+		svg:    svg,
+	}, nil
+}
+
+func (d *driver) noise() string {
+	w, h := float64(d.width), float64(d.height)
+	return fmt.Sprintf(`<path d="M%.1f %.1fC%.1f %.1f %.1f %.1f %.1f %.1f" fill="none" stroke="%s" stroke-width="%.1f"/>`,
+		rand.Float64()*w/4, rand.Float64()*h,
+		rand.Float64()*w, rand.Float64()*h,
+		rand.Float64()*w, rand.Float64()*h,
+		w-rand.Float64()*w/4, rand.Float64()*h,
+		randomColor(), 1+rand.Float64()*2)
+}
+
+type transform struct {
+	dx, dy float64
+	cx, cy float64
+	angle  float64
+	jitter float64
+}
+
+func (t transform) point(p fixed.Point26_6) string {
+	x, y := float64(p.X)/64-t.cx, float64(p.Y)/64-t.cy
+	sin, cos := math.Sincos(t.angle)
+	x, y = x*cos-y*sin+t.cx+t.dx, x*sin+y*cos+t.cy+t.dy
+	x += (rand.Float64()*2 - 1) * t.jitter
+	y += (rand.Float64()*2 - 1) * t.jitter
+	return fmt.Sprintf("%.1f %.1f", x, y)
+}
+
+func (t transform) path(segments sfnt.Segments) string {
+	var sb strings.Builder
+	for i, s := range segments {
+		switch s.Op {
+		case sfnt.SegmentOpMoveTo:
+			if i > 0 {
+				sb.WriteString("Z")
+			}
+			sb.WriteString("M" + t.point(s.Args[0]))
+		case sfnt.SegmentOpLineTo:
+			sb.WriteString("L" + t.point(s.Args[0]))
+		case sfnt.SegmentOpQuadTo:
+			sb.WriteString("Q" + t.point(s.Args[0]) + " " + t.point(s.Args[1]))
+		case sfnt.SegmentOpCubeTo:
+			sb.WriteString("C" + t.point(s.Args[0]) + " " + t.point(s.Args[1]) + " " + t.point(s.Args[2]))
+		}
+	}
+	sb.WriteString("Z")
+	return sb.String()
+}
+
+func randomColor() string {
+	return fmt.Sprintf("#%02x%02x%02x", rand.IntN(150), rand.IntN(150), rand.IntN(150))
+}
+
+var tmpl = template.Must(template.New("svg").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+{{ .svg }}
+`))
+
+// Captcha is a SVG captcha.
+type Captcha struct {
+	id     string
+	answer string
+	svg    string
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer.
+func (c *Captcha) Answer() string {
+	return c.answer
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns the data URI
+// of the SVG.
+func (c *Captcha) EncodeToString() string {
+	return "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString([]byte(c.svg))
+}
+
+// SVG returns the SVG markup.
+func (c *Captcha) SVG() string {
+	return c.svg
+}
+
+// HTMLField implements Captcha.HTMLField, the SVG is inlined.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":   c,
+		"fieldName": fieldName,
+		"svg":       template.HTML(c.svg),
+	})
+	return template.HTML(buf.String())
+}
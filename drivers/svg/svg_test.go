@@ -0,0 +1,50 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package svg
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	d := New(Width(100), Height(40), Length(5), Source("ab"), FontSize(20), Jitter(2), NoiseCount(1)).(*driver)
+	if d.width != 100 || d.height != 40 || d.length != 5 || d.source != "ab" || d.fontSize != 20 || d.jitter != 2 || d.noiseCount != 1 {
+		t.Errorf("unexpected driver %+v", d)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	c, err := New(Source("ABC"), Length(5), NoiseCount(3)).Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Answer()) != 5 || strings.Trim(c.Answer(), "ABC") != "" {
+		t.Errorf("unexpected answer %q", c.Answer())
+	}
+	svg := c.(*Captcha).SVG()
+	var doc struct {
+		Paths []struct {
+			D string `xml:"d,attr"`
+		} `xml:"path"`
+	}
+	if err := xml.Unmarshal([]byte(svg), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Paths) != 8 {
+		t.Errorf("expected 8 paths, got %d", len(doc.Paths))
+	}
+	if strings.Contains(svg, "<text") || strings.Contains(svg, c.Answer()) {
+		t.Errorf("expected no text in the markup %.200s", svg)
+	}
+	if !strings.HasPrefix(c.EncodeToString(), "data:image/svg+xml;base64,") {
+		t.Errorf("expected a SVG data URI, got %.30s", c.EncodeToString())
+	}
+	html := string(c.HTMLField("captcha_id"))
+	if !strings.Contains(html, c.ID()) || !strings.Contains(html, "<svg") {
+		t.Errorf("unexpected HTML %.200s", html)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package tiles provides banks of labeled tiles, which are shared by the
+// grid and odd-one-out drivers.
+package tiles
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/clevergo/captchas/drivers/internal/imaging"
+)
+
+// Bank is a bank of labeled tiles.
+type Bank interface {
+	// Labels returns all of labels, there must be two labels at least.
+	Labels() []string
+
+	// Tile returns a tile of the label, the tile is scaled to the size.
+	Tile(label string, size int) (image.Image, error)
+}
+
+// ErrUnknownLabel is returned by banks when there are no tiles of the label.
+var ErrUnknownLabel = errors.New("tiles: unknown label")
+
+type imageBank struct {
+	labels []string
+	images map[string][]image.Image
+}
+
+// NewImageBank returns a bank of the images grouped by labels.
+func NewImageBank(images map[string][]image.Image) Bank {
+	b := &imageBank{images: images}
+	for label, imgs := range images {
+		if len(imgs) > 0 {
+			b.labels = append(b.labels, label)
+		}
+	}
+	sort.Strings(b.labels)
+	return b
+}
+
+func (b *imageBank) Labels() []string {
+	return b.labels
+}
+
+func (b *imageBank) Tile(label string, size int) (image.Image, error) {
+	images := b.images[label]
+	if len(images) == 0 {
+		return nil, ErrUnknownLabel
+	}
+	return imaging.Scale(images[rand.IntN(len(images))], size, size), nil
+}
+
+type shapeBank struct{}
+
+var shapes = map[string]func(x, y, size int) bool{
+	"circle": func(x, y, size int) bool {
+		r := size / 2
+		return (x-r)*(x-r)+(y-r)*(y-r) <= r*r
+	},
+	"square": func(x, y, size int) bool {
+		return true
+	},
+	"triangle": func(x, y, size int) bool {
+		// the apex is at the middle of the top.
+		return 2*abs(x-size/2) <= y
+	},
+	"diamond": func(x, y, size int) bool {
+		return abs(x-size/2)+abs(y-size/2) <= size/2
+	},
+}
+
+// NewShapeBank returns a bank of random tiles of shapes, the labels are
+// circle, square, triangle and diamond.
+func NewShapeBank() Bank {
+	return shapeBank{}
+}
+
+func (shapeBank) Labels() []string {
+	return []string{"circle", "square", "triangle", "diamond"}
+}
+
+func (shapeBank) Tile(label string, size int) (image.Image, error) {
+	in, ok := shapes[label]
+	if !ok {
+		return nil, ErrUnknownLabel
+	}
+	img := imaging.Background(size, size)
+	shape := size/2 + rand.IntN(max(size/4, 1))
+	at := image.Pt(rand.IntN(max(size-shape, 1)), rand.IntN(max(size-shape, 1)))
+	c := imaging.RandomColor()
+	for y := 0; y < shape; y++ {
+		for x := 0; x < shape; x++ {
+			if in(x, y, shape) {
+				img.SetRGBA(at.X+x, at.Y+y, c)
+			}
+		}
+	}
+	return img, nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Compose composes the tiles in rows of the given columns, with the gap
+// between tiles and around them on a white background.
+func Compose(tiles []image.Image, columns, size, gap int) *image.RGBA {
+	rows := (len(tiles) + columns - 1) / columns
+	img := image.NewRGBA(image.Rect(0, 0, columns*size+(columns+1)*gap, rows*size+(rows+1)*gap))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	for i, tile := range tiles {
+		x, y := gap+(i%columns)*(size+gap), gap+(i/columns)*(size+gap)
+		draw.Draw(img, image.Rect(x, y, x+size, y+size), tile, tile.Bounds().Min, draw.Over)
+	}
+	return img
+}
+
+// Index returns the index of the tile at the point of the composed image,
+// or -1 if the point is not on any tile.
+func Index(p image.Point, count, columns, size, gap int) int {
+	col, x := (p.X-gap)/(size+gap), (p.X-gap)%(size+gap)
+	row, y := (p.Y-gap)/(size+gap), (p.Y-gap)%(size+gap)
+	if p.X < gap || p.Y < gap || x >= size || y >= size || col >= columns {
+		return -1
+	}
+	if i := row*columns + col; i < count {
+		return i
+	}
+	return -1
+}
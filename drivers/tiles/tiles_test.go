@@ -0,0 +1,79 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package tiles
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+func TestImageBank(t *testing.T) {
+	bank := NewImageBank(map[string][]image.Image{
+		"b":     {image.NewUniform(color.Black)},
+		"a":     {image.NewUniform(color.White)},
+		"empty": nil,
+	})
+	if labels := bank.Labels(); !slices.Equal(labels, []string{"a", "b"}) {
+		t.Errorf("unexpected labels %v", labels)
+	}
+	tile, err := bank.Tile("a", 10)
+	if err != nil || tile.Bounds() != image.Rect(0, 0, 10, 10) {
+		t.Errorf("unexpected tile %v, %v", tile, err)
+	}
+	if _, err := bank.Tile("empty", 10); err != ErrUnknownLabel {
+		t.Errorf("expected error %v, got %v", ErrUnknownLabel, err)
+	}
+}
+
+func TestShapeBank(t *testing.T) {
+	bank := NewShapeBank()
+	for _, label := range bank.Labels() {
+		if _, err := bank.Tile(label, 40); err != nil {
+			t.Errorf("unexpected error %v of label %q", err, label)
+		}
+	}
+	if _, err := bank.Tile("cat", 40); err != ErrUnknownLabel {
+		t.Errorf("expected error %v, got %v", ErrUnknownLabel, err)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	images := make([]image.Image, 5)
+	for i := range images {
+		images[i] = image.NewUniform(color.Black)
+	}
+	img := Compose(images, 3, 10, 2)
+	if img.Bounds() != image.Rect(0, 0, 38, 26) {
+		t.Errorf("unexpected bounds %v", img.Bounds())
+	}
+	if img.RGBAAt(1, 1) != (color.RGBA{0xff, 0xff, 0xff, 0xff}) || img.RGBAAt(2, 2) != (color.RGBA{A: 0xff}) {
+		t.Error("expected the tiles on the white background")
+	}
+}
+
+func TestIndex(t *testing.T) {
+	tests := []struct {
+		p     image.Point
+		index int
+	}{
+		{image.Pt(2, 2), 0},
+		{image.Pt(11, 11), 0},
+		{image.Pt(12, 2), -1},
+		{image.Pt(14, 2), 1},
+		{image.Pt(26, 2), 2},
+		{image.Pt(16, 16), 4},
+		{image.Pt(2, 14), 3},
+		{image.Pt(1, 1), -1},
+		{image.Pt(40, 2), -1},
+		{image.Pt(30, 20), -1},
+	}
+	for _, test := range tests {
+		if index := Index(test.p, 5, 3, 10, 2); index != test.index {
+			t.Errorf("expected index %d of %v, got %d", test.index, test.p, index)
+		}
+	}
+}
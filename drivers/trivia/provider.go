@@ -0,0 +1,103 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package trivia
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// Question is a question and its accepted answers.
+type Question struct {
+	Text    string
+	Answers []string
+}
+
+// QuestionProvider provides questions.
+type QuestionProvider interface {
+	Question() (Question, error)
+}
+
+// ProviderFunc is an adapter to use ordinary functions as question
+// providers.
+type ProviderFunc func() (Question, error)
+
+// Question implements QuestionProvider.Question.
+func (f ProviderFunc) Question() (Question, error) {
+	return f()
+}
+
+// ErrNoQuestions is returned by providers that have no questions.
+var ErrNoQuestions = errors.New("trivia: no questions")
+
+type bank []Question
+
+// NewBank returns a provider that picks questions from the given ones
+// randomly.
+func NewBank(questions ...Question) QuestionProvider {
+	return bank(questions)
+}
+
+func (b bank) Question() (Question, error) {
+	if len(b) == 0 {
+		return Question{}, ErrNoQuestions
+	}
+	return b[rand.IntN(len(b))], nil
+}
+
+// DefaultQuestions are the questions of the default bank.
+var DefaultQuestions = []Question{
+	{Text: "What color is the sky on a sunny day?", Answers: []string{"blue"}},
+	{Text: "How many legs does a cat have?", Answers: []string{"4", "four"}},
+	{Text: "What is the opposite of hot?", Answers: []string{"cold"}},
+	{Text: "How many days are there in a week?", Answers: []string{"7", "seven"}},
+	{Text: "What is the first letter of the word \"apple\"?", Answers: []string{"a"}},
+	{Text: "Which is bigger, an elephant or a mouse?", Answers: []string{"elephant", "an elephant"}},
+	{Text: "What do bees make?", Answers: []string{"honey"}},
+	{Text: "What color is grass?", Answers: []string{"green"}},
+}
+
+type wordProvider struct {
+	words  []string
+	length int
+}
+
+// NewWordProvider returns a provider that asks for the word at a random
+// position of a random phrase, such as "What is the 2nd word of "hello brave
+// world"?". The phrase consists of the given number of words.
+func NewWordProvider(words []string, length int) QuestionProvider {
+	return &wordProvider{words: words, length: length}
+}
+
+func (p *wordProvider) Question() (Question, error) {
+	if len(p.words) == 0 || p.length <= 0 {
+		return Question{}, ErrNoQuestions
+	}
+	phrase := make([]string, p.length)
+	for i := range phrase {
+		phrase[i] = p.words[rand.IntN(len(p.words))]
+	}
+	n := rand.IntN(p.length)
+	return Question{
+		Text:    fmt.Sprintf("What is the %s word of %q?", ordinal(n+1), strings.Join(phrase, " ")),
+		Answers: []string{phrase[n]},
+	}, nil
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package trivia provides a question and answer captcha driver, which works
+// in text-only channels, such as CLI, chat bots and plain HTML forms.
+// Questions come from a QuestionProvider, so that sites can use their own
+// localized questions.
+package trivia
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"strings"
+
+	"github.com/clevergo/captchas"
+	"github.com/mojocn/base64Captcha"
+)
+
+// Option is a function that receives a pointer of trivia driver.
+type Option func(*driver)
+
+// Provider sets the question provider, defaults to the bank of
+// DefaultQuestions.
+func Provider(provider QuestionProvider) Option {
+	return func(d *driver) {
+		d.provider = provider
+	}
+}
+
+type driver struct {
+	provider QuestionProvider
+}
+
+// New returns a trivia driver.
+func New(opts ...Option) captchas.Driver {
+	d := &driver{
+		provider: NewBank(DefaultQuestions...),
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	return d
+}
+
+// Generate implements Driver.Generate.
+func (d *driver) Generate() (captchas.Captcha, error) {
+	q, err := d.provider.Question()
+	if err != nil {
+		return nil, err
+	}
+	if len(q.Answers) == 0 {
+		return nil, errors.New("trivia: the question has no answers")
+	}
+
+	return &Captcha{
+		id:       base64Captcha.RandomId(),
+		question: q,
+	}, nil
+}
+
+// Match implements Matcher.Match, the answer matches if it equals to any of
+// the accepted answers case-insensitively, regardless of extra whitespaces.
+func (d *driver) Match(actual, answer string) bool {
+	actual = normalize(actual)
+	for _, a := range strings.Split(answer, "\n") {
+		if strings.EqualFold(actual, normalize(a)) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+var tmpl = template.Must(template.New("trivia").Parse(`
+<input type="hidden" name="{{ .fieldName }}" value="{{ .captcha.ID }}">
+<label>{{ .captcha.Question }}</label>
+`))
+
+// Captcha is a trivia captcha.
+type Captcha struct {
+	id       string
+	question Question
+}
+
+var _ captchas.Captcha = (*Captcha)(nil)
+
+// ID implements Captcha.ID.
+func (c *Captcha) ID() string {
+	return c.id
+}
+
+// Answer implements Captcha.Answer, it is the accepted answers separated by
+// newlines.
+func (c *Captcha) Answer() string {
+	return strings.Join(c.question.Answers, "\n")
+}
+
+// EncodeToString implements Captcha.EncodeToString, it returns the question.
+func (c *Captcha) EncodeToString() string {
+	return c.question.Text
+}
+
+// Question returns the text of question.
+func (c *Captcha) Question() string {
+	return c.question.Text
+}
+
+// HTMLField implements Captcha.HTMLField.
+func (c *Captcha) HTMLField(fieldName string) template.HTML {
+	buf := &bytes.Buffer{}
+	tmpl.Execute(buf, map[string]interface{}{
+		"captcha":   c,
+		"fieldName": fieldName,
+	})
+	return template.HTML(buf.String())
+}
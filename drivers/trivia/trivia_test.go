@@ -0,0 +1,88 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package trivia
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestGenerate(t *testing.T) {
+	q := Question{Text: "What color is the sky?", Answers: []string{"blue", "azure"}}
+	c, err := New(Provider(NewBank(q))).Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.EncodeToString() != q.Text || c.(*Captcha).Question() != q.Text || c.Answer() != "blue\nazure" {
+		t.Errorf("unexpected captcha %+v", c)
+	}
+	html := string(c.HTMLField("captcha_id"))
+	if !strings.Contains(html, c.ID()) || !strings.Contains(html, "What color is the sky?") {
+		t.Errorf("unexpected HTML %s", html)
+	}
+
+	if _, err := New(Provider(NewBank())).Generate(); err != ErrNoQuestions {
+		t.Errorf("expected error %v, got %v", ErrNoQuestions, err)
+	}
+	if _, err := New(Provider(NewBank(Question{Text: "?"}))).Generate(); err == nil {
+		t.Error("expected an error if the question has no answers")
+	}
+	if _, err := New().Generate(); err != nil {
+		t.Errorf("unexpected error %v of default questions", err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	m := New().(captchas.Matcher)
+	tests := []struct {
+		actual string
+		match  bool
+	}{
+		{"blue", true},
+		{" Blue ", true},
+		{"AZURE", true},
+		{"light  blue", true},
+		{"red", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if match := m.Match(test.actual, "blue\nazure\nlight blue"); match != test.match {
+			t.Errorf("expected %q matches %t, got %t", test.actual, test.match, match)
+		}
+	}
+}
+
+func TestProviderFunc(t *testing.T) {
+	p := ProviderFunc(func() (Question, error) {
+		return Question{Text: "1+1?", Answers: []string{"2"}}, nil
+	})
+	if q, err := p.Question(); err != nil || q.Text != "1+1?" {
+		t.Errorf("unexpected question %+v, %v", q, err)
+	}
+}
+
+func TestWordProvider(t *testing.T) {
+	p := NewWordProvider([]string{"hello"}, 3)
+	q, err := p.Question()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(q.Text, `"hello hello hello"`) || len(q.Answers) != 1 || q.Answers[0] != "hello" {
+		t.Errorf("unexpected question %+v", q)
+	}
+	if _, err := NewWordProvider(nil, 3).Question(); err != ErrNoQuestions {
+		t.Errorf("expected error %v, got %v", ErrNoQuestions, err)
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	for n, s := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 22: "22nd", 101: "101st"} {
+		if o := ordinal(n); o != s {
+			t.Errorf("expected %s, got %s", s, o)
+		}
+	}
+}
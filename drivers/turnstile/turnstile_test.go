@@ -0,0 +1,107 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package turnstile
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/memstore"
+)
+
+func TestNew(t *testing.T) {
+	client := &http.Client{}
+	d := New("site", "secret", Action("login"), Hostname("example.com"), HTTPClient(client), Timeout(time.Second), URL("url")).(*driver)
+	v := d.verifier
+	if d.siteKey != "site" || v.Secret != "secret" || v.Action != "login" || v.Hostname != "example.com" ||
+		v.Client != client || v.Timeout != time.Second || v.URL != "url" {
+		t.Errorf("unexpected driver %+v", v)
+	}
+	if New("site", "secret").(*driver).verifier.URL != VerifyURL {
+		t.Error("expected the default URL")
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	c, err := New("site", "secret", Action("login")).Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := string(c.HTMLField("captcha_id"))
+	for _, s := range []string{c.ID(), `class="cf-turnstile"`, `data-sitekey="site"`, `data-action="login"`} {
+		if !strings.Contains(html, s) {
+			t.Errorf("expected %q in HTML %s", s, html)
+		}
+	}
+	if c.EncodeToString() != "site" || c.Answer() != "turnstile" {
+		t.Errorf("unexpected captcha %+v", c)
+	}
+}
+
+func TestManager(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.PostFormValue("response")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success":  r.PostFormValue("secret") == "secret" && token != "invalid",
+			"hostname": map[string]string{"other-host": "example.org"}[token],
+			"action":   "login",
+		})
+	}))
+	defer srv.Close()
+
+	manager := captchas.New(memstore.New(), New("site", "secret", URL(srv.URL), Action("login")))
+	tests := map[string]error{
+		"token":   nil,
+		"invalid": captchas.ErrIncorrectCaptcha,
+	}
+	for token, expected := range tests {
+		c, err := manager.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := manager.Verify(c.ID(), token, true); err != expected {
+			t.Errorf("expected error %v of token %q, got %v", expected, token, err)
+		}
+	}
+
+	manager = captchas.New(memstore.New(), New("site", "secret", URL(srv.URL), Hostname("example.org")))
+	c, _ := manager.Generate()
+	if err := manager.Verify(c.ID(), "token", true); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v of the unexpected hostname, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	c, _ = manager.Generate()
+	if err := manager.Verify(c.ID(), "other-host", true); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestManagerUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.PostFormValue("response") == "broken" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+	}))
+	defer srv.Close()
+
+	manager := captchas.New(memstore.New(), New("site", "secret", URL(srv.URL)), captchas.MaxAttempts(1))
+	c, err := manager.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Verify(c.ID(), "broken", false); !errors.Is(err, captchas.ErrDriverUnavailable) {
+		t.Errorf("expected error %v, got %v", captchas.ErrDriverUnavailable, err)
+	}
+	if err := manager.Verify(c.ID(), "token", true); err != nil {
+		t.Errorf("expected the captcha is still valid, got %v", err)
+	}
+}
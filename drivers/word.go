@@ -0,0 +1,132 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package drivers
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"errors"
+	"image/color"
+	"io"
+	"math/rand/v2"
+	"strings"
+
+	"github.com/clevergo/captchas"
+	"github.com/mojocn/base64Captcha"
+)
+
+//go:embed wordlists/english.txt
+var englishWords []byte
+
+// EnglishWords is the default wordlist of common English words.
+var EnglishWords, _ = ParseWordList(bytes.NewReader(englishWords))
+
+// ParseWordList parses a wordlist of one word per line, blank lines and
+// lines starting with "#" are ignored.
+func ParseWordList(r io.Reader) ([]string, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
+
+// WordOption is a function that receives a pointer of word driver.
+type WordOption func(*word)
+
+// WordHeight sets height.
+func WordHeight(height int) WordOption {
+	return func(w *word) {
+		w.height = height
+	}
+}
+
+// WordWidth sets width.
+func WordWidth(width int) WordOption {
+	return func(w *word) {
+		w.width = width
+	}
+}
+
+// WordNoiseCount sets noise count.
+func WordNoiseCount(count int) WordOption {
+	return func(w *word) {
+		w.noiseCount = count
+	}
+}
+
+// WordBGColor sets background color.
+func WordBGColor(color *color.RGBA) WordOption {
+	return func(w *word) {
+		w.bgColor = color
+	}
+}
+
+// WordFonts sets fonts.
+func WordFonts(fonts []string) WordOption {
+	return func(w *word) {
+		w.fonts = fonts
+	}
+}
+
+// WordList sets the wordlist, defaults to EnglishWords.
+func WordList(words []string) WordOption {
+	return func(w *word) {
+		w.words = words
+	}
+}
+
+type word struct {
+	// captcha png height in pixel.
+	height int
+	// captcha png width in pixel.
+	width int
+	// text noise count.
+	noiseCount      int
+	showLineOptions int
+	// background color.
+	bgColor *color.RGBA
+	fonts   []string
+	words   []string
+	driver  *base64Captcha.DriverString
+}
+
+// NewWord returns a word driver, which renders real words of the wordlist
+// instead of random strings.
+func NewWord(opts ...WordOption) captchas.Driver {
+	d := &word{
+		height: 80,
+		width:  220,
+		words:  EnglishWords,
+	}
+
+	for _, f := range opts {
+		f(d)
+	}
+
+	d.driver = base64Captcha.NewDriverString(d.height, d.width, d.noiseCount, d.showLineOptions, 0, "", d.bgColor, d.fonts)
+
+	return d
+}
+
+// Generate implements Driver.Generate.
+func (d *word) Generate() (captchas.Captcha, error) {
+	if len(d.words) == 0 {
+		return nil, errors.New("drivers: empty wordlist")
+	}
+	answer := d.words[rand.IntN(len(d.words))]
+	item, err := d.driver.DrawCaptcha(answer)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCaptcha(base64Captcha.RandomId(), answer, htmlTagIMG, item), nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package drivers
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseWordList(t *testing.T) {
+	words, err := ParseWordList(strings.NewReader("# comment\napple\n\n  banana \n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(words, []string{"apple", "banana"}) {
+		t.Errorf("unexpected words %v", words)
+	}
+	if len(EnglishWords) == 0 || slices.Contains(EnglishWords, "") {
+		t.Errorf("unexpected English words %v", EnglishWords)
+	}
+}
+
+func TestNewWord(t *testing.T) {
+	words := []string{"apple"}
+	d := NewWord(WordHeight(60), WordWidth(200), WordNoiseCount(2), WordList(words)).(*word)
+	if d.height != 60 || d.width != 200 || d.noiseCount != 2 || !reflect.DeepEqual(d.words, words) {
+		t.Errorf("unexpected driver %+v", d)
+	}
+	c, err := d.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Answer() != "apple" || !strings.HasPrefix(c.EncodeToString(), "data:image/png;base64,") {
+		t.Errorf("unexpected captcha %q, %.30s", c.Answer(), c.EncodeToString())
+	}
+
+	if _, err := NewWord(WordList(nil)).Generate(); err == nil {
+		t.Error("expected an error of empty wordlist")
+	}
+}
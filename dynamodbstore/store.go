@@ -0,0 +1,165 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package dynamodbstore
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/clevergo/captchas"
+)
+
+// Attribute names of captcha items, the table's partition key must be
+// attrID, and DynamoDB TTL should be enabled on attrExpiration.
+const (
+	attrID         = "id"
+	attrAnswer     = "answer"
+	attrExpiration = "expiration"
+)
+
+// Option is a function that receives a pointer of dynamodb store.
+type Option func(s *store)
+
+// Table sets the table name.
+func Table(table string) Option {
+	return func(s *store) {
+		s.table = table
+	}
+}
+
+// Expiration sets the expiration.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+type client interface {
+	GetItem(context.Context, *dynamodb.GetItemInput, ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	DeleteItem(context.Context, *dynamodb.DeleteItemInput, ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+}
+
+type store struct {
+	client     client
+	table      string
+	expiration time.Duration
+}
+
+// New returns a dynamodb store.
+func New(client *dynamodb.Client, opts ...Option) captchas.Store {
+	s := &store{
+		client:     client,
+		table:      "captchas",
+		expiration: 10 * time.Minute,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	return s
+}
+
+func (s *store) key(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		attrID: &types.AttributeValueMemberS{Value: id},
+	}
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	var item map[string]types.AttributeValue
+	if clear {
+		out, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName:    aws.String(s.table),
+			Key:          s.key(id),
+			ReturnValues: types.ReturnValueAllOld,
+		})
+		if err != nil {
+			return "", err
+		}
+		item = out.Attributes
+	} else {
+		out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:      aws.String(s.table),
+			Key:            s.key(id),
+			ConsistentRead: aws.Bool(true),
+		})
+		if err != nil {
+			return "", err
+		}
+		item = out.Item
+	}
+
+	return parseItem(item)
+}
+
+// parseItem returns the answer of the given item. DynamoDB deletes expired
+// items lazily, so the expiration has to be checked as well.
+func parseItem(item map[string]types.AttributeValue) (string, error) {
+	if len(item) == 0 {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+
+	if v, ok := item[attrExpiration].(*types.AttributeValueMemberN); ok {
+		expiration, err := strconv.ParseInt(v.Value, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		if time.Now().Unix() > expiration {
+			return "", captchas.ErrExpiredCaptcha
+		}
+	}
+
+	answer, ok := item[attrAnswer].(*types.AttributeValueMemberS)
+	if !ok {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+
+	return answer.Value, nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	return s.set(ctx, id, answer, s.expiration)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.SetWithTTLContext(context.Background(), id, answer, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	return s.set(ctx, id, answer, ttl)
+}
+
+func (s *store) set(ctx context.Context, id, answer string, ttl time.Duration) error {
+	expiration := time.Now().Add(ttl).Unix()
+	_, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item: map[string]types.AttributeValue{
+			attrID:         &types.AttributeValueMemberS{Value: id},
+			attrAnswer:     &types.AttributeValueMemberS{Value: answer},
+			attrExpiration: &types.AttributeValueMemberN{Value: strconv.FormatInt(expiration, 10)},
+		},
+	})
+	return err
+}
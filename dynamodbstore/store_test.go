@@ -0,0 +1,94 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package dynamodbstore
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/clevergo/captchas"
+)
+
+type testClient struct {
+	items map[string]map[string]types.AttributeValue
+}
+
+func newTestClient() *testClient {
+	return &testClient{items: make(map[string]map[string]types.AttributeValue)}
+}
+
+func (c *testClient) id(key map[string]types.AttributeValue) string {
+	return key[attrID].(*types.AttributeValueMemberS).Value
+}
+
+func (c *testClient) GetItem(_ context.Context, in *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return &dynamodb.GetItemOutput{Item: c.items[c.id(in.Key)]}, nil
+}
+
+func (c *testClient) PutItem(_ context.Context, in *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	c.items[c.id(in.Item)] = in.Item
+	return &dynamodb.PutItemOutput{}, nil
+}
+
+func (c *testClient) DeleteItem(_ context.Context, in *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	id := c.id(in.Key)
+	item := c.items[id]
+	delete(c.items, id)
+	return &dynamodb.DeleteItemOutput{Attributes: item}, nil
+}
+
+func TestNew(t *testing.T) {
+	table := "foo"
+	expiration := 5 * time.Minute
+	s, _ := New(nil, Table(table), Expiration(expiration)).(*store)
+	if s.table != table {
+		t.Errorf("expected table %s, got %s", table, s.table)
+	}
+	if s.expiration != expiration {
+		t.Errorf("expected expiration %v, got %v", expiration, s.expiration)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	s := &store{client: newTestClient(), table: "captchas", expiration: time.Minute}
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+func TestParseItem(t *testing.T) {
+	expired := strconv.FormatInt(time.Now().Add(-time.Second).Unix(), 10)
+	_, err := parseItem(map[string]types.AttributeValue{
+		attrAnswer:     &types.AttributeValueMemberS{Value: "bar"},
+		attrExpiration: &types.AttributeValueMemberN{Value: expired},
+	})
+	if err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package encryptedstore wraps a store and encrypts answers with AES-GCM, so
+// that plaintext answers never reach the underlying store.
+package encryptedstore
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+// ErrInvalidCiphertext is returned if an answer can't be decrypted by any key.
+var ErrInvalidCiphertext = errors.New("encryptedstore: invalid ciphertext")
+
+// Option is a function that receives a pointer of store.
+type Option func(*store)
+
+// DecryptionKeys sets extra keys which are only used for decryption, it is
+// useful for rotating keys without invalidating outstanding captchas.
+func DecryptionKeys(keys ...[]byte) Option {
+	return func(s *store) {
+		s.keys = append(s.keys, keys...)
+	}
+}
+
+type store struct {
+	store captchas.Store
+	keys  [][]byte
+	aeads []cipher.AEAD
+}
+
+// New returns an encrypted store that delegates to the given store, the key
+// should be 16, 24, or 32 bytes to select AES-128, AES-192, or AES-256.
+func New(s captchas.Store, key []byte, opts ...Option) (captchas.Store, error) {
+	es := &store{
+		store: s,
+		keys:  [][]byte{key},
+	}
+
+	for _, f := range opts {
+		f(es)
+	}
+
+	for _, key := range es.keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		es.aeads = append(es.aeads, aead)
+	}
+
+	return es, nil
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	value, err := captchas.GetContext(ctx, s.store, id, clear)
+	if err != nil {
+		return "", err
+	}
+	return s.decrypt(id, value)
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	value, err := s.encrypt(id, answer)
+	if err != nil {
+		return err
+	}
+	return captchas.SetContext(ctx, s.store, id, value)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.SetWithTTLContext(context.Background(), id, answer, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	value, err := s.encrypt(id, answer)
+	if err != nil {
+		return err
+	}
+	return captchas.SetWithTTLContext(ctx, s.store, id, value, ttl)
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *store) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	return s.SetIfNotExistsContext(context.Background(), id, answer, ttl)
+}
+
+// SetIfNotExistsContext implements ContextNXStore.SetIfNotExistsContext.
+func (s *store) SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error) {
+	value, err := s.encrypt(id, answer)
+	if err != nil {
+		return false, err
+	}
+	return captchas.SetIfNotExistsContext(ctx, s.store, id, value, ttl)
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (s *store) Attempt(id string) (int, error) {
+	return s.AttemptContext(context.Background(), id)
+}
+
+// AttemptContext implements ContextAttemptStore.AttemptContext.
+func (s *store) AttemptContext(ctx context.Context, id string) (int, error) {
+	return captchas.AttemptContext(ctx, s.store, id)
+}
+
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext implements ContextDeleteStore.DeleteContext.
+func (s *store) DeleteContext(ctx context.Context, id string) error {
+	return captchas.DeleteContext(ctx, s.store, id)
+}
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	return s.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext implements ContextTouchStore.TouchContext.
+func (s *store) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
+	return captchas.TouchContext(ctx, s.store, id, ttl)
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *store) Exists(id string) (bool, error) {
+	return s.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext implements ContextExistsStore.ExistsContext.
+func (s *store) ExistsContext(ctx context.Context, id string) (bool, error) {
+	return captchas.ExistsContext(ctx, s.store, id)
+}
+
+// Close closes the underlying store if it implements io.Closer.
+func (s *store) Close() error {
+	return captchas.Close(s.store)
+}
+
+// encrypt seals the answer with a random nonce, the ID is used as additional
+// data, so that ciphertexts can't be swapped between captchas.
+func (s *store) encrypt(id, answer string) (string, error) {
+	aead := s.aeads[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(answer)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	data := aead.Seal(nonce, nonce, []byte(answer), []byte(id))
+	return base64.RawStdEncoding.EncodeToString(data), nil
+}
+
+func (s *store) decrypt(id, value string) (string, error) {
+	data, err := base64.RawStdEncoding.DecodeString(value)
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+	for _, aead := range s.aeads {
+		if len(data) < aead.NonceSize() {
+			continue
+		}
+		nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+		if answer, err := aead.Open(nil, nonce, ciphertext, []byte(id)); err == nil {
+			return string(answer), nil
+		}
+	}
+	return "", ErrInvalidCiphertext
+}
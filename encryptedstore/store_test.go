@@ -0,0 +1,132 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package encryptedstore
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/memstore"
+)
+
+var (
+	key    = bytes.Repeat([]byte("k"), 32)
+	oldKey = bytes.Repeat([]byte("o"), 16)
+)
+
+func TestNew(t *testing.T) {
+	s, err := New(memstore.New(), key, DecryptionKeys(oldKey))
+	if err != nil {
+		t.Fatalf("failed to create store: %s", err)
+	}
+	es, _ := s.(*store)
+	if len(es.aeads) != 2 {
+		t.Errorf("expected %d keys, got %d", 2, len(es.aeads))
+	}
+
+	for _, k := range [][]byte{nil, []byte("short")} {
+		if _, err = New(memstore.New(), k); err == nil {
+			t.Errorf("expected an error for key %q, got nil", k)
+		}
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	mem := memstore.New()
+	s, err := New(mem, key)
+	if err != nil {
+		t.Fatalf("failed to create store: %s", err)
+	}
+	_, err = s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if value, _ := mem.Get("foo", false); value == "bar" {
+		t.Error("expected an encrypted answer, got plaintext")
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
+
+func TestStoreDecrypt(t *testing.T) {
+	mem := memstore.New()
+	old, _ := New(mem, oldKey)
+	s, _ := New(mem, key, DecryptionKeys(oldKey))
+
+	// answers encrypted by the old key are still available.
+	old.Set("foo", "bar")
+	if value, err := s.Get("foo", false); err != nil || value != "bar" {
+		t.Errorf("expected value %q, got %q, %v", "bar", value, err)
+	}
+
+	// ciphertexts are bound to IDs.
+	value, _ := mem.Get("foo", false)
+	mem.Set("fizz", value)
+	if _, err := s.Get("fizz", false); err != ErrInvalidCiphertext {
+		t.Errorf("expected error %v, got %v", ErrInvalidCiphertext, err)
+	}
+
+	for _, value := range []string{"", "!", "YWJj"} {
+		mem.Set("invalid", value)
+		if _, err := s.Get("invalid", false); err != ErrInvalidCiphertext {
+			t.Errorf("expected error %v, got %v", ErrInvalidCiphertext, err)
+		}
+	}
+}
+
+func TestStoreForwarding(t *testing.T) {
+	mem := memstore.New()
+	s, err := New(mem, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.(captchas.NXStore).SetIfNotExists("foo", "bar", time.Minute); err != nil || !ok {
+		t.Fatalf("expected to be saved, got %t, %v", ok, err)
+	}
+	if ok, _ := s.(captchas.NXStore).SetIfNotExists("foo", "baz", time.Minute); ok {
+		t.Error("expected the ID to be taken")
+	}
+	if value, _ := mem.Get("foo", false); value == "bar" {
+		t.Error("expected the answer to be encrypted")
+	}
+	if n, err := s.(captchas.AttemptStore).Attempt("foo"); err != nil || n != 1 {
+		t.Errorf("expected attempts %d, got %d, %v", 1, n, err)
+	}
+	if err := captchas.Touch(s, "foo", time.Hour); err != nil {
+		t.Errorf("failed to touch: %s", err)
+	}
+	if ok, err := captchas.ExistsContext(context.Background(), s, "foo"); err != nil || !ok {
+		t.Errorf("expected the captcha to exist, got %t, %v", ok, err)
+	}
+	if value, err := captchas.GetContext(context.Background(), s, "foo", false); err != nil || value != "bar" {
+		t.Errorf("expected answer %q, got %q, %v", "bar", value, err)
+	}
+	if err := s.(captchas.DeleteStore).Delete("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := captchas.Exists(mem, "foo"); ok {
+		t.Error("expected the captcha to be deleted")
+	}
+}
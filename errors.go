@@ -0,0 +1,92 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package captchas
+
+import "errors"
+
+// Errors
+var (
+	ErrIncorrectCaptcha    = errors.New("incorrect captcha")
+	ErrExpiredCaptcha      = errors.New("expired captcha")
+	ErrStoreUnavailable    = errors.New("store unavailable")
+	ErrTTLUnsupported      = errors.New("store doesn't support TTL")
+	ErrCaptchaCollision    = errors.New("captcha ID collision")
+	ErrTouchUnsupported    = errors.New("store doesn't support touch")
+	ErrMetadataUnsupported = errors.New("store doesn't support metadata")
+	ErrAttemptsUnsupported = errors.New("store doesn't support attempts")
+	ErrTooManyAttempts     = errors.New("too many attempts")
+	ErrMatcherUnsupported  = errors.New("store doesn't support matcher")
+	ErrDriverUnavailable   = errors.New("driver unavailable")
+)
+
+// StoreError records a failure of store backend, such as a timeout, as
+// opposed to incorrect and expired captchas. It matches ErrStoreUnavailable,
+// and unwraps to the underlying error.
+//
+//	var serr *captchas.StoreError
+//	if errors.As(err, &serr) {
+//		log.Printf("store %s failed: %s", serr.Op, serr.Err)
+//	}
+type StoreError struct {
+	Op  string
+	Err error
+}
+
+// Error implements error.
+func (e *StoreError) Error() string {
+	return "captchas: store " + e.Op + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *StoreError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrStoreUnavailable.
+func (e *StoreError) Is(target error) bool {
+	return target == ErrStoreUnavailable
+}
+
+// DriverError records a failure of the remote service of driver, such as an
+// outage of siteverify API, as opposed to incorrect captchas. It matches
+// ErrDriverUnavailable, and unwraps to the underlying error.
+type DriverError struct {
+	Op  string
+	Err error
+}
+
+// Error implements error.
+func (e *DriverError) Error() string {
+	return "captchas: driver " + e.Op + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *DriverError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrDriverUnavailable.
+func (e *DriverError) Is(target error) bool {
+	return target == ErrDriverUnavailable
+}
+
+// IsCaptchaError reports whether the error indicates an incorrect or expired
+// captcha, rather than a failure of store.
+func IsCaptchaError(err error) bool {
+	return errors.Is(err, ErrIncorrectCaptcha) || errors.Is(err, ErrExpiredCaptcha)
+}
+
+// wrapError wraps the error returned by store as StoreError, unless it is an
+// error of this package, such as ErrIncorrectCaptcha and ErrTTLUnsupported.
+func wrapError(op string, err error) error {
+	if err == nil || IsCaptchaError(err) || errors.Is(err, ErrStoreUnavailable) {
+		return err
+	}
+	switch err {
+	case ErrTTLUnsupported, ErrTouchUnsupported, ErrMetadataUnsupported, ErrAttemptsUnsupported, ErrTooManyAttempts, ErrCaptchaCollision, ErrMatcherUnsupported:
+		return err
+	}
+	return &StoreError{Op: op, Err: err}
+}
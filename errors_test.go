@@ -0,0 +1,59 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package captchas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIsCaptchaError(t *testing.T) {
+	cases := []struct {
+		err      error
+		expected bool
+	}{
+		{nil, false},
+		{ErrIncorrectCaptcha, true},
+		{ErrExpiredCaptcha, true},
+		{fmt.Errorf("wrapped: %w", ErrExpiredCaptcha), true},
+		{errors.New("timeout"), false},
+	}
+	for _, test := range cases {
+		if actual := IsCaptchaError(test.err); actual != test.expected {
+			t.Errorf("expected IsCaptchaError(%v) %t, got %t", test.err, test.expected, actual)
+		}
+	}
+}
+
+func TestManagerStoreError(t *testing.T) {
+	m := New(testMapStore{"foo": "error", "fizz": "buzz"}, &testDriver{})
+	_, err := m.Get("foo", false)
+	var serr *StoreError
+	if !errors.As(err, &serr) || serr.Op != "get" {
+		t.Fatalf("expected a store error, got %v", err)
+	}
+	if !errors.Is(err, ErrStoreUnavailable) {
+		t.Errorf("expected error to match %v", ErrStoreUnavailable)
+	}
+	if err = m.Verify("foo", "bar", false); !errors.As(err, &serr) {
+		t.Errorf("expected a store error, got %v", err)
+	}
+	if _, err = m.Get("missing", false); err != ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", ErrIncorrectCaptcha, err)
+	}
+	if err = m.Verify("fizz", "bar", false); err != ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", ErrIncorrectCaptcha, err)
+	}
+
+	err = wrapError("get", context.DeadlineExceeded)
+	if !errors.Is(err, context.DeadlineExceeded) || err.Error() != "captchas: store get: "+context.DeadlineExceeded.Error() {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err = wrapError("set", ErrTTLUnsupported); err != ErrTTLUnsupported {
+		t.Errorf("expected error %v, got %v", ErrTTLUnsupported, err)
+	}
+}
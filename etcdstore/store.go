@@ -0,0 +1,116 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package etcdstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/internal/timeutil"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Option is a function that receives a pointer of etcd store.
+type Option func(s *store)
+
+// Prefix sets the prefix of key.
+func Prefix(prefix string) Option {
+	return func(s *store) {
+		s.prefix = prefix
+	}
+}
+
+// Expiration sets the expiration, it will be rounded up to seconds
+// since etcd leases are measured in seconds.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+type store struct {
+	client     *clientv3.Client
+	prefix     string
+	expiration time.Duration
+}
+
+// New returns an etcd store.
+func New(client *clientv3.Client, opts ...Option) captchas.Store {
+	s := &store{
+		client:     client,
+		prefix:     "captchas",
+		expiration: 10 * time.Minute,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	return s
+}
+
+func (s *store) getKey(id string) string {
+	return s.prefix + "/" + id
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	key := s.getKey(id)
+	if clear {
+		resp, err := s.client.Delete(ctx, key, clientv3.WithPrevKV())
+		if err != nil {
+			return "", err
+		}
+		if len(resp.PrevKvs) == 0 {
+			return "", captchas.ErrIncorrectCaptcha
+		}
+		return string(resp.PrevKvs[0].Value), nil
+	}
+
+	resp, err := s.client.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Kvs) == 0 {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	return string(resp.Kvs[0].Value), nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	return s.set(ctx, id, answer, s.expiration)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.SetWithTTLContext(context.Background(), id, answer, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	return s.set(ctx, id, answer, ttl)
+}
+
+func (s *store) set(ctx context.Context, id, answer string, ttl time.Duration) error {
+	lease, err := s.client.Grant(ctx, timeutil.Seconds(ttl))
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Put(ctx, s.getKey(id), answer, clientv3.WithLease(lease.ID))
+	return err
+}
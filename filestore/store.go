@@ -0,0 +1,198 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package filestore
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+// Option is a function that receives a pointer of file store.
+type Option func(s *store)
+
+// Expiration sets expiration.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+// GCInterval sets garbage collection interval, a non-positive interval
+// disables the garbage collection.
+func GCInterval(interval time.Duration) Option {
+	return func(s *store) {
+		s.gcInterval = interval
+	}
+}
+
+// temporary files are prefixed with a dot, which never occurs in
+// encoded captcha IDs.
+const tmpPrefix = "."
+
+type store struct {
+	dir        string
+	expiration time.Duration
+	gcInterval time.Duration
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// New returns a file store that saves each captcha as a file under the given
+// directory, the directory will be created if not exists.
+func New(dir string, opts ...Option) (captchas.Store, error) {
+	s := &store{
+		dir:        dir,
+		expiration: 10 * time.Minute,
+		gcInterval: time.Minute,
+		done:       make(chan struct{}),
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	if s.gcInterval > 0 {
+		go s.gc()
+	}
+
+	return s, nil
+}
+
+// getPath returns the file path of the given ID, the ID is encoded, so
+// that it can't escape from the directory.
+func (s *store) getPath(id string) string {
+	return filepath.Join(s.dir, base64.RawURLEncoding.EncodeToString([]byte(id)))
+}
+
+func (s *store) tmpPath() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, tmpPrefix+hex.EncodeToString(b)), nil
+}
+
+func (s *store) isExpired(modTime time.Time, now time.Time) bool {
+	return now.After(modTime.Add(s.expiration))
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	path := s.getPath(id)
+	if clear {
+		// renaming is atomic, only one of concurrent consumers is able to
+		// take the file away.
+		tmp, err := s.tmpPath()
+		if err != nil {
+			return "", err
+		}
+		if err = os.Rename(path, tmp); err != nil {
+			if os.IsNotExist(err) {
+				return "", captchas.ErrIncorrectCaptcha
+			}
+			return "", err
+		}
+		defer os.Remove(tmp)
+		path = tmp
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	if s.isExpired(info.ModTime(), time.Now()) {
+		return "", captchas.ErrExpiredCaptcha
+	}
+
+	answer, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	return string(answer), nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	// writes to a temporary file first, so that readers never see
+	// partial content.
+	tmp, err := s.tmpPath()
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(tmp, []byte(answer), 0600); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, s.getPath(id)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+func (s *store) gc() {
+	ticker := time.NewTicker(s.gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.deleteExpired()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Close stops the garbage collection.
+func (s *store) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
+
+// deleteExpired deletes the files which modification time is expired,
+// including the temporary files left by interrupted operations.
+func (s *store) deleteExpired() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if s.isExpired(info.ModTime(), now) {
+			os.Remove(filepath.Join(s.dir, entry.Name()))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package filestore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestNew(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "captchas")
+	expiration := 5 * time.Minute
+	gcInterval := time.Minute
+	s, err := New(dir, Expiration(expiration), GCInterval(gcInterval))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(dir); err != nil {
+		t.Errorf("expected directory to be created: %s", err)
+	}
+	fs, _ := s.(*store)
+	if fs.expiration != expiration {
+		t.Errorf("expected expiration %v, got %v", expiration, fs.expiration)
+	}
+	if fs.gcInterval != gcInterval {
+		t.Errorf("expected gcInterval %v, got %v", gcInterval, fs.gcInterval)
+	}
+}
+
+func TestGetPath(t *testing.T) {
+	dir := t.TempDir()
+	s := &store{dir: dir}
+	for _, id := range []string{"foo", "../foo", "foo/bar"} {
+		path := s.getPath(id)
+		if filepath.Dir(path) != dir {
+			t.Errorf("expected path %q to be under %q", path, dir)
+		}
+		if strings.HasPrefix(filepath.Base(path), tmpPrefix) {
+			t.Errorf("expected path %q not to be a temporary file", path)
+		}
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+func TestStoreDeleteExpired(t *testing.T) {
+	dir := t.TempDir()
+	s := &store{dir: dir, expiration: time.Minute}
+	for _, id := range []string{"expired", "active"} {
+		if err := s.Set(id, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-2 * time.Minute)
+	if err := os.Chtimes(s.getPath("expired"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("expired", false); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+
+	if err := s.deleteExpired(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("expired", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected item %q to be deleted", "expired")
+	}
+	if _, err := s.Get("active", false); err != nil {
+		t.Errorf("expected item %q to be kept, got %v", "active", err)
+	}
+}
+
+func TestStoreCloseWithoutGC(t *testing.T) {
+	s, err := New(t.TempDir(), GCInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = captchas.Close(s); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+}
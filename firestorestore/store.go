@@ -0,0 +1,120 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package firestorestore
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"github.com/clevergo/captchas"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Option is a function that receives a pointer of firestore store.
+type Option func(s *store)
+
+// Collection sets the collection name.
+func Collection(collection string) Option {
+	return func(s *store) {
+		s.collection = collection
+	}
+}
+
+// Expiration sets the expiration.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+type document struct {
+	Answer string `firestore:"answer"`
+	// ExpireAt can be used as the field of TTL policy.
+	ExpireAt time.Time `firestore:"expireAt"`
+}
+
+type store struct {
+	client     *firestore.Client
+	collection string
+	expiration time.Duration
+}
+
+// New returns a firestore store.
+func New(client *firestore.Client, opts ...Option) captchas.Store {
+	s := &store{
+		client:     client,
+		collection: "captchas",
+		expiration: 10 * time.Minute,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	return s
+}
+
+func (s *store) doc(id string) *firestore.DocumentRef {
+	return s.client.Collection(s.collection).Doc(id)
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	ref := s.doc(id)
+	var doc document
+	var err error
+	if clear {
+		err = s.client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			snapshot, err := tx.Get(ref)
+			if err != nil {
+				return err
+			}
+			if err = snapshot.DataTo(&doc); err != nil {
+				return err
+			}
+			return tx.Delete(ref)
+		})
+	} else {
+		var snapshot *firestore.DocumentSnapshot
+		snapshot, err = ref.Get(ctx)
+		if err == nil {
+			err = snapshot.DataTo(&doc)
+		}
+	}
+	if status.Code(err) == codes.NotFound {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// TTL policy deletes expired documents lazily.
+	if time.Now().After(doc.ExpireAt) {
+		return "", captchas.ErrExpiredCaptcha
+	}
+
+	return doc.Answer, nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	_, err := s.doc(id).Set(ctx, document{
+		Answer:   answer,
+		ExpireAt: time.Now().Add(s.expiration),
+	})
+	return err
+}
@@ -0,0 +1,72 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package firestorestore
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+var testClient *firestore.Client
+
+func TestMain(m *testing.M) {
+	// tests run against the emulator only.
+	if os.Getenv("FIRESTORE_EMULATOR_HOST") != "" {
+		client, err := firestore.NewClient(context.Background(), "captchas")
+		if err != nil {
+			panic(err)
+		}
+		testClient = client
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestNew(t *testing.T) {
+	expiration := 5 * time.Minute
+	collection := "foo"
+	s, _ := New(testClient, Expiration(expiration), Collection(collection)).(*store)
+	if s.expiration != expiration {
+		t.Errorf("expected expiration %v, got %v", expiration, s.expiration)
+	}
+	if s.collection != collection {
+		t.Errorf("expected collection %s, got %s", collection, s.collection)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	if testClient == nil {
+		t.Skip("firestore emulator is unavailable")
+	}
+
+	s := New(testClient)
+	_, err := s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package freecachestore
+
+import (
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/internal/timeutil"
+	"github.com/coocood/freecache"
+)
+
+// Option is a function that receives a pointer of freecache store.
+type Option func(s *store)
+
+// Expiration sets the expiration, it will be rounded up to seconds
+// since freecache expiration is measured in seconds.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+type store struct {
+	cache      *freecache.Cache
+	expiration time.Duration
+}
+
+// New returns a freecache store, the memory is limited by the size of
+// the given cache, the oldest captchas will be evicted once it is full.
+func New(cache *freecache.Cache, opts ...Option) captchas.Store {
+	s := &store{
+		cache:      cache,
+		expiration: 10 * time.Minute,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	return s
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	key := []byte(id)
+	answer, err := s.cache.Get(key)
+	if err == freecache.ErrNotFound {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// only one of concurrent consumers is able to delete the captcha.
+	if clear && !s.cache.Del(key) {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+
+	return string(answer), nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetWithTTL(id, answer, s.expiration)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.cache.Set([]byte(id), []byte(answer), int(timeutil.Seconds(ttl)))
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package freecachestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/coocood/freecache"
+)
+
+func TestNew(t *testing.T) {
+	expiration := 5 * time.Minute
+	s, _ := New(freecache.NewCache(512*1024), Expiration(expiration)).(*store)
+	if s.expiration != expiration {
+		t.Errorf("expected expiration %v, got %v", expiration, s.expiration)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	s := New(freecache.NewCache(512 * 1024))
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package grpcstore
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"github.com/clevergo/captchas"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ServerOption is a function that receives a pointer of server.
+type ServerOption func(s *server)
+
+// RequireToken requires the calls to carry the given bearer token in the
+// authorization metadata, see Token.
+func RequireToken(token string) ServerOption {
+	return func(s *server) {
+		s.token = token
+	}
+}
+
+// Insecure allows the server to serve calls without a token, it is meant
+// for tests and listeners that are protected otherwise, such as by mutual
+// TLS, since anyone who reaches the server is able to read answers.
+func Insecure() ServerOption {
+	return func(s *server) {
+		s.insecure = true
+	}
+}
+
+type server struct {
+	UnimplementedStoreServer
+	store    captchas.Store
+	token    string
+	insecure bool
+}
+
+// NewServer returns a store service that serves with the given store,
+// it should be registered via RegisterStoreServer. The service exposes
+// answers, it panics if neither a non-empty token is required by
+// RequireToken nor Insecure is set.
+func NewServer(store captchas.Store, opts ...ServerOption) StoreServer {
+	s := &server{store: store}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	if s.token == "" && !s.insecure {
+		panic("grpcstore: a token is required, see RequireToken and Insecure")
+	}
+
+	return s
+}
+
+// authorize checks the bearer token of the incoming call.
+func (s *server) authorize(ctx context.Context) error {
+	if s.insecure {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get(headerAuthorization) {
+		token, ok := strings.CutPrefix(v, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "invalid token")
+}
+
+// Get implements StoreServer.Get.
+func (s *server) Get(ctx context.Context, req *GetRequest) (*GetResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	answer, err := captchas.GetContext(ctx, s.store, req.Id, false)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &GetResponse{Answer: answer}, nil
+}
+
+// Consume implements StoreServer.Consume.
+func (s *server) Consume(ctx context.Context, req *ConsumeRequest) (*ConsumeResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	answer, err := captchas.GetContext(ctx, s.store, req.Id, true)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &ConsumeResponse{Answer: answer}, nil
+}
+
+// Set implements StoreServer.Set.
+func (s *server) Set(ctx context.Context, req *SetRequest) (*SetResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if err := captchas.SetContext(ctx, s.store, req.Id, req.Answer); err != nil {
+		return nil, toStatus(err)
+	}
+	return &SetResponse{}, nil
+}
+
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, captchas.ErrIncorrectCaptcha):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, captchas.ErrExpiredCaptcha):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
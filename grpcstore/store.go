@@ -0,0 +1,118 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative store.proto
+
+package grpcstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Option is a function that receives a pointer of grpc store.
+type Option func(s *store)
+
+// Timeout sets the timeout of each call.
+func Timeout(timeout time.Duration) Option {
+	return func(s *store) {
+		s.timeout = timeout
+	}
+}
+
+// Token sets the bearer token that sent along with every call, see
+// RequireToken.
+func Token(token string) Option {
+	return func(s *store) {
+		s.token = token
+	}
+}
+
+// headerAuthorization is the metadata key that carries the bearer token.
+const headerAuthorization = "authorization"
+
+type store struct {
+	client  StoreClient
+	token   string
+	timeout time.Duration
+}
+
+// New returns a grpc store that delegates to the remote store service.
+func New(conn grpc.ClientConnInterface, opts ...Option) captchas.Store {
+	s := &store{
+		client:  NewStoreClient(conn),
+		timeout: 5 * time.Second,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	return s
+}
+
+func (s *store) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, headerAuthorization, "Bearer "+s.token)
+	}
+	if s.timeout > 0 {
+		return context.WithTimeout(ctx, s.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	if clear {
+		resp, err := s.client.Consume(ctx, &ConsumeRequest{Id: id})
+		if err != nil {
+			return "", fromStatus(err)
+		}
+		return resp.Answer, nil
+	}
+
+	resp, err := s.client.Get(ctx, &GetRequest{Id: id})
+	if err != nil {
+		return "", fromStatus(err)
+	}
+	return resp.Answer, nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+
+	_, err := s.client.Set(ctx, &SetRequest{Id: id, Answer: answer})
+	return fromStatus(err)
+}
+
+func fromStatus(err error) error {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return captchas.ErrIncorrectCaptcha
+	case codes.FailedPrecondition:
+		return captchas.ErrExpiredCaptcha
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package grpcstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/memstore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var testConn *grpc.ClientConn
+
+func TestMain(m *testing.M) {
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+	RegisterStoreServer(srv, NewServer(memstore.New(), RequireToken("secret")))
+	go srv.Serve(lis)
+
+	var err error
+	testConn, err = grpc.NewClient(
+		"passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	testConn.Close()
+	srv.Stop()
+	os.Exit(code)
+}
+
+func TestNew(t *testing.T) {
+	timeout := time.Second
+	s, _ := New(testConn, Timeout(timeout), Token("secret")).(*store)
+	if s.timeout != timeout {
+		t.Errorf("expected timeout %v, got %v", timeout, s.timeout)
+	}
+	if s.token != "secret" {
+		t.Errorf("expected token %q, got %q", "secret", s.token)
+	}
+}
+
+func TestNewServerWithoutToken(t *testing.T) {
+	for _, opts := range [][]ServerOption{nil, {RequireToken("")}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic without token")
+				}
+			}()
+			NewServer(memstore.New(), opts...)
+		}()
+	}
+}
+
+func TestServerToken(t *testing.T) {
+	for _, token := range []string{"", "wrong"} {
+		if err := New(testConn, Token(token)).Set("foo", "bar"); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("token %q: expected code %v, got %v", token, codes.Unauthenticated, status.Code(err))
+		}
+	}
+
+	srv := NewServer(memstore.New(), Insecure())
+	if _, err := srv.Get(context.Background(), &GetRequest{Id: "foo"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected code %v, got %v", codes.NotFound, status.Code(err))
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	s := New(testConn, Token("secret"))
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	unknown := errors.New("unknown")
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{captchas.ErrIncorrectCaptcha, codes.NotFound},
+		{captchas.ErrExpiredCaptcha, codes.FailedPrecondition},
+		{unknown, codes.Internal},
+		{fmt.Errorf("wrapped: %w", captchas.ErrExpiredCaptcha), codes.FailedPrecondition},
+	}
+	for _, test := range tests {
+		err := toStatus(test.err)
+		if status.Code(err) != test.code {
+			t.Errorf("expected code %v, got %v", test.code, status.Code(err))
+		}
+		if test.code != codes.Internal && !errors.Is(test.err, fromStatus(err)) {
+			t.Errorf("expected error %v, got %v", test.err, fromStatus(err))
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package hashedstore wraps a store and saves HMAC-SHA256 hashes of answers
+// only, so that a leaked dump of the underlying store can't be used to solve
+// outstanding captchas.
+//
+// The store implements captchas.Verifier, answers are compared inside the
+// store by Manager.Verify, and Get returns the hash instead of the answer.
+package hashedstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+// Option is a function that receives a pointer of store.
+type Option func(*store)
+
+// CaseSensitive is an option that enable or disable case sensitive.
+func CaseSensitive(v bool) Option {
+	return func(s *store) {
+		s.caseSensitive = v
+	}
+}
+
+type store struct {
+	store         captchas.Store
+	key           []byte
+	caseSensitive bool
+}
+
+// New returns a hashed store that delegates to the given store, the key is
+// the secret of HMAC.
+func New(s captchas.Store, key []byte, opts ...Option) captchas.Store {
+	hs := &store{
+		store:         s,
+		key:           key,
+		caseSensitive: true,
+	}
+
+	for _, f := range opts {
+		f(hs)
+	}
+
+	return hs
+}
+
+// Get implements Store.Get, it returns the hash of answer.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext, it returns the hash of
+// answer.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	return captchas.GetContext(ctx, s.store, id, clear)
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	return captchas.SetContext(ctx, s.store, id, s.hash(id, answer))
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.SetWithTTLContext(context.Background(), id, answer, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	return captchas.SetWithTTLContext(ctx, s.store, id, s.hash(id, answer), ttl)
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *store) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	return s.SetIfNotExistsContext(context.Background(), id, answer, ttl)
+}
+
+// SetIfNotExistsContext implements ContextNXStore.SetIfNotExistsContext.
+func (s *store) SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error) {
+	return captchas.SetIfNotExistsContext(ctx, s.store, id, s.hash(id, answer), ttl)
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (s *store) Attempt(id string) (int, error) {
+	return s.AttemptContext(context.Background(), id)
+}
+
+// AttemptContext implements ContextAttemptStore.AttemptContext.
+func (s *store) AttemptContext(ctx context.Context, id string) (int, error) {
+	return captchas.AttemptContext(ctx, s.store, id)
+}
+
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext implements ContextDeleteStore.DeleteContext.
+func (s *store) DeleteContext(ctx context.Context, id string) error {
+	return captchas.DeleteContext(ctx, s.store, id)
+}
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	return s.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext implements ContextTouchStore.TouchContext.
+func (s *store) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
+	return captchas.TouchContext(ctx, s.store, id, ttl)
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *store) Exists(id string) (bool, error) {
+	return s.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext implements ContextExistsStore.ExistsContext.
+func (s *store) ExistsContext(ctx context.Context, id string) (bool, error) {
+	return captchas.ExistsContext(ctx, s.store, id)
+}
+
+// Close closes the underlying store if it implements io.Closer.
+func (s *store) Close() error {
+	return captchas.Close(s.store)
+}
+
+// Verify implements Verifier.Verify.
+func (s *store) Verify(id, actual string, clear bool) error {
+	return s.VerifyContext(context.Background(), id, actual, clear)
+}
+
+// VerifyContext implements ContextVerifier.VerifyContext.
+func (s *store) VerifyContext(ctx context.Context, id, actual string, clear bool) error {
+	hash, err := captchas.GetContext(ctx, s.store, id, clear)
+	if err != nil {
+		return err
+	}
+
+	if actual == "" || !hmac.Equal([]byte(hash), []byte(s.hash(id, actual))) {
+		return captchas.ErrIncorrectCaptcha
+	}
+
+	return nil
+}
+
+// hash returns the HMAC of the ID and answer, the ID is included so that
+// hashes can't be swapped between captchas.
+func (s *store) hash(id, answer string) string {
+	if !s.caseSensitive {
+		answer = strings.ToLower(answer)
+	}
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(id))
+	mac.Write([]byte{0})
+	mac.Write([]byte(answer))
+	return base64.RawStdEncoding.EncodeToString(mac.Sum(nil))
+}
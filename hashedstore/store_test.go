@@ -0,0 +1,99 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package hashedstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/memstore"
+)
+
+var key = []byte("secret")
+
+func TestNew(t *testing.T) {
+	s := New(memstore.New(), key, CaseSensitive(false))
+	hs, _ := s.(*store)
+	if string(hs.key) != string(key) {
+		t.Errorf("expected key %q, got %q", key, hs.key)
+	}
+	if hs.caseSensitive {
+		t.Error("expected to disable case sensitive")
+	}
+}
+
+func TestStoreVerify(t *testing.T) {
+	mem := memstore.New()
+	s := New(mem, key).(captchas.Verifier)
+	if err := s.Verify("foo", "bar", true); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	if err := s.(captchas.Store).Set("foo", "bar"); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if value, _ := mem.Get("foo", false); value == "bar" {
+		t.Error("expected a hashed answer, got plaintext")
+	}
+	for _, actual := range []string{"", "Bar", "baz"} {
+		if err := s.Verify("foo", actual, false); err != captchas.ErrIncorrectCaptcha {
+			t.Errorf("expected error %v for %q, got %v", captchas.ErrIncorrectCaptcha, actual, err)
+		}
+	}
+	if err := s.Verify("foo", "bar", true); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if err := s.Verify("foo", "bar", true); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	// hashes are bound to IDs.
+	s.(captchas.Store).Set("foo", "bar")
+	value, _ := mem.Get("foo", false)
+	mem.Set("fizz", value)
+	if err := s.Verify("fizz", "bar", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
+
+func TestStoreCaseInsensitive(t *testing.T) {
+	s := New(memstore.New(), key, CaseSensitive(false))
+	s.Set("foo", "BaR")
+	m := captchas.New(s, nil)
+	if err := m.Verify("foo", "bAr", true); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+}
+
+func TestStoreForwarding(t *testing.T) {
+	mem := memstore.New()
+	s := New(mem, key)
+	if ok, err := s.(captchas.NXStore).SetIfNotExists("foo", "bar", time.Minute); err != nil || !ok {
+		t.Fatalf("expected to be saved, got %t, %v", ok, err)
+	}
+	if ok, _ := s.(captchas.NXStore).SetIfNotExists("foo", "baz", time.Minute); ok {
+		t.Error("expected the ID to be taken")
+	}
+	if n, err := s.(captchas.AttemptStore).Attempt("foo"); err != nil || n != 1 {
+		t.Errorf("expected attempts %d, got %d, %v", 1, n, err)
+	}
+	if err := captchas.Touch(s, "foo", time.Hour); err != nil {
+		t.Errorf("failed to touch: %s", err)
+	}
+	if ok, err := captchas.Exists(s, "foo"); err != nil || !ok {
+		t.Errorf("expected the captcha to exist, got %t, %v", ok, err)
+	}
+	if err := s.(captchas.ContextVerifier).VerifyContext(context.Background(), "foo", "bar", false); err != nil {
+		t.Errorf("expected non error, got %v", err)
+	}
+	if err := s.(captchas.DeleteStore).Delete("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := captchas.Exists(mem, "foo"); ok {
+		t.Error("expected the captcha to be deleted")
+	}
+}
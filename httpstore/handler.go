@@ -0,0 +1,140 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package httpstore
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/clevergo/captchas"
+)
+
+type setRequest struct {
+	Answer string `json:"answer"`
+}
+
+type answerResponse struct {
+	Answer string `json:"answer"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// HandlerOption is a function that receives a pointer of handler.
+type HandlerOption func(h *handler)
+
+// RequireToken requires the requests to carry the given bearer token in the
+// Authorization header, see Token.
+func RequireToken(token string) HandlerOption {
+	return func(h *handler) {
+		h.token = token
+	}
+}
+
+// Insecure allows the handler to serve requests without a token, it is
+// meant for tests and listeners that are protected otherwise, such as by
+// mutual TLS, since anyone who reaches the handler is able to read answers.
+func Insecure() HandlerOption {
+	return func(h *handler) {
+		h.insecure = true
+	}
+}
+
+type handler struct {
+	store    captchas.Store
+	token    string
+	insecure bool
+}
+
+// NewHandler returns a handler that exposes the given store remotely, it
+// should be mounted with http.StripPrefix, and serves the following API:
+//
+//	GET    /{id}   returns the answer: {"answer": "..."}
+//	DELETE /{id}   returns the answer and deletes the captcha.
+//	PUT    /{id}   saves the answer: {"answer": "..."}
+//
+// 401 Unauthorized means the bearer token is missing or wrong, 404 Not Found
+// means the captcha does not exist, 410 Gone means the captcha was expired,
+// other errors respond {"error": "..."}.
+//
+// The handler exposes answers, it should be served on a private listener,
+// and panics if neither a non-empty token is required by RequireToken nor
+// Insecure is set.
+func NewHandler(store captchas.Store, opts ...HandlerOption) http.Handler {
+	h := &handler{store: store}
+
+	for _, f := range opts {
+		f(h)
+	}
+
+	if h.token == "" && !h.insecure {
+		panic("httpstore: a token is required, see RequireToken and Insecure")
+	}
+
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.token != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: http.StatusText(http.StatusUnauthorized)})
+			return
+		}
+	}
+
+	id, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/"))
+	if err != nil || id == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid captcha ID"})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodDelete:
+		answer, err := captchas.GetContext(r.Context(), h.store, id, r.Method == http.MethodDelete)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, answerResponse{Answer: answer})
+	case http.MethodPut:
+		var req setRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+		if err := captchas.SetContext(r.Context(), h.store, id, req.Answer); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, DELETE, PUT")
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: http.StatusText(http.StatusMethodNotAllowed)})
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, captchas.ErrIncorrectCaptcha):
+		code = http.StatusNotFound
+	case errors.Is(err, captchas.ErrExpiredCaptcha):
+		code = http.StatusGone
+	}
+	writeJSON(w, code, errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
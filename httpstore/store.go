@@ -0,0 +1,129 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package httpstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/clevergo/captchas"
+)
+
+// Option is a function that receives a pointer of http store.
+type Option func(s *store)
+
+// HTTPClient sets the HTTP client.
+func HTTPClient(client *http.Client) Option {
+	return func(s *store) {
+		s.client = client
+	}
+}
+
+// Token sets the bearer token that sent along with every request, see
+// RequireToken.
+func Token(token string) Option {
+	return func(s *store) {
+		s.token = token
+	}
+}
+
+type store struct {
+	endpoint string
+	token    string
+	client   *http.Client
+}
+
+// New returns a http store that delegates to the remote store served by
+// Handler under the given endpoint, e.g. "https://api.example.com/captchas".
+func New(endpoint string, opts ...Option) captchas.Store {
+	s := &store{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   http.DefaultClient,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	return s
+}
+
+func (s *store) url(id string) string {
+	return s.endpoint + "/" + url.PathEscape(id)
+}
+
+func (s *store) do(ctx context.Context, method, id string, body interface{}, v interface{}) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.url(id), &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(resp.Body).Decode(v)
+	case http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return captchas.ErrIncorrectCaptcha
+	case http.StatusGone:
+		return captchas.ErrExpiredCaptcha
+	}
+
+	var e errorResponse
+	if err = json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error == "" {
+		return fmt.Errorf("%s %s: %s", method, req.URL, resp.Status)
+	}
+	return fmt.Errorf("%s %s: %s", method, req.URL, e.Error)
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	method := http.MethodGet
+	if clear {
+		method = http.MethodDelete
+	}
+
+	var resp answerResponse
+	if err := s.do(ctx, method, id, nil, &resp); err != nil {
+		return "", err
+	}
+	return resp.Answer, nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	return s.do(ctx, http.MethodPut, id, setRequest{Answer: answer}, nil)
+}
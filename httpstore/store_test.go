@@ -0,0 +1,151 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package httpstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/memstore"
+)
+
+var testServer *httptest.Server
+
+func TestMain(m *testing.M) {
+	mux := http.NewServeMux()
+	mux.Handle("/captchas/", http.StripPrefix("/captchas", NewHandler(memstore.New(), RequireToken("secret"))))
+	testServer = httptest.NewServer(mux)
+
+	code := m.Run()
+	testServer.Close()
+	os.Exit(code)
+}
+
+func TestNew(t *testing.T) {
+	client := &http.Client{Timeout: time.Second}
+	s, _ := New("http://localhost/captchas/", HTTPClient(client), Token("secret")).(*store)
+	if s.client != client {
+		t.Errorf("expected client %v, got %v", client, s.client)
+	}
+	if s.token != "secret" {
+		t.Errorf("expected token %q, got %q", "secret", s.token)
+	}
+	if s.url("foo/bar") != "http://localhost/captchas/foo%2Fbar" {
+		t.Errorf("expected url %s, got %s", "http://localhost/captchas/foo%2Fbar", s.url("foo/bar"))
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	s := New(testServer.URL+"/captchas", Token("secret"))
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+func TestHandler(t *testing.T) {
+	store := memstore.New(memstore.Expiration(-time.Second))
+	store.Set("expired", "bar")
+	h := NewHandler(store, Insecure())
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodGet, "/", http.StatusBadRequest},
+		{http.MethodGet, "/foo", http.StatusNotFound},
+		{http.MethodGet, "/expired", http.StatusGone},
+		{http.MethodPut, "/foo", http.StatusBadRequest},
+		{http.MethodPost, "/foo", http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.code {
+			t.Errorf("%s %s: expected status code %d, got %d", test.method, test.path, test.code, w.Code)
+		}
+	}
+}
+
+func TestHandlerToken(t *testing.T) {
+	h := NewHandler(memstore.New(), RequireToken("secret"))
+	for header, code := range map[string]int{
+		"":              http.StatusUnauthorized,
+		"Bearer wrong":  http.StatusUnauthorized,
+		"secret":        http.StatusUnauthorized,
+		"Bearer secret": http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/foo", nil)
+		r.Header.Set("Authorization", header)
+		h.ServeHTTP(w, r)
+		if w.Code != code {
+			t.Errorf("Authorization %q: expected status code %d, got %d", header, code, w.Code)
+		}
+	}
+
+	if _, err := New(testServer.URL+"/captchas", Token("wrong")).Get("foo", false); err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+func TestNewHandlerWithoutToken(t *testing.T) {
+	for _, opts := range [][]HandlerOption{nil, {RequireToken("")}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic without token")
+				}
+			}()
+			NewHandler(memstore.New(), opts...)
+		}()
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeError(w, fmt.Errorf("wrapped: %w", captchas.ErrExpiredCaptcha))
+	if w.Code != http.StatusGone {
+		t.Errorf("expected status code %d, got %d", http.StatusGone, w.Code)
+	}
+}
+
+func TestStoreContext(t *testing.T) {
+	s := New(testServer.URL+"/captchas", Token("secret")).(captchas.ContextStore)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.SetContext(ctx, "foo", "bar"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if _, err := s.GetContext(ctx, "foo", false); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,18 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package timeutil provides time helpers for stores whose TTLs are in seconds.
+package timeutil
+
+import "time"
+
+// Seconds rounds up the duration to seconds, so that a captcha never expires
+// earlier than expected.
+func Seconds(d time.Duration) int64 {
+	n := int64(d / time.Second)
+	if d%time.Second > 0 {
+		n++
+	}
+	return n
+}
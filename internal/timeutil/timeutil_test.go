@@ -0,0 +1,27 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package timeutil
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSeconds(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		seconds  int64
+	}{
+		{time.Minute, 60},
+		{1500 * time.Millisecond, 2},
+		{time.Millisecond, 1},
+		{0, 0},
+	}
+	for _, test := range tests {
+		if seconds := Seconds(test.duration); seconds != test.seconds {
+			t.Errorf("expected seconds %d of %s, got %d", test.seconds, test.duration, seconds)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package leveldbstore
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// Option is a function that receives a pointer of leveldb store.
+type Option func(s *store)
+
+// Prefix sets the prefix of key.
+func Prefix(prefix string) Option {
+	return func(s *store) {
+		s.prefix = prefix
+	}
+}
+
+// Expiration sets expiration.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+// GCInterval sets garbage collection interval, a non-positive interval
+// disables the garbage collection.
+func GCInterval(interval time.Duration) Option {
+	return func(s *store) {
+		s.gcInterval = interval
+	}
+}
+
+type store struct {
+	mu         sync.Mutex
+	db         *leveldb.DB
+	prefix     string
+	expiration time.Duration
+	gcInterval time.Duration
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// New returns a leveldb store.
+func New(db *leveldb.DB, opts ...Option) captchas.Store {
+	s := &store{
+		db:         db,
+		prefix:     "captchas",
+		expiration: 10 * time.Minute,
+		gcInterval: time.Minute,
+		done:       make(chan struct{}),
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	if s.gcInterval > 0 {
+		go s.gc()
+	}
+
+	return s
+}
+
+func (s *store) getKey(id string) []byte {
+	return []byte(s.prefix + ":" + id)
+}
+
+// encode encodes expiration and answer as value, the first 8 bytes
+// is the big endian expiration in nanoseconds.
+func encode(expiration int64, answer string) []byte {
+	value := make([]byte, 8+len(answer))
+	binary.BigEndian.PutUint64(value, uint64(expiration))
+	copy(value[8:], answer)
+	return value
+}
+
+func decode(value []byte) (expiration int64, answer string) {
+	if len(value) < 8 {
+		return 0, ""
+	}
+	return int64(binary.BigEndian.Uint64(value)), string(value[8:])
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	key := s.getKey(id)
+	if clear {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	value, err := s.db.Get(key, nil)
+	if err == leveldb.ErrNotFound {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if clear {
+		if err = s.db.Delete(key, nil); err != nil {
+			return "", err
+		}
+	}
+
+	expiration, answer := decode(value)
+	if time.Now().UnixNano() > expiration {
+		return "", captchas.ErrExpiredCaptcha
+	}
+
+	return answer, nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetWithTTL(id, answer, s.expiration)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.db.Put(s.getKey(id), encode(time.Now().Add(ttl).UnixNano(), answer), nil)
+}
+
+func (s *store) gc() {
+	ticker := time.NewTicker(s.gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.deleteExpired()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Close stops the garbage collection, the database is left open.
+func (s *store) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
+
+// deleteExpired deletes expired captchas in a single batch, and then
+// compacts the affected key range to drop the tombstones.
+func (s *store) deleteExpired() error {
+	now := time.Now().UnixNano()
+	batch := new(leveldb.Batch)
+	var first, last []byte
+
+	iter := s.db.NewIterator(util.BytesPrefix([]byte(s.prefix+":")), nil)
+	for iter.Next() {
+		if expiration, _ := decode(iter.Value()); now > expiration {
+			key := append([]byte{}, iter.Key()...)
+			if first == nil {
+				first = key
+			}
+			last = key
+			batch.Delete(key)
+		}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	if err := s.db.Write(batch, nil); err != nil {
+		return err
+	}
+
+	return s.db.CompactRange(util.Range{Start: first, Limit: append(last, 0)})
+}
@@ -0,0 +1,233 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package loggingstore wraps a store and logs each operation with captcha ID,
+// outcome and latency.
+package loggingstore
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+// Logger is the interface that logs messages, *log.Logger implements it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// LoggerFunc is an adapter to allow the use of ordinary functions as logger.
+type LoggerFunc func(format string, v ...interface{})
+
+// Printf implements Logger.Printf.
+func (f LoggerFunc) Printf(format string, v ...interface{}) {
+	f(format, v...)
+}
+
+// Option is a function that receives a pointer of store.
+type Option func(*store)
+
+// Redact sets whether to redact answers, defaults to true.
+func Redact(v bool) Option {
+	return func(s *store) {
+		s.redact = v
+	}
+}
+
+const redacted = "[redacted]"
+
+type store struct {
+	store  captchas.Store
+	logger Logger
+	redact bool
+}
+
+// New returns a store that logs operations of the given store. The returned
+// store implements captchas.Verifier or captchas.MatchVerifier if the given
+// store does.
+func New(s captchas.Store, logger Logger, opts ...Option) captchas.Store {
+	ls := &store{
+		store:  s,
+		logger: logger,
+		redact: true,
+	}
+
+	for _, f := range opts {
+		f(ls)
+	}
+
+	if _, ok := s.(captchas.MatchVerifier); ok {
+		return &matchVerifier{ls}
+	}
+	if _, ok := s.(captchas.Verifier); ok {
+		return &verifier{ls}
+	}
+	return ls
+}
+
+func (s *store) log(op, id, answer string, start time.Time, err error) {
+	if s.redact && answer != "" {
+		answer = redacted
+	} else {
+		answer = strconv.Quote(answer)
+	}
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+	}
+	s.logger.Printf("captchas: op=%s id=%q answer=%s latency=%s result=%q", op, id, answer, time.Since(start), result)
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	op := "get"
+	if clear {
+		op = "consume"
+	}
+	start := time.Now()
+	answer, err := captchas.GetContext(ctx, s.store, id, clear)
+	s.log(op, id, answer, start, err)
+	return answer, err
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	start := time.Now()
+	err := captchas.SetContext(ctx, s.store, id, answer)
+	s.log("set", id, answer, start, err)
+	return err
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.SetWithTTLContext(context.Background(), id, answer, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	start := time.Now()
+	err := captchas.SetWithTTLContext(ctx, s.store, id, answer, ttl)
+	s.log("set", id, answer, start, err)
+	return err
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *store) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	return s.SetIfNotExistsContext(context.Background(), id, answer, ttl)
+}
+
+// SetIfNotExistsContext implements ContextNXStore.SetIfNotExistsContext.
+func (s *store) SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error) {
+	start := time.Now()
+	ok, err := captchas.SetIfNotExistsContext(ctx, s.store, id, answer, ttl)
+	result := err
+	if err == nil && !ok {
+		result = captchas.ErrCaptchaCollision
+	}
+	s.log("set", id, answer, start, result)
+	return ok, err
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (s *store) Attempt(id string) (int, error) {
+	return s.AttemptContext(context.Background(), id)
+}
+
+// AttemptContext implements ContextAttemptStore.AttemptContext.
+func (s *store) AttemptContext(ctx context.Context, id string) (int, error) {
+	start := time.Now()
+	n, err := captchas.AttemptContext(ctx, s.store, id)
+	s.log("attempt", id, "", start, err)
+	return n, err
+}
+
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext implements ContextDeleteStore.DeleteContext.
+func (s *store) DeleteContext(ctx context.Context, id string) error {
+	start := time.Now()
+	err := captchas.DeleteContext(ctx, s.store, id)
+	s.log("delete", id, "", start, err)
+	return err
+}
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	return s.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext implements ContextTouchStore.TouchContext.
+func (s *store) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
+	start := time.Now()
+	err := captchas.TouchContext(ctx, s.store, id, ttl)
+	s.log("touch", id, "", start, err)
+	return err
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *store) Exists(id string) (bool, error) {
+	return s.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext implements ContextExistsStore.ExistsContext.
+func (s *store) ExistsContext(ctx context.Context, id string) (bool, error) {
+	start := time.Now()
+	ok, err := captchas.ExistsContext(ctx, s.store, id)
+	s.log("exists", id, "", start, err)
+	return ok, err
+}
+
+// Close closes the underlying store if it implements io.Closer.
+func (s *store) Close() error {
+	return captchas.Close(s.store)
+}
+
+type verifier struct {
+	*store
+}
+
+// Verify implements Verifier.Verify.
+func (v *verifier) Verify(id, actual string, clear bool) error {
+	return v.VerifyContext(context.Background(), id, actual, clear)
+}
+
+// VerifyContext implements ContextVerifier.VerifyContext.
+func (v *verifier) VerifyContext(ctx context.Context, id, actual string, clear bool) error {
+	start := time.Now()
+	err := captchas.VerifyContext(ctx, v.store.store.(captchas.Verifier), id, actual, clear)
+	v.log("verify", id, actual, start, err)
+	return err
+}
+
+type matchVerifier struct {
+	*store
+}
+
+// VerifyMatch implements MatchVerifier.VerifyMatch.
+func (v *matchVerifier) VerifyMatch(id, actual string, clear bool, match func(actual, answer string) bool) error {
+	return v.VerifyMatchContext(context.Background(), id, actual, clear, match)
+}
+
+// VerifyMatchContext implements ContextMatchVerifier.VerifyMatchContext.
+func (v *matchVerifier) VerifyMatchContext(ctx context.Context, id, actual string, clear bool, match func(actual, answer string) bool) error {
+	start := time.Now()
+	err := captchas.VerifyMatchContext(ctx, v.store.store.(captchas.MatchVerifier), id, actual, clear, match)
+	v.log("verify", id, actual, start, err)
+	return err
+}
@@ -0,0 +1,174 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package loggingstore
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/hashedstore"
+	"github.com/clevergo/captchas/memstore"
+)
+
+type testLogger []string
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	*l = append(*l, fmt.Sprintf(format, v...))
+}
+
+func TestNew(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := log.New(buf, "", 0)
+	s := New(memstore.New(), logger, Redact(false))
+	ls, _ := s.(*store)
+	if ls.logger != logger {
+		t.Errorf("expected logger %v, got %v", logger, ls.logger)
+	}
+	if ls.redact {
+		t.Error("expected to disable redaction")
+	}
+
+	s.Set("foo", "bar")
+	if !strings.Contains(buf.String(), `op=set id="foo" answer="bar"`) {
+		t.Errorf("unexpected log: %s", buf.String())
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	logger := &testLogger{}
+	s := New(memstore.New(), logger)
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	expected := []string{
+		`op=consume id="foo" answer="" `,
+		`op=set id="foo" answer=[redacted] `,
+		`op=get id="foo" answer=[redacted] `,
+		`op=consume id="foo" answer=[redacted] `,
+	}
+	results := []string{`result="incorrect captcha"`, `result="ok"`, `result="ok"`, `result="ok"`}
+	if len(*logger) != len(expected) {
+		t.Fatalf("expected %d logs, got %d", len(expected), len(*logger))
+	}
+	for i, msg := range *logger {
+		if !strings.Contains(msg, expected[i]) || !strings.HasSuffix(msg, results[i]) {
+			t.Errorf("unexpected log: %s", msg)
+		}
+		if strings.Contains(msg, "bar") {
+			t.Errorf("expected answer to be redacted: %s", msg)
+		}
+	}
+}
+
+func TestStoreVerify(t *testing.T) {
+	logger := &testLogger{}
+	if _, ok := New(memstore.New(), logger).(captchas.Verifier); ok {
+		t.Error("expected a non-verifier store")
+	}
+
+	s := New(hashedstore.New(memstore.New(), []byte("secret")), logger)
+	s.Set("foo", "bar")
+	if err := s.(captchas.Verifier).Verify("foo", "bar", true); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if msg := (*logger)[len(*logger)-1]; !strings.Contains(msg, `op=verify id="foo" answer=[redacted] `) {
+		t.Errorf("unexpected log: %s", msg)
+	}
+}
+
+type testMatchVerifier struct {
+	captchas.Store
+}
+
+func (s testMatchVerifier) VerifyMatch(id, actual string, clear bool, match func(actual, answer string) bool) error {
+	answer, err := s.Get(id, clear)
+	if err != nil {
+		return err
+	}
+	if !match(actual, answer) {
+		return captchas.ErrIncorrectCaptcha
+	}
+	return nil
+}
+
+func TestStoreVerifyMatch(t *testing.T) {
+	logger := &testLogger{}
+	s, ok := New(testMatchVerifier{memstore.New()}, logger).(captchas.MatchVerifier)
+	if !ok {
+		t.Fatal("expected a match verifier store")
+	}
+	s.(captchas.Store).Set("foo", "bar")
+	if err := s.VerifyMatch("foo", "BAR", true, strings.EqualFold); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if msg := (*logger)[len(*logger)-1]; !strings.Contains(msg, `op=verify id="foo" answer=[redacted] `) {
+		t.Errorf("unexpected log: %s", msg)
+	}
+}
+
+func TestStoreForwarding(t *testing.T) {
+	logger := &testLogger{}
+	mem := memstore.New()
+	s := New(mem, logger)
+	nx := s.(captchas.NXStore)
+	if ok, err := nx.SetIfNotExists("foo", "bar", time.Minute); err != nil || !ok {
+		t.Fatalf("expected to be saved, got %t, %v", ok, err)
+	}
+	if ok, err := nx.SetIfNotExists("foo", "bar", time.Minute); err != nil || ok {
+		t.Errorf("expected the ID to be taken, got %t, %v", ok, err)
+	}
+	if n, err := s.(captchas.AttemptStore).Attempt("foo"); err != nil || n != 1 {
+		t.Errorf("expected attempts %d, got %d, %v", 1, n, err)
+	}
+	captchas.Touch(s, "foo", time.Hour)
+	if ok, err := captchas.Exists(s, "foo"); err != nil || !ok {
+		t.Errorf("expected the captcha to exist, got %t, %v", ok, err)
+	}
+	s.(captchas.DeleteStore).Delete("foo")
+	if ok, _ := captchas.Exists(mem, "foo"); ok {
+		t.Error("expected the captcha to be deleted")
+	}
+
+	expected := []string{
+		`op=set id="foo" answer=[redacted] `,
+		`op=set id="foo" answer=[redacted] `,
+		`op=attempt id="foo" `,
+		`op=touch id="foo" `,
+		`op=exists id="foo" `,
+		`op=delete id="foo" `,
+	}
+	if len(*logger) != len(expected) {
+		t.Fatalf("expected %d logs, got %d", len(expected), len(*logger))
+	}
+	for i, msg := range *logger {
+		if !strings.Contains(msg, expected[i]) {
+			t.Errorf("unexpected log: %s", msg)
+		}
+	}
+	if !strings.HasSuffix((*logger)[1], fmt.Sprintf("result=%q", captchas.ErrCaptchaCollision)) {
+		t.Errorf("expected the collision to be logged: %s", (*logger)[1])
+	}
+}
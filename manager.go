@@ -5,8 +5,9 @@
 package captchas
 
 import (
-	"errors"
+	"context"
 	"strings"
+	"time"
 )
 
 // Option is a function that receives a pointer of manager.
@@ -19,11 +20,42 @@ func CaseSensitive(v bool) Option {
 	}
 }
 
+// TTL is an option that sets the lifetime of captchas generated by manager,
+// instead of the expiration of store, so that managers of different drivers
+// can share a single store. The store must implement TTLStore.
+func TTL(ttl time.Duration) Option {
+	return func(m *Manager) {
+		m.ttl = ttl
+	}
+}
+
+// MaxCollisions is an option that sets how many times manager regenerates
+// the captcha if the ID is taken, it requires the store to implement
+// NXStore. Defaults to 3.
+func MaxCollisions(n int) Option {
+	return func(m *Manager) {
+		m.maxCollisions = n
+	}
+}
+
+// MaxAttempts is an option that limits the verification attempts of each
+// captcha, the captcha is deleted once the limit is exceeded, so that it
+// cannot be brute-forced when verifying without clearing. The store must
+// implement AttemptStore.
+func MaxAttempts(n int) Option {
+	return func(m *Manager) {
+		m.maxAttempts = n
+	}
+}
+
 // Manager is a captchas manager.
 type Manager struct {
 	store         Store
 	driver        Driver
 	caseSensitive bool
+	ttl           time.Duration
+	maxCollisions int
+	maxAttempts   int
 }
 
 // New returns a manager instance with the given store and driver.
@@ -32,6 +64,7 @@ func New(store Store, driver Driver, opts ...Option) *Manager {
 		store:         store,
 		driver:        driver,
 		caseSensitive: true,
+		maxCollisions: 3,
 	}
 
 	for _, f := range opts {
@@ -43,48 +76,239 @@ func New(store Store, driver Driver, opts ...Option) *Manager {
 
 // Generate generates a new captcha and save it to store, returns an error if failed.
 func (m *Manager) Generate() (Captcha, error) {
-	captcha, err := m.driver.Generate()
-	if err != nil {
-		return nil, err
+	return m.GenerateContext(context.Background())
+}
+
+// GenerateContext is the context-aware version of Generate.
+func (m *Manager) GenerateContext(ctx context.Context) (Captcha, error) {
+	return m.generate(ctx, nil)
+}
+
+// GenerateWithMetadata generates a new captcha and save it to store with the
+// given metadata, the creation time defaults to now. The store must
+// implement MetadataStore, ID collisions are detected as Generate does if
+// the store also implements NXStore.
+func (m *Manager) GenerateWithMetadata(md Metadata) (Captcha, error) {
+	return m.GenerateWithMetadataContext(context.Background(), md)
+}
+
+// GenerateWithMetadataContext is the context-aware version of
+// GenerateWithMetadata.
+func (m *Manager) GenerateWithMetadataContext(ctx context.Context, md Metadata) (Captcha, error) {
+	if _, ok := m.store.(MetadataStore); !ok {
+		return nil, ErrMetadataUnsupported
 	}
-	if err = m.store.Set(captcha.ID(), captcha.Answer()); err != nil {
-		return nil, err
+	if md.CreatedAt.IsZero() {
+		md.CreatedAt = time.Now()
 	}
+	return m.generate(ctx, &md)
+}
+
+// generate generates and saves captchas until the ID is not taken.
+func (m *Manager) generate(ctx context.Context, md *Metadata) (Captcha, error) {
+	for i := 0; i <= m.maxCollisions; i++ {
+		captcha, err := m.driver.Generate()
+		if err != nil {
+			return nil, err
+		}
+		ok, err := m.save(ctx, captcha, md)
+		if err != nil {
+			return nil, wrapError("set", err)
+		}
+		if ok {
+			return captcha, nil
+		}
+	}
+
+	return nil, ErrCaptchaCollision
+}
+
+// GetMetadata returns the metadata of the captcha, see GetMetadata.
+func (m *Manager) GetMetadata(id string) (Metadata, error) {
+	return m.GetMetadataContext(context.Background(), id)
+}
+
+// GetMetadataContext is the context-aware version of GetMetadata.
+func (m *Manager) GetMetadataContext(ctx context.Context, id string) (Metadata, error) {
+	md, err := GetMetadataContext(ctx, m.store, id)
+	return md, wrapError("get metadata", err)
+}
 
-	return captcha, nil
+// save saves the captcha with the optional metadata, reports false if the ID
+// is taken. The ID is claimed by SetIfNotExists before saving the metadata.
+func (m *Manager) save(ctx context.Context, captcha Captcha, md *Metadata) (bool, error) {
+	if ns, ok := m.store.(NXStore); ok {
+		ok, err := SetIfNotExistsContext(ctx, ns, captcha.ID(), captcha.Answer(), m.ttl)
+		if !ok || err != nil || md == nil {
+			return ok, err
+		}
+	}
+	if md != nil {
+		return true, SetWithMetadataContext(ctx, m.store, captcha.ID(), captcha.Answer(), m.ttl, *md)
+	}
+	if m.ttl > 0 {
+		return true, SetWithTTLContext(ctx, m.store, captcha.ID(), captcha.Answer(), m.ttl)
+	}
+	return true, SetContext(ctx, m.store, captcha.ID(), captcha.Answer())
 }
 
-// Get is a shortcut of Store.Get.
+// Get is a shortcut of Store.Get, failures of store are wrapped as
+// StoreError.
 func (m *Manager) Get(id string, clear bool) (string, error) {
-	return m.store.Get(id, clear)
+	answer, err := m.store.Get(id, clear)
+	return answer, wrapError("get", err)
 }
 
-// Errors
-var (
-	ErrIncorrectCaptcha = errors.New("incorrect captcha")
-	ErrExpiredCaptcha   = errors.New("expired captcha")
-)
+// GetContext is the context-aware version of Get.
+func (m *Manager) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	answer, err := GetContext(ctx, m.store, id, clear)
+	return answer, wrapError("get", err)
+}
+
+// Exists reports whether the captcha is still valid, the captcha is never
+// consumed, see Exists.
+func (m *Manager) Exists(id string) (bool, error) {
+	return m.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext is the context-aware version of Exists.
+func (m *Manager) ExistsContext(ctx context.Context, id string) (bool, error) {
+	ok, err := ExistsContext(ctx, m.store, id)
+	return ok, wrapError("exists", err)
+}
+
+// Delete invalidates the captcha, see Delete.
+func (m *Manager) Delete(id string) error {
+	return m.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext is the context-aware version of Delete.
+func (m *Manager) DeleteContext(ctx context.Context, id string) error {
+	return wrapError("delete", DeleteContext(ctx, m.store, id))
+}
+
+// Touch extends the lifetime of the captcha, see Touch.
+func (m *Manager) Touch(id string, ttl time.Duration) error {
+	return m.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext is the context-aware version of Touch.
+func (m *Manager) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
+	return wrapError("touch", TouchContext(ctx, m.store, id, ttl))
+}
+
+// Close closes the store if it implements io.Closer, see Close.
+func (m *Manager) Close() error {
+	return Close(m.store)
+}
 
 // Verify verifies whether the given actual value is equal to the
 // answer of captcha, returns an error if failed.
 func (m *Manager) Verify(id, actual string, clear bool) error {
-	answer, err := m.store.Get(id, clear)
+	return m.VerifyContext(context.Background(), id, actual, clear)
+}
+
+// VerifyContext is the context-aware version of Verify.
+func (m *Manager) VerifyContext(ctx context.Context, id, actual string, clear bool) error {
+	if cm, ok := m.driver.(ContextMatcher); ok {
+		return m.verifyContextMatcher(ctx, cm, id, actual, clear)
+	}
+
+	if m.maxAttempts > 0 {
+		if err := m.attempt(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	return m.verify(ctx, id, actual, clear, m.isEqual)
+}
+
+// verify verifies the captcha by the store if it is a verifier, otherwise
+// compares the answer with match.
+func (m *Manager) verify(ctx context.Context, id, actual string, clear bool, match func(actual, answer string) bool) error {
+	if v, ok := m.store.(MatchVerifier); ok {
+		return wrapError("verify", VerifyMatchContext(ctx, v, id, actual, clear, match))
+	}
+	if v, ok := m.store.(Verifier); ok {
+		// the answer is unavailable to compare with the driver.
+		if m.isMatcher() {
+			return ErrMatcherUnsupported
+		}
+		return wrapError("verify", VerifyContext(ctx, v, id, actual, clear))
+	}
+
+	answer, err := m.GetContext(ctx, id, clear)
 	if err != nil {
 		return err
 	}
 
-	if m.isEqual(actual, answer) {
+	if match(actual, answer) {
 		return nil
 	}
 
 	return ErrIncorrectCaptcha
 }
 
+// verifyContextMatcher verifies by the store as other drivers do, the error
+// of driver is captured by the match function. The attempt is counted after
+// matching, so that failures of the driver can be retried, it is needless if
+// the captcha is consumed.
+func (m *Manager) verifyContextMatcher(ctx context.Context, cm ContextMatcher, id, actual string, clear bool) error {
+	var merr error
+	err := m.verify(ctx, id, actual, clear, func(actual, answer string) bool {
+		if actual == "" || answer == "" {
+			return false
+		}
+		ok, err := cm.MatchContext(ctx, actual, answer)
+		if err != nil {
+			merr = err
+		}
+		return ok
+	})
+	if merr != nil {
+		return &DriverError{Op: "match", Err: merr}
+	}
+	if m.maxAttempts > 0 && !clear && (err == nil || err == ErrIncorrectCaptcha) {
+		if aerr := m.attempt(ctx, id); aerr != nil {
+			return aerr
+		}
+	}
+	return err
+}
+
+// attempt counts the verification attempt, every verification counts, so
+// that concurrent guesses cannot exceed the limit.
+func (m *Manager) attempt(ctx context.Context, id string) error {
+	n, err := AttemptContext(ctx, m.store, id)
+	if err != nil {
+		return wrapError("attempt", err)
+	}
+	if n > m.maxAttempts {
+		if err := DeleteContext(ctx, m.store, id); err != nil {
+			return wrapError("delete", err)
+		}
+		return ErrTooManyAttempts
+	}
+	return nil
+}
+
+func (m *Manager) isMatcher() bool {
+	switch m.driver.(type) {
+	case Matcher, ContextMatcher:
+		return true
+	}
+	return false
+}
+
 func (m *Manager) isEqual(actual, answer string) bool {
 	if answer == "" || actual == "" {
 		return false
 	}
 
+	if matcher, ok := m.driver.(Matcher); ok {
+		return matcher.Match(actual, answer)
+	}
+
 	if !m.caseSensitive {
 		return strings.EqualFold(actual, answer)
 	}
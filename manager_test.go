@@ -5,9 +5,13 @@
 package captchas
 
 import (
+	"context"
 	"errors"
+	"html/template"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCaseSensitive(t *testing.T) {
@@ -43,6 +47,27 @@ func TestManagerIsEqual(t *testing.T) {
 	}
 }
 
+type testMatcher struct {
+	testDriver
+}
+
+func (d testMatcher) Match(actual, answer string) bool {
+	return strings.HasPrefix(actual, answer)
+}
+
+func TestManagerMatcher(t *testing.T) {
+	m := New(&testStore{}, &testMatcher{}, CaseSensitive(false))
+	if !m.isEqual("foobar", "foo") {
+		t.Errorf("expected %q matches %q", "foobar", "foo")
+	}
+	if m.isEqual("Foo", "foo") {
+		t.Errorf("expected %q doesn't match %q", "Foo", "foo")
+	}
+	if m.isEqual("", "foo") {
+		t.Error("expected an empty value doesn't match")
+	}
+}
+
 type testStore struct {
 }
 
@@ -117,3 +142,556 @@ func TestManagerVerify(t *testing.T) {
 		}
 	}
 }
+
+type testVerifier struct {
+	testStore
+}
+
+func (s *testVerifier) Verify(id, actual string, clear bool) error {
+	if actual == "bar" {
+		return nil
+	}
+	return ErrIncorrectCaptcha
+}
+
+func TestManagerVerifyVerifier(t *testing.T) {
+	m := New(&testVerifier{}, &testDriver{})
+	for _, clear := range []bool{true, false} {
+		if err := m.Verify("foo", "bar", clear); err != nil {
+			t.Errorf("expected non error, got %v", err)
+		}
+		if err := m.Verify("foo", "get", clear); err != ErrIncorrectCaptcha {
+			t.Errorf("expected err %v, got %v", ErrIncorrectCaptcha, err)
+		}
+	}
+}
+
+type testMatchVerifier struct {
+	testStore
+}
+
+func (s *testMatchVerifier) VerifyMatch(id, actual string, clear bool, match func(actual, answer string) bool) error {
+	answer, _ := s.Get(id, clear)
+	if match(actual, answer) {
+		return nil
+	}
+	return ErrIncorrectCaptcha
+}
+
+func TestManagerVerifyMatchVerifier(t *testing.T) {
+	m := New(&testVerifier{}, &testMatcher{})
+	if err := m.Verify("foo", "bar", true); err != ErrMatcherUnsupported {
+		t.Errorf("expected err %v, got %v", ErrMatcherUnsupported, err)
+	}
+
+	m = New(&testMatchVerifier{}, &testMatcher{})
+	if err := m.Verify("foo", "getAndDel!", true); err != nil {
+		t.Errorf("expected non error, got %v", err)
+	}
+	if err := m.Verify("foo", "ge", false); err != ErrIncorrectCaptcha {
+		t.Errorf("expected err %v, got %v", ErrIncorrectCaptcha, err)
+	}
+
+	m = New(&testMatchVerifier{}, &testDriver{}, CaseSensitive(false))
+	if err := m.Verify("foo", "GET", false); err != nil {
+		t.Errorf("expected non error, got %v", err)
+	}
+}
+
+type ctxKey struct{}
+
+type testContextStore struct {
+	testStore
+	ctx context.Context
+}
+
+func (s *testContextStore) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	s.ctx = ctx
+	return "bar", nil
+}
+
+func (s *testContextStore) SetContext(ctx context.Context, id, answer string) error {
+	s.ctx = ctx
+	return nil
+}
+
+type testCaptcha struct {
+}
+
+func (c testCaptcha) ID() string {
+	return "foo"
+}
+
+func (c testCaptcha) Answer() string {
+	return "bar"
+}
+
+func (c testCaptcha) EncodeToString() string {
+	return ""
+}
+
+func (c testCaptcha) HTMLField(fieldName string) template.HTML {
+	return ""
+}
+
+type testCaptchaDriver struct {
+}
+
+func (d *testCaptchaDriver) Generate() (Captcha, error) {
+	return testCaptcha{}, nil
+}
+
+func TestManagerContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	store := &testContextStore{}
+	m := New(store, &testCaptchaDriver{})
+	if _, err := m.GenerateContext(ctx); err != nil || store.ctx != ctx {
+		t.Errorf("expected context %v, got %v, %v", ctx, store.ctx, err)
+	}
+	store.ctx = nil
+	if value, err := m.GetContext(ctx, "foo", true); err != nil || value != "bar" || store.ctx != ctx {
+		t.Errorf("expected context %v, got %v, %v", ctx, store.ctx, err)
+	}
+	store.ctx = nil
+	if err := m.VerifyContext(ctx, "foo", "bar", true); err != nil || store.ctx != ctx {
+		t.Errorf("expected context %v, got %v, %v", ctx, store.ctx, err)
+	}
+
+	// falls back to Store.
+	m = New(&testStore{}, &testCaptchaDriver{})
+	if value, _ := m.GetContext(ctx, "foo", false); value != "get" {
+		t.Errorf("expected value %q, got %q", "get", value)
+	}
+	if err := SetContext(ctx, &testStore{}, "foo", "bar"); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+}
+
+type testCloser struct {
+	testStore
+	closed bool
+}
+
+func (s *testCloser) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestManagerClose(t *testing.T) {
+	if err := New(&testStore{}, &testDriver{}).Close(); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+
+	store := &testCloser{}
+	if err := New(store, &testDriver{}).Close(); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if !store.closed {
+		t.Error("expected the store to be closed")
+	}
+}
+
+type testTTLStore struct {
+	testStore
+	ttl time.Duration
+}
+
+func (s *testTTLStore) SetWithTTL(id, answer string, ttl time.Duration) error {
+	s.ttl = ttl
+	return nil
+}
+
+func TestTTL(t *testing.T) {
+	store := &testTTLStore{}
+	m := New(store, &testCaptchaDriver{}, TTL(time.Minute))
+	if m.ttl != time.Minute {
+		t.Errorf("expected TTL %s, got %s", time.Minute, m.ttl)
+	}
+	if _, err := m.Generate(); err != nil {
+		t.Fatalf("failed to generate: %s", err)
+	}
+	if store.ttl != time.Minute {
+		t.Errorf("expected TTL %s, got %s", time.Minute, store.ttl)
+	}
+
+	m = New(&testStore{}, &testCaptchaDriver{}, TTL(time.Minute))
+	if _, err := m.Generate(); err != ErrTTLUnsupported {
+		t.Errorf("expected error %v, got %v", ErrTTLUnsupported, err)
+	}
+}
+
+type testMapStore map[string]string
+
+func (s testMapStore) Get(id string, clear bool) (string, error) {
+	answer, ok := s[id]
+	if !ok {
+		return "", ErrIncorrectCaptcha
+	}
+	if answer == "error" {
+		return "", errors.New("error")
+	}
+	if clear {
+		delete(s, id)
+	}
+	return answer, nil
+}
+
+func (s testMapStore) Set(id, answer string) error {
+	if answer == "error" {
+		return errors.New("error")
+	}
+	s[id] = answer
+	return nil
+}
+
+func TestBatch(t *testing.T) {
+	s := testMapStore{}
+	if err := SetMulti(s, map[string]string{"foo": "bar", "fizz": "buzz"}); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	answers, err := GetMulti(s, []string{"foo", "fizz", "missing"}, true)
+	if err != nil {
+		t.Fatalf("failed to get: %s", err)
+	}
+	if !reflect.DeepEqual(answers, map[string]string{"foo": "bar", "fizz": "buzz"}) {
+		t.Errorf("unexpected answers: %v", answers)
+	}
+	if len(s) != 0 {
+		t.Errorf("expected captchas to be deleted, got %v", s)
+	}
+
+	if err = SetMulti(s, map[string]string{"foo": "error"}); err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+	s["foo"] = "error"
+	if _, err = GetMulti(s, []string{"foo"}, false); err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+type testNXStore struct {
+	testMapStore
+	ttl time.Duration
+}
+
+func (s *testNXStore) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	s.ttl = ttl
+	if _, ok := s.testMapStore[id]; ok {
+		return false, nil
+	}
+	s.testMapStore[id] = answer
+	return true, nil
+}
+
+type testCountDriver struct {
+	testCaptchaDriver
+	count int
+}
+
+func (d *testCountDriver) Generate() (Captcha, error) {
+	d.count++
+	return d.testCaptchaDriver.Generate()
+}
+
+func TestMaxCollisions(t *testing.T) {
+	store := &testNXStore{testMapStore: testMapStore{}}
+	driver := &testCountDriver{}
+	m := New(store, driver, MaxCollisions(2), TTL(time.Minute))
+	if m.maxCollisions != 2 {
+		t.Errorf("expected max collisions %d, got %d", 2, m.maxCollisions)
+	}
+	if _, err := m.Generate(); err != nil {
+		t.Fatalf("failed to generate: %s", err)
+	}
+	if store.ttl != time.Minute {
+		t.Errorf("expected TTL %s, got %s", time.Minute, store.ttl)
+	}
+	driver.count = 0
+	if _, err := m.Generate(); err != ErrCaptchaCollision {
+		t.Errorf("expected error %v, got %v", ErrCaptchaCollision, err)
+	}
+	if driver.count != 3 {
+		t.Errorf("expected %d generations, got %d", 3, driver.count)
+	}
+}
+
+func TestManagerTouch(t *testing.T) {
+	if err := New(&testStore{}, &testDriver{}).Touch("foo", time.Minute); err != ErrTouchUnsupported {
+		t.Errorf("expected error %v, got %v", ErrTouchUnsupported, err)
+	}
+}
+
+func TestManagerDelete(t *testing.T) {
+	s := testMapStore{"foo": "bar"}
+	m := New(s, &testDriver{})
+	if err := m.Delete("foo"); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if err := m.Delete("foo"); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if len(s) != 0 {
+		t.Errorf("expected the captcha to be deleted, got %v", s)
+	}
+}
+
+func TestManagerExists(t *testing.T) {
+	m := New(testMapStore{"foo": "bar", "error": "error"}, &testDriver{})
+	if ok, err := m.Exists("foo"); err != nil || !ok {
+		t.Errorf("expected true, got %t, %v", ok, err)
+	}
+	if ok, err := m.Exists("fizz"); err != nil || ok {
+		t.Errorf("expected false, got %t, %v", ok, err)
+	}
+	if _, err := m.Exists("error"); err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+type testMetadataStore struct {
+	testStore
+	md Metadata
+}
+
+func (s *testMetadataStore) SetWithMetadata(id, answer string, ttl time.Duration, md Metadata) error {
+	s.md = md
+	return nil
+}
+
+func (s *testMetadataStore) GetMetadata(id string) (Metadata, error) {
+	return s.md, nil
+}
+
+func TestManagerMetadata(t *testing.T) {
+	store := &testMetadataStore{}
+	m := New(store, &testCaptchaDriver{})
+	if _, err := m.GenerateWithMetadata(Metadata{ClientIP: "127.0.0.1"}); err != nil {
+		t.Fatalf("failed to generate: %s", err)
+	}
+	md, err := m.GetMetadata("foo")
+	if err != nil || md.ClientIP != "127.0.0.1" || md.CreatedAt.IsZero() {
+		t.Errorf("unexpected metadata %v, %v", md, err)
+	}
+
+	m = New(&testStore{}, &testCaptchaDriver{})
+	if _, err = m.GenerateWithMetadata(Metadata{}); err != ErrMetadataUnsupported {
+		t.Errorf("expected error %v, got %v", ErrMetadataUnsupported, err)
+	}
+	if _, err = m.GetMetadata("foo"); err != ErrMetadataUnsupported {
+		t.Errorf("expected error %v, got %v", ErrMetadataUnsupported, err)
+	}
+}
+
+type testAttemptStore struct {
+	testMapStore
+	attempts map[string]int
+}
+
+func (s *testAttemptStore) Attempt(id string) (int, error) {
+	if _, ok := s.testMapStore[id]; !ok {
+		return 0, ErrIncorrectCaptcha
+	}
+	s.attempts[id]++
+	return s.attempts[id], nil
+}
+
+func TestMaxAttempts(t *testing.T) {
+	store := &testAttemptStore{testMapStore: testMapStore{"foo": "bar"}, attempts: map[string]int{}}
+	m := New(store, &testDriver{}, MaxAttempts(2))
+	for _, actual := range []string{"baz", "bar"} {
+		err := m.Verify("foo", actual, false)
+		if actual == "bar" && err != nil {
+			t.Errorf("expected non error, got %s", err)
+		}
+	}
+	if err := m.Verify("foo", "bar", false); err != ErrTooManyAttempts {
+		t.Errorf("expected error %v, got %v", ErrTooManyAttempts, err)
+	}
+	if _, ok := store.testMapStore["foo"]; ok {
+		t.Error("expected the captcha to be deleted")
+	}
+
+	m = New(&testStore{}, &testDriver{}, MaxAttempts(2))
+	if err := m.Verify("foo", "bar", false); err != ErrAttemptsUnsupported {
+		t.Errorf("expected error %v, got %v", ErrAttemptsUnsupported, err)
+	}
+
+	broken := &testBrokenDeleteStore{testAttemptStore{testMapStore: testMapStore{"foo": "bar"}, attempts: map[string]int{"foo": 2}}}
+	m = New(broken, &testDriver{}, MaxAttempts(2))
+	err := m.Verify("foo", "bar", false)
+	var serr *StoreError
+	if !errors.As(err, &serr) || serr.Op != "delete" {
+		t.Errorf("expected a store error of delete, got %v", err)
+	}
+}
+
+type testBrokenDeleteStore struct {
+	testAttemptStore
+}
+
+func (s *testBrokenDeleteStore) Delete(id string) error {
+	return errors.New("unavailable")
+}
+
+type testContextMatcher struct {
+	testDriver
+}
+
+func (d testContextMatcher) MatchContext(ctx context.Context, actual, answer string) (bool, error) {
+	if actual == "broken" {
+		return false, errors.New("unavailable")
+	}
+	return actual == answer, nil
+}
+
+func TestManagerContextMatcher(t *testing.T) {
+	store := &testAttemptStore{testMapStore: testMapStore{"foo": "bar"}, attempts: map[string]int{}}
+	m := New(store, &testContextMatcher{}, MaxAttempts(1))
+	err := m.Verify("foo", "broken", false)
+	var derr *DriverError
+	if !errors.As(err, &derr) || !errors.Is(err, ErrDriverUnavailable) || IsCaptchaError(err) {
+		t.Fatalf("expected a driver error, got %v", err)
+	}
+	if store.attempts["foo"] != 0 || store.testMapStore["foo"] != "bar" {
+		t.Error("expected the failure doesn't count the attempt or consume the captcha")
+	}
+	if err := m.Verify("foo", "broken", true); !errors.Is(err, ErrDriverUnavailable) {
+		t.Fatalf("expected a driver error, got %v", err)
+	}
+	if _, ok := store.testMapStore["foo"]; ok {
+		t.Error("expected the captcha to be consumed")
+	}
+
+	store.testMapStore["foo"] = "bar"
+	if err := m.Verify("foo", "bar", true); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if _, ok := store.testMapStore["foo"]; ok {
+		t.Error("expected the captcha to be consumed")
+	}
+
+	store.testMapStore["foo"], store.attempts["foo"] = "bar", 0
+	if err := m.Verify("foo", "baz", false); err != ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", ErrIncorrectCaptcha, err)
+	}
+	if err := m.Verify("foo", "bar", false); err != ErrTooManyAttempts {
+		t.Errorf("expected error %v, got %v", ErrTooManyAttempts, err)
+	}
+
+	m = New(&testVerifier{}, &testContextMatcher{})
+	if err := m.Verify("foo", "bar", false); err != ErrMatcherUnsupported {
+		t.Errorf("expected error %v, got %v", ErrMatcherUnsupported, err)
+	}
+
+	m = New(&testMatchVerifier{}, &testContextMatcher{})
+	if err := m.Verify("foo", "broken", false); !errors.Is(err, ErrDriverUnavailable) {
+		t.Errorf("expected a driver error, got %v", err)
+	}
+	if err := m.Verify("foo", "getAndDel", true); err != nil {
+		t.Errorf("expected non error by the match verifier, got %v", err)
+	}
+}
+
+type testNXMetadataStore struct {
+	testNXStore
+	md map[string]Metadata
+}
+
+func (s *testNXMetadataStore) SetWithMetadata(id, answer string, ttl time.Duration, md Metadata) error {
+	s.testMapStore[id] = answer
+	s.md[id] = md
+	return nil
+}
+
+func (s *testNXMetadataStore) GetMetadata(id string) (Metadata, error) {
+	return s.md[id], nil
+}
+
+func TestManagerMetadataCollision(t *testing.T) {
+	store := &testNXMetadataStore{testNXStore: testNXStore{testMapStore: testMapStore{}}, md: map[string]Metadata{}}
+	driver := &testCountDriver{}
+	m := New(store, driver, TTL(time.Minute))
+	if _, err := m.GenerateWithMetadata(Metadata{ClientIP: "127.0.0.1"}); err != nil {
+		t.Fatalf("failed to generate: %s", err)
+	}
+	if store.ttl != time.Minute || store.md["foo"].ClientIP != "127.0.0.1" {
+		t.Errorf("unexpected ttl %s and metadata %v", store.ttl, store.md)
+	}
+	store.md["foo"] = Metadata{}
+	driver.count = 0
+	if _, err := m.GenerateWithMetadata(Metadata{ClientIP: "127.0.0.2"}); err != ErrCaptchaCollision {
+		t.Errorf("expected error %v, got %v", ErrCaptchaCollision, err)
+	}
+	if driver.count != 4 || store.md["foo"].ClientIP != "" {
+		t.Errorf("expected %d generations without overwriting, got %d, %v", 4, driver.count, store.md)
+	}
+}
+
+type testContextKey struct{}
+
+type testContextNXStore struct {
+	testNXMetadataStore
+	values []interface{}
+}
+
+func (s *testContextNXStore) SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error) {
+	s.values = append(s.values, ctx.Value(testContextKey{}))
+	return s.SetIfNotExists(id, answer, ttl)
+}
+
+func (s *testContextNXStore) SetWithMetadataContext(ctx context.Context, id, answer string, ttl time.Duration, md Metadata) error {
+	s.values = append(s.values, ctx.Value(testContextKey{}))
+	return s.SetWithMetadata(id, answer, ttl, md)
+}
+
+func (s *testContextNXStore) GetMetadataContext(ctx context.Context, id string) (Metadata, error) {
+	s.values = append(s.values, ctx.Value(testContextKey{}))
+	return s.GetMetadata(id)
+}
+
+type testContextAttemptStore struct {
+	testAttemptStore
+	values []interface{}
+}
+
+func (s *testContextAttemptStore) AttemptContext(ctx context.Context, id string) (int, error) {
+	s.values = append(s.values, ctx.Value(testContextKey{}))
+	return s.Attempt(id)
+}
+
+func (s *testContextAttemptStore) DeleteContext(ctx context.Context, id string) error {
+	s.values = append(s.values, ctx.Value(testContextKey{}))
+	return s.Delete(id)
+}
+
+func (s *testContextAttemptStore) Delete(id string) error {
+	delete(s.testMapStore, id)
+	return nil
+}
+
+func TestManagerAttemptContext(t *testing.T) {
+	store := &testContextAttemptStore{testAttemptStore: testAttemptStore{testMapStore: testMapStore{"foo": "bar"}, attempts: map[string]int{"foo": 1}}}
+	m := New(store, &testDriver{}, MaxAttempts(1))
+	ctx := context.WithValue(context.Background(), testContextKey{}, "foo")
+	if err := m.VerifyContext(ctx, "foo", "bar", false); err != ErrTooManyAttempts {
+		t.Errorf("expected error %v, got %v", ErrTooManyAttempts, err)
+	}
+	if len(store.values) != 2 || store.values[0] != "foo" || store.values[1] != "foo" {
+		t.Errorf("expected the context to be passed to the store, got %v", store.values)
+	}
+}
+
+func TestManagerGenerateContext(t *testing.T) {
+	store := &testContextNXStore{testNXMetadataStore: testNXMetadataStore{testNXStore: testNXStore{testMapStore: testMapStore{}}, md: map[string]Metadata{}}}
+	m := New(store, &testCaptchaDriver{})
+	ctx := context.WithValue(context.Background(), testContextKey{}, "foo")
+	if _, err := m.GenerateWithMetadataContext(ctx, Metadata{}); err != nil {
+		t.Fatalf("failed to generate: %s", err)
+	}
+	if len(store.values) != 2 || store.values[0] != "foo" || store.values[1] != "foo" {
+		t.Errorf("expected the context to be passed to the store, got %v", store.values)
+	}
+	if _, err := m.GetMetadataContext(ctx, "foo"); err != nil || len(store.values) != 3 {
+		t.Errorf("expected the context to be passed to the store, got %v, %v", err, store.values)
+	}
+}
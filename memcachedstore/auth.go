@@ -0,0 +1,66 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memcachedstore
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// ErrAuthentication is returned by AuthDialer when the server rejects
+// the credentials.
+var ErrAuthentication = errors.New("memcachedstore: authentication failure")
+
+// DialFunc is the dial function of memcache.Client.DialContext.
+type DialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// AuthDialer returns a DialFunc that authenticates new connections by the
+// ASCII authentication of memcached 1.5.15+ started with an auth file (-Y).
+// Binary SASL is not supported, since the client speaks the text protocol
+// only. The dial defaults to net.Dialer.
+//
+//	client := memcache.NewFromSelector(ring)
+//	client.DialContext = memcachedstore.AuthDialer("user", "password", nil)
+func AuthDialer(username, password string, dial DialFunc) DialFunc {
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		conn, err := dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		if err = authenticate(ctx, conn, username, password); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return conn, nil
+	}
+}
+
+func authenticate(ctx context.Context, conn net.Conn, username, password string) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	credentials := username + " " + password
+	if _, err := fmt.Fprintf(conn, "set auth 0 0 %d\r\n%s\r\n", len(credentials), credentials); err != nil {
+		return err
+	}
+	// the server replies a single line, nothing is buffered beyond it.
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(line) != "STORED" {
+		return ErrAuthentication
+	}
+	return nil
+}
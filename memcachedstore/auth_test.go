@@ -0,0 +1,58 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memcachedstore
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+)
+
+// serveAuth accepts a connection and replies STORED if the credentials are
+// "user password".
+func serveAuth(l net.Listener) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	cmd, _ := r.ReadString('\n')
+	data, _ := r.ReadString('\n')
+	if cmd == "set auth 0 0 13\r\n" && data == "user password\r\n" {
+		conn.Write([]byte("STORED\r\n"))
+	} else {
+		conn.Write([]byte("CLIENT_ERROR authentication failure\r\n"))
+	}
+	r.ReadString('\n')
+}
+
+func TestAuthDialer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	tests := []struct {
+		password string
+		err      error
+	}{
+		{"password", nil},
+		{"wrong", ErrAuthentication},
+	}
+	for _, test := range tests {
+		go serveAuth(l)
+		dial := AuthDialer("user", test.password, nil)
+		conn, err := dial(context.Background(), "tcp", l.Addr().String())
+		if err != test.err {
+			t.Errorf("expected error %v, got %v", test.err, err)
+		}
+		if conn != nil {
+			conn.Close()
+		}
+	}
+}
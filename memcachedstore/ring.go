@@ -0,0 +1,115 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memcachedstore
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+// pointsPerServer is the number of points of each server on the ring.
+const pointsPerServer = 160
+
+// Ring is a memcache.ServerSelector of consistent hashing, which is
+// compatible with ketama, so that only the keys of a server move when the
+// server is added or removed.
+//
+//	ring, err := memcachedstore.NewRing("10.0.0.1:11211", "10.0.0.2:11211")
+//	client := memcache.NewFromSelector(ring)
+type Ring struct {
+	mu     sync.RWMutex
+	addrs  []net.Addr
+	points []point
+}
+
+type point struct {
+	hash uint32
+	addr net.Addr
+}
+
+var _ memcache.ServerSelector = (*Ring)(nil)
+
+// NewRing returns a ring of the given servers.
+func NewRing(servers ...string) (*Ring, error) {
+	r := &Ring{}
+	if err := r.SetServers(servers...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetServers changes the servers of the ring, the servers are TCP
+// addresses or paths of Unix sockets.
+func (r *Ring) SetServers(servers ...string) error {
+	addrs := make([]net.Addr, len(servers))
+	points := make([]point, 0, pointsPerServer*len(servers))
+	for i, server := range servers {
+		var err error
+		if strings.Contains(server, "/") {
+			addrs[i], err = net.ResolveUnixAddr("unix", server)
+		} else {
+			addrs[i], err = net.ResolveTCPAddr("tcp", server)
+		}
+		if err != nil {
+			return err
+		}
+		for j := 0; j < pointsPerServer/4; j++ {
+			digest := md5.Sum([]byte(server + "-" + strconv.Itoa(j)))
+			for k := 0; k < 4; k++ {
+				hash := binary.LittleEndian.Uint32(digest[4*k:])
+				points = append(points, point{hash: hash, addr: addrs[i]})
+			}
+		}
+	}
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].hash < points[j].hash
+	})
+
+	r.mu.Lock()
+	r.addrs = addrs
+	r.points = points
+	r.mu.Unlock()
+	return nil
+}
+
+// PickServer implements memcache.ServerSelector.PickServer.
+func (r *Ring) PickServer(key string) (net.Addr, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	switch len(r.addrs) {
+	case 0:
+		return nil, memcache.ErrNoServers
+	case 1:
+		return r.addrs[0], nil
+	}
+	digest := md5.Sum([]byte(key))
+	hash := binary.LittleEndian.Uint32(digest[:])
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i].hash >= hash
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].addr, nil
+}
+
+// Each implements memcache.ServerSelector.Each.
+func (r *Ring) Each(f func(net.Addr) error) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, addr := range r.addrs {
+		if err := f(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
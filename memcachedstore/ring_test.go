@@ -0,0 +1,68 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memcachedstore
+
+import (
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+func TestRing(t *testing.T) {
+	r, err := NewRing()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = r.PickServer("foo"); err != memcache.ErrNoServers {
+		t.Errorf("expected error %v, got %v", memcache.ErrNoServers, err)
+	}
+
+	servers := []string{"127.0.0.1:11211", "127.0.0.1:11212", "127.0.0.1:11213"}
+	if err = r.SetServers(servers...); err != nil {
+		t.Fatal(err)
+	}
+	picked := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		key := "captchas:" + strconv.Itoa(i)
+		addr, err := r.PickServer(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		picked[key] = addr.String()
+		counts[addr.String()]++
+	}
+	for _, server := range servers {
+		if counts[server] < 500 {
+			t.Errorf("expected keys to be distributed evenly, got %v", counts)
+		}
+	}
+
+	// only the keys of the new server move.
+	if err = r.SetServers(append(servers, "127.0.0.1:11214")...); err != nil {
+		t.Fatal(err)
+	}
+	for key, server := range picked {
+		addr, _ := r.PickServer(key)
+		if addr.String() != server && addr.String() != "127.0.0.1:11214" {
+			t.Fatalf("expected key %s to stay on %s, got %s", key, server, addr)
+		}
+	}
+
+	var each []string
+	r.Each(func(addr net.Addr) error {
+		each = append(each, addr.String())
+		return nil
+	})
+	if len(each) != 4 {
+		t.Errorf("expected 4 servers, got %v", each)
+	}
+
+	if err = r.SetServers("invalid:address:11211"); err == nil {
+		t.Error("expected an error of invalid address, got nil")
+	}
+}
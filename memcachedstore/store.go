@@ -5,8 +5,11 @@
 package memcachedstore
 
 import (
+	"time"
+
 	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/internal/timeutil"
 )
 
 // Option is a function that receives a pointer of memcached store.
@@ -55,24 +58,90 @@ func (s *store) getKey(id string) string {
 func (s *store) Get(id string, clear bool) (string, error) {
 	key := s.getKey(id)
 	item, err := s.client.Get(key)
+	if err == memcache.ErrCacheMiss {
+		return "", captchas.ErrIncorrectCaptcha
+	}
 	if err != nil {
 		return "", err
 	}
+	answer := string(item.Value)
+	if answer == "" {
+		return "", captchas.ErrIncorrectCaptcha
+	}
 	if clear {
-		if err := s.client.Delete(key); err != nil {
+		if err = s.consume(item); err != nil {
 			return "", err
 		}
 	}
-	return string(item.Value), nil
+	return answer, nil
+}
+
+// consume swaps the captcha with an empty tombstone by CAS, which fails if
+// the captcha has been consumed or replaced since it was got, so that only
+// one of concurrent consumers is able to get the answer. The tombstone is
+// deleted then, or expires in a second.
+func (s *store) consume(item *memcache.Item) error {
+	item.Value = nil
+	item.Expiration = 1
+	err := s.client.CompareAndSwap(item)
+	if err == memcache.ErrCASConflict || err == memcache.ErrNotStored || err == memcache.ErrCacheMiss {
+		return captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return err
+	}
+	if err = s.client.Delete(item.Key); err != nil && err != memcache.ErrCacheMiss {
+		return err
+	}
+	return nil
 }
 
-// Set implements Store.Get.
+// Set implements Store.Set.
 func (s *store) Set(id, answer string) error {
+	return s.SetWithTTL(id, answer, time.Duration(s.expiration)*time.Second)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
 	item := &memcache.Item{
 		Key:        s.getKey(id),
 		Value:      []byte(answer),
-		Expiration: s.expiration,
+		Expiration: int32(timeutil.Seconds(ttl)),
 	}
 
 	return s.client.Set(item)
 }
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *store) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		ttl = time.Duration(s.expiration) * time.Second
+	}
+	err := s.client.Add(&memcache.Item{
+		Key:        s.getKey(id),
+		Value:      []byte(answer),
+		Expiration: int32(timeutil.Seconds(ttl)),
+	})
+	if err == memcache.ErrNotStored {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	err := s.client.Touch(s.getKey(id), int32(timeutil.Seconds(ttl)))
+	if err == memcache.ErrCacheMiss {
+		return captchas.ErrIncorrectCaptcha
+	}
+	return err
+}
+
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	err := s.client.Delete(s.getKey(id))
+	if err == memcache.ErrCacheMiss {
+		return nil
+	}
+	return err
+}
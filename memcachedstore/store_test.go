@@ -5,6 +5,8 @@
 package memcachedstore
 
 import (
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/bradfitz/gomemcache/memcache"
@@ -73,3 +75,25 @@ func TestStoreGet(t *testing.T) {
 		t.Error("expected a non-nil error, got nil")
 	}
 }
+
+func TestStoreConsume(t *testing.T) {
+	s := New(testClient)
+	if err := s.Set("foo", "bar"); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	var wg sync.WaitGroup
+	var consumed int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Get("foo", true); err == nil {
+				atomic.AddInt32(&consumed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if consumed != 1 {
+		t.Errorf("expected the captcha to be consumed once, got %d", consumed)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memstore
+
+import (
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+// Drainer is implemented by memory stores, it copies pending captchas to
+// a durable store on shutdown, so that rolling deploys don't invalidate the
+// captchas on users' screens.
+//
+//	defer store.(memstore.Drainer).DrainTo(redisstore.New(client))
+type Drainer interface {
+	// DrainTo copies unexpired captchas to the given store with their
+	// remaining lifetimes if it implements captchas.TTLStore, and with
+	// their metadata if it implements captchas.MetadataStore. Returns the
+	// number of copied captchas.
+	DrainTo(dst captchas.Store) (int, error)
+}
+
+// DrainTo implements Drainer.DrainTo, shards are locked while taking the
+// snapshot only.
+func (s *store) DrainTo(dst captchas.Store) (int, error) {
+	now := s.now()
+	var records []record
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		for id, item := range sh.items {
+			if now.UnixNano() <= item.expiration {
+				records = append(records, record{ID: id, Answer: item.answer, Expiration: item.expiration, Metadata: item.metadata})
+			}
+		}
+		sh.mu.RUnlock()
+	}
+	return drain(dst, records, now)
+}
+
+// drain copies the records to the store with their remaining lifetimes.
+func drain(dst captchas.Store, records []record, now time.Time) (int, error) {
+	ms, hasMetadata := dst.(captchas.MetadataStore)
+	_, hasTTL := dst.(captchas.TTLStore)
+	for i, r := range records {
+		ttl := time.Unix(0, r.Expiration).Sub(now)
+		var err error
+		switch {
+		case hasMetadata && r.Metadata != nil:
+			err = ms.SetWithMetadata(r.ID, r.Answer, ttl, *r.Metadata)
+		case hasTTL:
+			err = captchas.SetWithTTL(dst, r.ID, r.Answer, ttl)
+		default:
+			err = dst.Set(r.ID, r.Answer)
+		}
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(records), nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestStoreDrainTo(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	src := New(Clock(clock.Now), Expiration(time.Minute)).(*store)
+	src.Set("foo", "bar")
+	src.SetWithMetadata("fizz", "buzz", 0, captchas.Metadata{ClientIP: "127.0.0.1"})
+	src.SetWithTTL("expired", "expired", -time.Second)
+	clock.Add(30 * time.Second)
+
+	dst := New(Clock(clock.Now), Expiration(time.Hour)).(*store)
+	n, err := src.DrainTo(dst)
+	if err != nil || n != 2 {
+		t.Fatalf("expected %d captchas to be drained, got %d, %v", 2, n, err)
+	}
+	if value, err := dst.Get("foo", false); err != nil || value != "bar" {
+		t.Errorf("expected value %q, got %q, %v", "bar", value, err)
+	}
+	if md, err := dst.GetMetadata("fizz"); err != nil || md.ClientIP != "127.0.0.1" {
+		t.Errorf("unexpected metadata %v, %v", md, err)
+	}
+	if _, err := dst.Get("expired", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	// the remaining lifetime is kept.
+	clock.Add(31 * time.Second)
+	if _, err := dst.Get("foo", false); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+}
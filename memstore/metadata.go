@@ -0,0 +1,41 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memstore
+
+import (
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+// SetWithMetadata implements MetadataStore.SetWithMetadata.
+func (s *store) SetWithMetadata(id, answer string, ttl time.Duration, md captchas.Metadata) error {
+	if ttl <= 0 {
+		ttl = s.expiration
+	}
+	sh := s.getShard(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	it := newItem(id, answer, s.now().Add(ttl).UnixNano())
+	it.metadata = &md
+	return s.set(sh, it)
+}
+
+// GetMetadata implements MetadataStore.GetMetadata, captchas saved without
+// metadata have zero metadata.
+func (s *store) GetMetadata(id string) (captchas.Metadata, error) {
+	sh := s.getShard(id)
+	now := s.now().UnixNano()
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	it, err := sh.get(id, now)
+	if err != nil {
+		return captchas.Metadata{}, err
+	}
+	if it.metadata == nil {
+		return captchas.Metadata{}, nil
+	}
+	return *it.metadata, nil
+}
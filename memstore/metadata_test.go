@@ -0,0 +1,53 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memstore
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestStoreMetadata(t *testing.T) {
+	s := New().(*store)
+	md := captchas.Metadata{
+		CreatedAt: time.Now(),
+		Driver:    "digit",
+		ClientIP:  "127.0.0.1",
+		Labels:    map[string]string{"form": "login"},
+	}
+	if err := s.SetWithMetadata("foo", "bar", 0, md); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if value, err := s.Get("foo", false); err != nil || value != "bar" {
+		t.Errorf("expected value %q, got %q, %v", "bar", value, err)
+	}
+	if got, err := s.GetMetadata("foo"); err != nil || !reflect.DeepEqual(got, md) {
+		t.Errorf("expected metadata %v, got %v, %v", md, got, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "captchas")
+	if err := s.Persist(path); err != nil {
+		t.Fatalf("failed to persist: %s", err)
+	}
+	restored := New().(*store)
+	if err := restored.Restore(path); err != nil {
+		t.Fatalf("failed to restore: %s", err)
+	}
+	if got, err := restored.GetMetadata("foo"); err != nil || !got.CreatedAt.Equal(md.CreatedAt) || got.ClientIP != md.ClientIP {
+		t.Errorf("expected metadata %v, got %v, %v", md, got, err)
+	}
+
+	s.Set("fizz", "buzz")
+	if got, err := s.GetMetadata("fizz"); err != nil || !reflect.DeepEqual(got, captchas.Metadata{}) {
+		t.Errorf("expected zero metadata, got %v, %v", got, err)
+	}
+	if _, err := s.GetMetadata("missing"); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memstore
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/clevergo/captchas"
+)
+
+// Persister is implemented by memory stores, it saves pending captchas to a
+// file and loads them back, so that captchas survive graceful restarts.
+//
+//	store := memstore.New()
+//	if err := store.(memstore.Persister).Restore(path); err != nil && !os.IsNotExist(err) {
+//		// handle error.
+//	}
+//	defer store.(memstore.Persister).Persist(path)
+type Persister interface {
+	// Persist saves unexpired captchas to the given file atomically.
+	Persist(path string) error
+
+	// Restore loads unexpired captchas from the given file.
+	Restore(path string) error
+}
+
+type record struct {
+	ID         string
+	Answer     string
+	Expiration int64
+	Metadata   *captchas.Metadata
+}
+
+// Persist implements Persister.Persist.
+func (s *store) Persist(path string) error {
+	now := s.now().UnixNano()
+	var records []record
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		for id, item := range sh.items {
+			if now <= item.expiration {
+				records = append(records, record{ID: id, Answer: item.answer, Expiration: item.expiration, Metadata: item.metadata})
+			}
+		}
+		sh.mu.RUnlock()
+	}
+	return persist(path, records)
+}
+
+// persist writes the records to a temporary file, and renames it to the
+// given path.
+func persist(path string, records []record) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err = gob.NewEncoder(f).Encode(records); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Restore implements Persister.Restore.
+func (s *store) Restore(path string) error {
+	records, err := restore(path)
+	if err != nil {
+		return err
+	}
+
+	// restores by expiration, so that the oldest captchas are evicted first.
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Expiration < records[j].Expiration
+	})
+	now := s.now().UnixNano()
+	for _, r := range records {
+		if now > r.Expiration {
+			continue
+		}
+		sh := s.getShard(r.ID)
+		sh.mu.Lock()
+		it := newItem(r.ID, r.Answer, r.Expiration)
+		it.metadata = r.Metadata
+		// captchas that exceed the memory limit are skipped.
+		s.set(sh, it)
+		sh.mu.Unlock()
+	}
+	return nil
+}
+
+// restore reads the records from the given file.
+func restore(path string) ([]record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []record
+	if err = gob.NewDecoder(f).Decode(&records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memstore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestStorePersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "captchas.gob")
+	s := New().(*store)
+	s.Set("foo", "bar")
+	s.Set("fizz", "buzz")
+	s.getShard("expired").set(&item{id: "expired", answer: "expired", expiration: time.Now().Add(-time.Second).UnixNano()})
+	if err := s.Persist(path); err != nil {
+		t.Fatalf("failed to persist: %s", err)
+	}
+
+	restored := New()
+	if err := restored.(Persister).Restore(path); err != nil {
+		t.Fatalf("failed to restore: %s", err)
+	}
+	for id, answer := range map[string]string{"foo": "bar", "fizz": "buzz"} {
+		if value, err := restored.Get(id, false); err != nil || value != answer {
+			t.Errorf("expected value %q, got %q, %v", answer, value, err)
+		}
+	}
+	if _, err := restored.Get("expired", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	// temporary files are removed.
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected %d file, got %d", 1, len(entries))
+	}
+}
+
+func TestStoreRestore(t *testing.T) {
+	dir := t.TempDir()
+	s := New().(*store)
+	if err := s.Restore(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %v", err)
+	}
+
+	path := filepath.Join(dir, "invalid")
+	os.WriteFile(path, []byte("invalid"), 0600)
+	if err := s.Restore(path); err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+
+	if err := s.Persist(filepath.Join(dir, "missing", "file")); err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
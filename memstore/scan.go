@@ -0,0 +1,42 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memstore
+
+import (
+	"sort"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+// Scan implements ScanStore.Scan, captchas are listed in order of ID, and
+// the cursor is the last ID of the previous page.
+func (s *store) Scan(cursor string, limit int) ([]captchas.Entry, string, error) {
+	now := s.now().UnixNano()
+	var entries []captchas.Entry
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		for id, it := range sh.items {
+			if id <= cursor || now > it.expiration {
+				continue
+			}
+			entry := captchas.Entry{ID: id, Expiration: time.Unix(0, it.expiration)}
+			if it.metadata != nil {
+				entry.Metadata = *it.metadata
+			}
+			entries = append(entries, entry)
+		}
+		sh.mu.RUnlock()
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	if limit <= 0 || len(entries) <= limit {
+		return entries, "", nil
+	}
+	entries = entries[:limit]
+	return entries, entries[limit-1].ID, nil
+}
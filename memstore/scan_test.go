@@ -0,0 +1,42 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memstore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestStoreScan(t *testing.T) {
+	s := New().(captchas.ScanStore)
+	for _, id := range []string{"c", "a", "d", "b"} {
+		s.Set(id, "answer")
+	}
+	captchas.SetWithTTL(s, "expired", "answer", -time.Second)
+
+	var ids []string
+	cursor := ""
+	for {
+		entries, next, err := s.Scan(cursor, 3)
+		if err != nil {
+			t.Fatalf("failed to scan: %s", err)
+		}
+		for _, entry := range entries {
+			if entry.Expiration.Before(time.Now()) {
+				t.Errorf("unexpected expiration %s", entry.Expiration)
+			}
+			ids = append(ids, entry.ID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if len(ids) != 4 || ids[0] != "a" || ids[3] != "d" {
+		t.Errorf("unexpected captchas %v", ids)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memstore
+
+import "sync/atomic"
+
+// Stats is the statistics of a memory store.
+type Stats struct {
+	// Items is the number of captchas, including expired captchas that
+	// haven't been collected yet.
+	Items int
+	// Hits is the number of lookups that found the captcha.
+	Hits uint64
+	// Misses is the number of lookups that didn't find the captcha, or
+	// found an expired one.
+	Misses uint64
+	// Expirations is the number of expired captchas deleted by garbage
+	// collection.
+	Expirations uint64
+	// Evictions is the number of captchas evicted by MaxItems and
+	// MaxMemory.
+	Evictions uint64
+	// Bytes is the approximate memory of captchas.
+	Bytes int
+}
+
+// StatsReporter is implemented by memory stores.
+//
+//	stats := store.(memstore.StatsReporter).Stats()
+type StatsReporter interface {
+	Stats() Stats
+}
+
+type counters struct {
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	expirations atomic.Uint64
+	evictions   atomic.Uint64
+}
+
+// Stats implements StatsReporter.Stats.
+func (s *store) Stats() Stats {
+	stats := Stats{
+		Hits:        s.stats.hits.Load(),
+		Misses:      s.stats.misses.Load(),
+		Expirations: s.stats.expirations.Load(),
+		Evictions:   s.stats.evictions.Load(),
+	}
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		stats.Items += len(sh.items)
+		sh.mu.RUnlock()
+	}
+	stats.Bytes = int(s.usage.bytes.Load())
+	return stats
+}
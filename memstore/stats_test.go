@@ -0,0 +1,32 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memstore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStoreStats(t *testing.T) {
+	s := New(Shards(1), MaxItems(2))
+	s.Set("foo", "bar")
+	s.Set("fizz", "buzz")
+	s.Set("expired", "expired")
+	s.Get("fizz", false)
+	s.Get("fizz", true)
+	s.Get("fizz", true)
+
+	mem := s.(*store)
+	sh := mem.shards[0]
+	sh.items["expired"].expiration = time.Now().Add(-time.Second).UnixNano()
+	s.Get("expired", false)
+	mem.deleteExpired()
+
+	stats := s.(StatsReporter).Stats()
+	expected := Stats{Items: 0, Hits: 2, Misses: 2, Expirations: 1, Evictions: 1}
+	if stats != expected {
+		t.Errorf("expected stats %+v, got %+v", expected, stats)
+	}
+}
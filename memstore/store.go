@@ -5,8 +5,13 @@
 package memstore
 
 import (
+	"container/heap"
+	"errors"
+	"math/rand/v2"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unsafe"
 
 	"github.com/clevergo/captchas"
 )
@@ -21,117 +26,523 @@ func Expiration(expiration time.Duration) Option {
 	}
 }
 
-// GCInterval sets garbage collection .
+// GCInterval sets garbage collection interval, a non-positive interval
+// disables the garbage collection goroutine, expired captchas are deleted
+// lazily on access and on each Set then, see also SweepOnSet.
 func GCInterval(interval time.Duration) Option {
 	return func(s *store) {
 		s.gcInterval = interval
 	}
 }
 
+// SweepOnSet enables or disables deleting expired captchas of the shard on
+// each Set, it bounds the memory without the garbage collection goroutine.
+// Defaults to true if the garbage collection is disabled, otherwise false.
+func SweepOnSet(v bool) Option {
+	return func(s *store) {
+		s.sweepOnSet = &v
+	}
+}
+
+// Shards sets the number of shards, each shard has its own lock, so that
+// concurrent operations on different shards don't contend with each other.
+func Shards(n int) Option {
+	return func(s *store) {
+		s.shardCount = n
+	}
+}
+
+// MaxItems sets the maximum number of captchas of all shards, the oldest
+// captchas of the shard being written are evicted first once the limit is
+// reached, then the oldest ones of the others. Zero means no limit.
+func MaxItems(n int) Option {
+	return func(s *store) {
+		s.maxItems = n
+	}
+}
+
+// MaxMemory sets the approximate maximum bytes of captchas of all shards,
+// the oldest captchas are evicted once the limit is reached as MaxItems
+// does, and a captcha that exceeds the limit by itself is rejected with
+// ErrTooLarge. Zero means no limit.
+func MaxMemory(bytes int) Option {
+	return func(s *store) {
+		s.maxMemory = bytes
+	}
+}
+
+// ErrTooLarge is returned when saving a captcha that exceeds the memory
+// limit, see MaxMemory.
+var ErrTooLarge = errors.New("memstore: captcha exceeds the memory limit")
+
+// ReadOptimized enables or disables the sync.Map based implementation, which
+// is tuned for read-mostly workloads. It supports Expiration, GCInterval and
+// Clock options only, the others, such as MaxItems, are ignored, so the
+// store is unbounded. The persistence, draining, statistics and metadata
+// APIs are available as well.
+func ReadOptimized(v bool) Option {
+	return func(s *store) {
+		s.readOptimized = v
+	}
+}
+
+// Clock sets the function that returns the current time, defaults to
+// time.Now, it is useful for testing expiration without sleeping.
+func Clock(now func() time.Time) Option {
+	return func(s *store) {
+		s.now = now
+	}
+}
+
 type item struct {
+	id         string
 	expiration int64
 	answer     string
+	metadata   *captchas.Metadata
+	attempts   int
+	// prev and next link items by insertion order.
+	prev, next *item
+	index      int
+	bytes      int
+}
+
+// itemOverhead approximates the memory of an item besides its strings,
+// including the map entry and the heap slot.
+const itemOverhead = int(unsafe.Sizeof(item{})) + 48
+
+// size returns the approximate memory of the item.
+func (it *item) size() int {
+	n := itemOverhead + len(it.id) + len(it.answer)
+	if md := it.metadata; md != nil {
+		n += int(unsafe.Sizeof(*md)) + len(md.Driver) + len(md.ClientIP)
+		for k, v := range md.Labels {
+			n += len(k) + len(v)
+		}
+	}
+	return n
+}
+
+// itemPool recycles items to reduce allocations at high captcha volumes.
+var itemPool = sync.Pool{
+	New: func() interface{} {
+		return new(item)
+	},
+}
+
+func newItem(id, answer string, expiration int64) *item {
+	it := itemPool.Get().(*item)
+	it.id = id
+	it.answer = answer
+	it.expiration = expiration
+	return it
+}
+
+// release resets the item and puts it back to the pool, the item must not
+// be referenced anymore.
+func release(it *item) {
+	*it = item{}
+	itemPool.Put(it)
+}
+
+// expiry is a min-heap of items ordered by expiration.
+type expiry []*item
+
+func (h expiry) Len() int { return len(h) }
+
+func (h expiry) Less(i, j int) bool { return h[i].expiration < h[j].expiration }
+
+func (h expiry) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiry) Push(x interface{}) {
+	it := x.(*item)
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *expiry) Pop() interface{} {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return it
+}
+
+// usage counts the items and bytes of all shards.
+type usage struct {
+	items atomic.Int64
+	bytes atomic.Int64
+}
+
+type shard struct {
+	mu    sync.RWMutex
+	items map[string]*item
+	usage *usage
+	// oldest and newest are the ends of the list of items by insertion
+	// order, the list is intrusive, so that it doesn't allocate.
+	oldest, newest *item
+	expiry         expiry
 }
 
 type store struct {
-	mu         *sync.RWMutex
-	expiration time.Duration
-	gcInterval time.Duration
-	items      map[string]*item
+	expiration    time.Duration
+	gcInterval    time.Duration
+	sweepOnSet    *bool
+	readOptimized bool
+	maxMemory     int
+	shardCount    int
+	maxItems      int
+	shards        []*shard
+	usage         usage
+	stats         counters
+	now           func() time.Time
+	done          chan struct{}
+	closeOnce     sync.Once
 }
 
 // New returns a memory store.
 func New(opts ...Option) captchas.Store {
 	s := &store{
-		mu:         &sync.RWMutex{},
 		expiration: 10 * time.Minute,
 		gcInterval: time.Minute,
-		items:      make(map[string]*item),
+		shardCount: 32,
+		now:        time.Now,
+		done:       make(chan struct{}),
 	}
 
 	for _, f := range opts {
 		f(s)
 	}
 
-	go s.gc()
+	if s.readOptimized {
+		return newSyncStore(s)
+	}
+
+	if s.sweepOnSet == nil {
+		sweep := s.gcInterval <= 0
+		s.sweepOnSet = &sweep
+	}
+	if s.shardCount < 1 {
+		s.shardCount = 1
+	}
+	s.shards = make([]*shard, s.shardCount)
+	for i := range s.shards {
+		s.shards[i] = &shard{
+			items: make(map[string]*item),
+			usage: &s.usage,
+		}
+	}
+
+	if s.gcInterval > 0 {
+		go s.gc()
+	}
 
 	return s
 }
 
+// getShard returns the shard of the given ID by FNV-1a hash.
+func (s *store) getShard(id string) *shard {
+	h := uint32(2166136261)
+	for i := 0; i < len(id); i++ {
+		h ^= uint32(id[i])
+		h *= 16777619
+	}
+	return s.shards[h%uint32(len(s.shards))]
+}
+
 // Get implements Store.Get.
 func (s *store) Get(id string, clear bool) (string, error) {
+	answer, err := s.get(id, clear)
+	if err != nil {
+		s.stats.misses.Add(1)
+	} else {
+		s.stats.hits.Add(1)
+	}
+	return answer, err
+}
+
+func (s *store) get(id string, clear bool) (string, error) {
+	sh := s.getShard(id)
+	now := s.now().UnixNano()
 	if clear {
-		item, err := s.getAndDel(id)
+		sh.mu.Lock()
+		defer sh.mu.Unlock()
+		item, err := s.lookup(sh, id, now)
 		if err != nil {
 			return "", err
 		}
-		return item.answer, nil
+		// the item is released on removal.
+		answer := item.answer
+		sh.remove(item)
+		return answer, nil
 	}
 
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	item, err := s.get(id)
-	if err != nil {
-		return "", err
+	sh.mu.RLock()
+	item, err := sh.get(id, now)
+	answer := ""
+	if err == nil {
+		answer = item.answer
 	}
-	return item.answer, nil
+	sh.mu.RUnlock()
+	if err == captchas.ErrExpiredCaptcha {
+		s.expire(sh, id, now)
+	}
+	return answer, err
+}
+
+// expire deletes the captcha if it is expired, expired captchas found by
+// read-only lookups are deleted by it, since the shard is read-locked then.
+func (s *store) expire(sh *shard, id string, now int64) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	s.lookup(sh, id, now)
 }
 
-func (s *store) get(id string) (*item, error) {
-	item, ok := s.items[id]
+// lookup returns the item of the write-locked shard, and deletes it if it
+// is expired.
+func (s *store) lookup(sh *shard, id string, now int64) (*item, error) {
+	it, err := sh.get(id, now)
+	if err == captchas.ErrExpiredCaptcha {
+		sh.remove(sh.items[id])
+		s.stats.expirations.Add(1)
+	}
+	return it, err
+}
+
+func (sh *shard) get(id string, now int64) (*item, error) {
+	item, ok := sh.items[id]
 	if !ok {
 		return nil, captchas.ErrIncorrectCaptcha
 	}
-	if time.Now().UnixNano() > item.expiration {
+	if now > item.expiration {
 		return nil, captchas.ErrExpiredCaptcha
 	}
 
 	return item, nil
 }
 
-func (s *store) getAndDel(id string) (*item, error) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// set saves the item, replaces the existing one of the same ID.
+func (sh *shard) set(it *item) {
+	if old, ok := sh.items[it.id]; ok {
+		sh.remove(old)
+	}
+	it.bytes = it.size()
+	sh.push(it)
+	heap.Push(&sh.expiry, it)
+	sh.items[it.id] = it
+	sh.usage.items.Add(1)
+	sh.usage.bytes.Add(int64(it.bytes))
+}
 
-	item, err := s.get(id)
-	if err != nil {
-		return nil, err
+// remove deletes the item and releases it.
+func (sh *shard) remove(it *item) {
+	sh.unlink(it)
+	heap.Remove(&sh.expiry, it.index)
+	delete(sh.items, it.id)
+	sh.usage.items.Add(-1)
+	sh.usage.bytes.Add(-int64(it.bytes))
+	release(it)
+}
+
+// push appends the item to the list as the newest one.
+func (sh *shard) push(it *item) {
+	it.prev = sh.newest
+	if sh.newest != nil {
+		sh.newest.next = it
+	} else {
+		sh.oldest = it
 	}
+	sh.newest = it
+}
 
-	delete(s.items, id)
+func (sh *shard) unlink(it *item) {
+	if it.prev != nil {
+		it.prev.next = it.next
+	} else {
+		sh.oldest = it.next
+	}
+	if it.next != nil {
+		it.next.prev = it.prev
+	} else {
+		sh.newest = it.prev
+	}
+}
 
-	return item, err
+// deleteExpired pops expired items from the heap, so that the cost is
+// proportional to the number of expired items, returns the number of
+// deleted items.
+func (sh *shard) deleteExpired(now int64) (n int) {
+	for len(sh.expiry) > 0 && now > sh.expiry[0].expiration {
+		sh.remove(sh.expiry[0])
+		n++
+	}
+	return
 }
 
 // Set implements Store.Set.
 func (s *store) Set(id, answer string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.items[id] = &item{
-		expiration: time.Now().Add(s.expiration).UnixNano(),
-		answer:     answer,
+	return s.SetWithTTL(id, answer, s.expiration)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	sh := s.getShard(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return s.set(sh, newItem(id, answer, s.now().Add(ttl).UnixNano()))
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *store) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		ttl = s.expiration
+	}
+	sh := s.getShard(id)
+	now := s.now()
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if _, err := sh.get(id, now.UnixNano()); err == nil {
+		return false, nil
+	}
+	if err := s.set(sh, newItem(id, answer, now.Add(ttl).UnixNano())); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	sh := s.getShard(id)
+	now := s.now()
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	it, err := s.lookup(sh, id, now.UnixNano())
+	if err != nil {
+		return err
 	}
+	it.expiration = now.Add(ttl).UnixNano()
+	heap.Fix(&sh.expiry, it.index)
 	return nil
 }
 
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	sh := s.getShard(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if it, ok := sh.items[id]; ok {
+		sh.remove(it)
+	}
+	return nil
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (s *store) Attempt(id string) (int, error) {
+	sh := s.getShard(id)
+	now := s.now().UnixNano()
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	it, err := s.lookup(sh, id, now)
+	if err != nil {
+		return 0, err
+	}
+	it.attempts++
+	return it.attempts, nil
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *store) Exists(id string) (bool, error) {
+	sh := s.getShard(id)
+	now := s.now().UnixNano()
+	sh.mu.RLock()
+	_, err := sh.get(id, now)
+	sh.mu.RUnlock()
+	if err == captchas.ErrExpiredCaptcha {
+		s.expire(sh, id, now)
+	}
+	return err == nil, nil
+}
+
+// set saves the item to the locked shard.
+func (s *store) set(sh *shard, it *item) error {
+	size := it.size()
+	if s.maxMemory > 0 && size > s.maxMemory {
+		release(it)
+		return ErrTooLarge
+	}
+	if *s.sweepOnSet {
+		s.stats.expirations.Add(uint64(sh.deleteExpired(s.now().UnixNano())))
+	}
+	if old, ok := sh.items[it.id]; ok {
+		sh.remove(old)
+	}
+	if s.maxItems > 0 || s.maxMemory > 0 {
+		s.stats.evictions.Add(uint64(s.evict(sh, size)))
+	}
+	sh.set(it)
+	return nil
+}
+
+// evict evicts the oldest items until there is room for a new item of the
+// given size, the locked shard goes first, then the others that are not
+// locked, so that it never waits for other shards. Returns the number of
+// evicted items.
+func (s *store) evict(sh *shard, size int) (n int) {
+	full := func() bool {
+		return (s.maxItems > 0 && s.usage.items.Load() >= int64(s.maxItems)) ||
+			(s.maxMemory > 0 && s.usage.bytes.Load()+int64(size) > int64(s.maxMemory))
+	}
+	for full() && sh.oldest != nil {
+		sh.remove(sh.oldest)
+		n++
+	}
+	start := rand.IntN(len(s.shards))
+	for i := 0; full() && i < len(s.shards); i++ {
+		other := s.shards[(start+i)%len(s.shards)]
+		if other == sh || !other.mu.TryLock() {
+			continue
+		}
+		for full() && other.oldest != nil {
+			other.remove(other.oldest)
+			n++
+		}
+		other.mu.Unlock()
+	}
+	return
+}
+
 func (s *store) gc() {
 	ticker := time.NewTicker(s.gcInterval)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
 			s.deleteExpired()
+		case <-s.done:
+			return
 		}
 	}
 }
 
-func (s *store) deleteExpired() {
-	now := time.Now().UnixNano()
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// Close stops the garbage collection.
+func (s *store) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
 
-	for id, item := range s.items {
-		if now > item.expiration {
-			delete(s.items, id)
-		}
+// deleteExpired locks shards one by one, so that the others remain available.
+func (s *store) deleteExpired() {
+	now := s.now().UnixNano()
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		n := sh.deleteExpired(now)
+		sh.mu.Unlock()
+		s.stats.expirations.Add(uint64(n))
 	}
 }
@@ -5,9 +5,14 @@
 package memstore
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/clevergo/captchas"
 )
 
 func TestNew(t *testing.T) {
@@ -51,26 +56,439 @@ func TestStoreGet(t *testing.T) {
 	}
 }
 
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func TestClock(t *testing.T) {
+	clock := &testClock{now: time.Unix(1000, 0)}
+	s := New(Clock(clock.Now), Expiration(time.Minute))
+	s.Set("foo", "bar")
+	if item := s.(*store).getShard("foo").items["foo"]; item.expiration != time.Unix(1060, 0).UnixNano() {
+		t.Errorf("expected expiration %d, got %d", time.Unix(1060, 0).UnixNano(), item.expiration)
+	}
+
+	clock.Add(time.Minute)
+	if value, err := s.Get("foo", false); err != nil || value != "bar" {
+		t.Errorf("expected value %q, got %q, %v", "bar", value, err)
+	}
+	clock.Add(time.Nanosecond)
+	if _, err := s.Get("foo", false); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+}
+
 func TestStoreDeleteExpired(t *testing.T) {
-	s := &store{
-		mu: &sync.RWMutex{},
-		items: map[string]*item{
-			"expired": {int64(0), "expired"},
-			"active":  {time.Now().Add(time.Second).UnixNano(), "active"},
-		},
+	clock := &testClock{now: time.Now()}
+	s := New(Shards(2), Clock(clock.Now)).(*store)
+	for _, it := range []*item{
+		{id: "expired", expiration: 0, answer: "expired"},
+		{id: "active", expiration: clock.now.Add(time.Second).UnixNano(), answer: "active"},
+	} {
+		s.getShard(it.id).set(it)
 	}
 
 	s.deleteExpired()
-	if len(s.items) != 1 {
-		t.Errorf("expected items count %d, got %d", 1, len(s.items))
+	if count := s.count(); count != 1 {
+		t.Errorf("expected items count %d, got %d", 1, count)
 	}
-	if _, ok := s.items["expired"]; ok {
+	if _, ok := s.getShard("expired").items["expired"]; ok {
 		t.Errorf("expected item %q to be deleted", "expired")
 	}
 
-	time.Sleep(time.Second)
+	clock.Add(2 * time.Second)
 	s.deleteExpired()
-	if len(s.items) != 0 {
-		t.Errorf("expected items count %d, got %d", 0, len(s.items))
+	if count := s.count(); count != 0 {
+		t.Errorf("expected items count %d, got %d", 0, count)
+	}
+}
+
+func (s *store) count() int {
+	n := 0
+	for _, sh := range s.shards {
+		n += len(sh.items)
+	}
+	return n
+}
+
+// len returns the length of the list of items.
+func (sh *shard) len() (n int) {
+	for it := sh.oldest; it != nil; it = it.next {
+		n++
+	}
+	return
+}
+
+func TestShards(t *testing.T) {
+	for _, n := range []int{-1, 0, 1, 16} {
+		s := New(Shards(n)).(*store)
+		expected := n
+		if expected < 1 {
+			expected = 1
+		}
+		if len(s.shards) != expected {
+			t.Errorf("expected %d shards, got %d", expected, len(s.shards))
+		}
+	}
+
+	s := New(Shards(4)).(*store)
+	for i := 0; i < 100; i++ {
+		s.Set(fmt.Sprintf("foo%d", i), "bar")
+	}
+	for i, sh := range s.shards {
+		if len(sh.items) == 0 {
+			t.Errorf("expected shard %d to be used", i)
+		}
+	}
+}
+
+func TestShardDeleteExpired(t *testing.T) {
+	sh := New(Shards(1)).(*store).shards[0]
+	for _, expiration := range []int64{5, 1, 4, 2, 3} {
+		sh.set(&item{id: strconv.FormatInt(expiration, 10), expiration: expiration})
+	}
+	// replacing updates the expiration.
+	sh.set(&item{id: "4", expiration: 6})
+
+	sh.deleteExpired(3)
+	if len(sh.items) != 3 || len(sh.expiry) != 3 || sh.len() != 3 {
+		t.Fatalf("expected items count %d, got %d, %d, %d", 3, len(sh.items), len(sh.expiry), sh.len())
+	}
+	for _, id := range []string{"3", "4", "5"} {
+		if _, ok := sh.items[id]; !ok {
+			t.Errorf("expected item %q to be kept", id)
+		}
+	}
+
+	sh.deleteExpired(6)
+	if _, ok := sh.items["4"]; !ok || len(sh.items) != 1 {
+		t.Errorf("expected item %q to be kept only, got %d items", "4", len(sh.items))
+	}
+}
+
+func TestMaxItems(t *testing.T) {
+	s := New(Shards(1), MaxItems(3)).(*store)
+	for i := 0; i < 5; i++ {
+		s.Set(fmt.Sprintf("foo%d", i), "bar")
+	}
+	// replacing doesn't evict.
+	s.Set("foo2", "baz")
+	if count := s.count(); count != 3 {
+		t.Errorf("expected items count %d, got %d", 3, count)
+	}
+	for i := 0; i < 5; i++ {
+		_, err := s.Get(fmt.Sprintf("foo%d", i), false)
+		if i < 2 && err != captchas.ErrIncorrectCaptcha {
+			t.Errorf("expected foo%d to be evicted, got %v", i, err)
+		}
+		if i >= 2 && err != nil {
+			t.Errorf("expected non error, got %s", err)
+		}
+	}
+
+	// the oldest one is foo3 after replacing foo2.
+	s.Get("foo4", true)
+	s.Set("foo5", "bar")
+	s.Set("foo6", "bar")
+	if _, err := s.Get("foo3", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected foo3 to be evicted, got %v", err)
+	}
+	if value, _ := s.Get("foo2", false); value != "baz" {
+		t.Errorf("expected value %q, got %q", "baz", value)
+	}
+
+	// the limit applies to all shards.
+	s = New(Shards(4), MaxItems(10)).(*store)
+	for i := 0; i < 100; i++ {
+		s.Set(fmt.Sprintf("foo%d", i), "bar")
+		if count := s.count(); count > 10 {
+			t.Fatalf("expected at most %d items, got %d", 10, count)
+		}
+	}
+	if count := s.count(); count != 10 {
+		t.Errorf("expected items count %d, got %d", 10, count)
+	}
+	if stats := s.Stats(); stats.Evictions != 90 {
+		t.Errorf("expected %d evictions, got %d", 90, stats.Evictions)
+	}
+}
+
+func TestStoreConcurrency(t *testing.T) {
+	s := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				id := fmt.Sprintf("foo%d-%d", i, j)
+				s.Set(id, "bar")
+				if value, err := s.Get(id, true); err != nil || value != "bar" {
+					t.Errorf("expected value %q, got %q, %v", "bar", value, err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func BenchmarkStoreParallel(b *testing.B) {
+	s := New()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			id := strconv.Itoa(i)
+			s.Set(id, "bar")
+			s.Get(id, true)
+			i++
+		}
+	})
+}
+
+func BenchmarkStoreSetGet(b *testing.B) {
+	s := New(MaxItems(10000))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		id := strconv.Itoa(i)
+		s.Set(id, "answer")
+		s.Get(id, true)
+	}
+}
+
+func TestStoreClose(t *testing.T) {
+	s := New(GCInterval(time.Millisecond))
+	mem, _ := s.(*store)
+	for i := 0; i < 2; i++ {
+		if err := mem.Close(); err != nil {
+			t.Fatalf("failed to close: %s", err)
+		}
+	}
+	select {
+	case <-mem.done:
+	default:
+		t.Error("expected the done channel to be closed")
+	}
+}
+
+func TestStoreSetWithTTL(t *testing.T) {
+	s := New().(captchas.TTLStore)
+	if err := s.SetWithTTL("foo", "bar", -time.Second); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if _, err := s.Get("foo", false); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+	if err := s.SetWithTTL("foo", "bar", time.Minute); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if value, err := s.Get("foo", true); err != nil || value != "bar" {
+		t.Errorf("expected value %q, got %q, %v", "bar", value, err)
+	}
+}
+
+func TestStoreSetIfNotExists(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	s := New(Clock(clock.Now), Expiration(time.Minute)).(captchas.NXStore)
+	if ok, err := s.SetIfNotExists("foo", "bar", 0); err != nil || !ok {
+		t.Fatalf("expected to be saved, got %t, %v", ok, err)
+	}
+	if ok, err := s.SetIfNotExists("foo", "baz", 0); err != nil || ok {
+		t.Errorf("expected not to be saved, got %t, %v", ok, err)
+	}
+	if value, _ := s.Get("foo", false); value != "bar" {
+		t.Errorf("expected value %q, got %q", "bar", value)
+	}
+
+	clock.Add(2 * time.Minute)
+	if ok, err := s.SetIfNotExists("foo", "baz", time.Second); err != nil || !ok {
+		t.Errorf("expected expired captcha to be replaced, got %t, %v", ok, err)
+	}
+	if value, _ := s.Get("foo", false); value != "baz" {
+		t.Errorf("expected value %q, got %q", "baz", value)
+	}
+}
+
+func TestStoreTouch(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	s := New(Clock(clock.Now), Expiration(time.Minute)).(captchas.TouchStore)
+	if err := s.Touch("foo", time.Minute); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	s.Set("foo", "bar")
+	clock.Add(50 * time.Second)
+	if err := s.Touch("foo", time.Minute); err != nil {
+		t.Fatalf("failed to touch: %s", err)
+	}
+	clock.Add(50 * time.Second)
+	if value, err := s.Get("foo", false); err != nil || value != "bar" {
+		t.Errorf("expected value %q, got %q, %v", "bar", value, err)
+	}
+	clock.Add(time.Minute)
+	if err := s.Touch("foo", time.Minute); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+}
+
+func TestStoreDelete(t *testing.T) {
+	s := New()
+	if err := captchas.Delete(s, "foo"); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	s.Set("foo", "bar")
+	if err := captchas.Delete(s, "foo"); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if _, err := s.Get("foo", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
+
+func TestStoreExists(t *testing.T) {
+	s := New()
+	if ok, err := captchas.Exists(s, "foo"); err != nil || ok {
+		t.Errorf("expected false, got %t, %v", ok, err)
+	}
+	captchas.SetWithTTL(s, "foo", "bar", -time.Second)
+	if ok, err := captchas.Exists(s, "foo"); err != nil || ok {
+		t.Errorf("expected false, got %t, %v", ok, err)
+	}
+	s.Set("foo", "bar")
+	if ok, err := captchas.Exists(s, "foo"); err != nil || !ok {
+		t.Errorf("expected true, got %t, %v", ok, err)
+	}
+}
+
+func TestStoreAttempt(t *testing.T) {
+	s := New().(captchas.AttemptStore)
+	if _, err := s.Attempt("foo"); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	s.Set("foo", "bar")
+	for i := 1; i <= 3; i++ {
+		if n, err := s.Attempt("foo"); err != nil || n != i {
+			t.Errorf("expected attempts %d, got %d, %v", i, n, err)
+		}
+	}
+	s.Set("foo", "bar")
+	if n, _ := s.Attempt("foo"); n != 1 {
+		t.Errorf("expected attempts to be reset, got %d", n)
+	}
+}
+
+func TestLazyExpiration(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	s := New(GCInterval(0), SweepOnSet(true), Shards(1), Clock(clock.Now), Expiration(time.Minute)).(*store)
+	defer s.Close()
+	s.Set("foo", "bar")
+	clock.Add(2 * time.Minute)
+	s.Set("fizz", "buzz")
+	if n := s.count(); n != 1 {
+		t.Errorf("expected expired captchas to be swept on set, got %d captchas", n)
+	}
+
+	// sweeps on set by default if the garbage collection is disabled.
+	s = New(GCInterval(0), Shards(1), Clock(clock.Now), Expiration(time.Minute)).(*store)
+	s.Set("foo", "bar")
+	clock.Add(2 * time.Minute)
+	s.Set("fizz", "buzz")
+	if n := s.count(); n != 1 {
+		t.Errorf("expected expired captchas to be swept on set, got %d captchas", n)
+	}
+	if *New(GCInterval(0), SweepOnSet(false)).(*store).sweepOnSet || *New().(*store).sweepOnSet {
+		t.Error("unexpected default of sweeping on set")
+	}
+
+	// every read path deletes the expired captcha.
+	reads := map[string]func(s *store, id string) error{
+		"get": func(s *store, id string) error {
+			_, err := s.Get(id, false)
+			return err
+		},
+		"consume": func(s *store, id string) error {
+			_, err := s.Get(id, true)
+			return err
+		},
+		"exists": func(s *store, id string) error {
+			if ok, _ := s.Exists(id); ok {
+				return nil
+			}
+			return captchas.ErrExpiredCaptcha
+		},
+		"attempt": func(s *store, id string) error {
+			_, err := s.Attempt(id)
+			return err
+		},
+		"touch": func(s *store, id string) error {
+			return s.Touch(id, time.Minute)
+		},
+	}
+	for name, read := range reads {
+		s = New(GCInterval(0), SweepOnSet(false), Clock(clock.Now), Expiration(time.Minute)).(*store)
+		s.Set("foo", "bar")
+		clock.Add(2 * time.Minute)
+		if err := read(s, "foo"); err != captchas.ErrExpiredCaptcha {
+			t.Errorf("%s: expected error %v, got %v", name, captchas.ErrExpiredCaptcha, err)
+		}
+		if n := s.count(); n != 0 {
+			t.Errorf("%s: expected expired captcha to be deleted on access, got %d captchas", name, n)
+		}
+		if stats := s.Stats(); stats.Expirations != 1 {
+			t.Errorf("%s: expected %d expirations, got %d", name, 1, stats.Expirations)
+		}
+	}
+}
+
+func TestMaxMemory(t *testing.T) {
+	size := (&item{id: "foo0", answer: "bar"}).size()
+	s := New(Shards(1), MaxMemory(3*size)).(*store)
+	for i := 0; i < 4; i++ {
+		if err := s.Set("foo"+strconv.Itoa(i), "bar"); err != nil {
+			t.Fatalf("failed to set: %s", err)
+		}
+	}
+	if _, err := s.Get("foo0", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected foo0 to be evicted, got %v", err)
+	}
+	stats := s.Stats()
+	if stats.Items != 3 || stats.Bytes != 3*size || stats.Evictions != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	if err := s.Set("foo1", "bar"); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if stats = s.Stats(); stats.Bytes != 3*size {
+		t.Errorf("expected bytes %d, got %d", 3*size, stats.Bytes)
+	}
+	s.Get("foo1", true)
+	if stats = s.Stats(); stats.Bytes != 2*size {
+		t.Errorf("expected bytes %d, got %d", 2*size, stats.Bytes)
+	}
+
+	if err := s.Set("large", strings.Repeat("a", 3*size)); err != ErrTooLarge {
+		t.Errorf("expected error %v, got %v", ErrTooLarge, err)
+	}
+
+	// a budget smaller than one item per shard still holds items.
+	s = New(Shards(32), MaxMemory(2*size)).(*store)
+	for i := 0; i < 10; i++ {
+		if err := s.Set("foo"+strconv.Itoa(i), "bar"); err != nil {
+			t.Fatalf("failed to set: %s", err)
+		}
+		if stats := s.Stats(); stats.Bytes > 2*size {
+			t.Fatalf("expected at most %d bytes, got %d", 2*size, stats.Bytes)
+		}
+	}
+	if stats = s.Stats(); stats.Items != 2 || stats.Evictions != 8 {
+		t.Errorf("unexpected stats %+v", stats)
 	}
 }
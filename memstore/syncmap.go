@@ -0,0 +1,292 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memstore
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+type syncItem struct {
+	answer     string
+	expiration int64
+	attempts   int
+	metadata   *captchas.Metadata
+}
+
+// syncStore is a memory store based on sync.Map, see ReadOptimized. Items
+// are immutable, they are replaced by compare-and-swap, so that only one of
+// concurrent consumers is able to delete a captcha.
+type syncStore struct {
+	expiration time.Duration
+	gcInterval time.Duration
+	now        func() time.Time
+	items      sync.Map
+	stats      counters
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+func newSyncStore(opts *store) *syncStore {
+	s := &syncStore{
+		expiration: opts.expiration,
+		gcInterval: opts.gcInterval,
+		now:        opts.now,
+		done:       opts.done,
+	}
+	if s.gcInterval > 0 {
+		go s.gc()
+	}
+	return s
+}
+
+func (s *syncStore) load(id string, now int64) (*syncItem, error) {
+	v, ok := s.items.Load(id)
+	if !ok {
+		return nil, captchas.ErrIncorrectCaptcha
+	}
+	it := v.(*syncItem)
+	if now > it.expiration {
+		// deletes the expired captcha lazily.
+		if s.items.CompareAndDelete(id, it) {
+			s.stats.expirations.Add(1)
+		}
+		return it, captchas.ErrExpiredCaptcha
+	}
+	return it, nil
+}
+
+// Get implements Store.Get.
+func (s *syncStore) Get(id string, clear bool) (string, error) {
+	answer, err := s.get(id, clear)
+	if err != nil {
+		s.stats.misses.Add(1)
+	} else {
+		s.stats.hits.Add(1)
+	}
+	return answer, err
+}
+
+func (s *syncStore) get(id string, clear bool) (string, error) {
+	it, err := s.load(id, s.now().UnixNano())
+	if err != nil {
+		return "", err
+	}
+	if clear && !s.items.CompareAndDelete(id, it) {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	return it.answer, nil
+}
+
+// Set implements Store.Set.
+func (s *syncStore) Set(id, answer string) error {
+	return s.SetWithTTL(id, answer, s.expiration)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *syncStore) SetWithTTL(id, answer string, ttl time.Duration) error {
+	s.items.Store(id, &syncItem{answer: answer, expiration: s.now().Add(ttl).UnixNano()})
+	return nil
+}
+
+// SetWithMetadata implements MetadataStore.SetWithMetadata.
+func (s *syncStore) SetWithMetadata(id, answer string, ttl time.Duration, md captchas.Metadata) error {
+	if ttl <= 0 {
+		ttl = s.expiration
+	}
+	s.items.Store(id, &syncItem{answer: answer, expiration: s.now().Add(ttl).UnixNano(), metadata: &md})
+	return nil
+}
+
+// GetMetadata implements MetadataStore.GetMetadata, captchas saved without
+// metadata have zero metadata.
+func (s *syncStore) GetMetadata(id string) (captchas.Metadata, error) {
+	it, err := s.load(id, s.now().UnixNano())
+	if err != nil || it.metadata == nil {
+		return captchas.Metadata{}, err
+	}
+	return *it.metadata, nil
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *syncStore) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		ttl = s.expiration
+	}
+	now := s.now()
+	it := &syncItem{answer: answer, expiration: now.Add(ttl).UnixNano()}
+	v, loaded := s.items.LoadOrStore(id, it)
+	if !loaded {
+		return true, nil
+	}
+	if old := v.(*syncItem); now.UnixNano() > old.expiration {
+		return s.items.CompareAndSwap(id, old, it), nil
+	}
+	return false, nil
+}
+
+// Touch implements TouchStore.Touch.
+func (s *syncStore) Touch(id string, ttl time.Duration) error {
+	now := s.now()
+	it, err := s.load(id, now.UnixNano())
+	if err != nil {
+		return err
+	}
+	touched := *it
+	touched.expiration = now.Add(ttl).UnixNano()
+	if !s.items.CompareAndSwap(id, it, &touched) {
+		return captchas.ErrIncorrectCaptcha
+	}
+	return nil
+}
+
+// Attempt implements AttemptStore.Attempt, the counter is increased by
+// replacing the item until no concurrent attempt interferes.
+func (s *syncStore) Attempt(id string) (int, error) {
+	for {
+		it, err := s.load(id, s.now().UnixNano())
+		if err != nil {
+			return 0, err
+		}
+		attempted := *it
+		attempted.attempts++
+		if s.items.CompareAndSwap(id, it, &attempted) {
+			return attempted.attempts, nil
+		}
+	}
+}
+
+// Delete implements DeleteStore.Delete.
+func (s *syncStore) Delete(id string) error {
+	s.items.Delete(id)
+	return nil
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *syncStore) Exists(id string) (bool, error) {
+	_, err := s.load(id, s.now().UnixNano())
+	return err == nil, nil
+}
+
+func (s *syncStore) gc() {
+	ticker := time.NewTicker(s.gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.deleteExpired()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Close stops the garbage collection.
+func (s *syncStore) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
+
+func (s *syncStore) deleteExpired() {
+	now := s.now().UnixNano()
+	s.items.Range(func(key, value interface{}) bool {
+		if now > value.(*syncItem).expiration && s.items.CompareAndDelete(key, value) {
+			s.stats.expirations.Add(1)
+		}
+		return true
+	})
+}
+
+// records returns the unexpired captchas.
+func (s *syncStore) records(now int64) []record {
+	var records []record
+	s.items.Range(func(key, value interface{}) bool {
+		if it := value.(*syncItem); now <= it.expiration {
+			records = append(records, record{ID: key.(string), Answer: it.answer, Expiration: it.expiration, Metadata: it.metadata})
+		}
+		return true
+	})
+	return records
+}
+
+// Scan implements ScanStore.Scan, captchas are listed in order of ID, and
+// the cursor is the last ID of the previous page.
+func (s *syncStore) Scan(cursor string, limit int) ([]captchas.Entry, string, error) {
+	var entries []captchas.Entry
+	for _, r := range s.records(s.now().UnixNano()) {
+		if r.ID <= cursor {
+			continue
+		}
+		entry := captchas.Entry{ID: r.ID, Expiration: time.Unix(0, r.Expiration)}
+		if r.Metadata != nil {
+			entry.Metadata = *r.Metadata
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	if limit <= 0 || len(entries) <= limit {
+		return entries, "", nil
+	}
+	entries = entries[:limit]
+	return entries, entries[limit-1].ID, nil
+}
+
+// Stats implements StatsReporter.Stats, there are no evictions since the
+// store is unbounded.
+func (s *syncStore) Stats() Stats {
+	stats := Stats{
+		Hits:        s.stats.hits.Load(),
+		Misses:      s.stats.misses.Load(),
+		Expirations: s.stats.expirations.Load(),
+	}
+	s.items.Range(func(key, value interface{}) bool {
+		it := value.(*syncItem)
+		stats.Items++
+		stats.Bytes += (&item{id: key.(string), answer: it.answer, metadata: it.metadata}).size()
+		return true
+	})
+	return stats
+}
+
+// Persist implements Persister.Persist.
+func (s *syncStore) Persist(path string) error {
+	return persist(path, s.records(s.now().UnixNano()))
+}
+
+// Restore implements Persister.Restore, the captchas of the same IDs are
+// replaced.
+func (s *syncStore) Restore(path string) error {
+	records, err := restore(path)
+	if err != nil {
+		return err
+	}
+	now := s.now().UnixNano()
+	for _, r := range records {
+		if now <= r.Expiration {
+			s.items.Store(r.ID, &syncItem{answer: r.Answer, expiration: r.Expiration, metadata: r.Metadata})
+		}
+	}
+	return nil
+}
+
+// DrainTo implements Drainer.DrainTo.
+func (s *syncStore) DrainTo(dst captchas.Store) (int, error) {
+	now := s.now()
+	return drain(dst, s.records(now.UnixNano()), now)
+}
+
+var (
+	_ Persister     = (*syncStore)(nil)
+	_ Drainer       = (*syncStore)(nil)
+	_ StatsReporter = (*syncStore)(nil)
+)
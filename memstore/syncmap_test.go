@@ -0,0 +1,169 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package memstore
+
+import (
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestReadOptimized(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	s, ok := New(ReadOptimized(true), Clock(clock.Now), Expiration(time.Minute)).(*syncStore)
+	if !ok {
+		t.Fatal("expected a sync.Map based store")
+	}
+	defer s.Close()
+	if _, err := s.Get("foo", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	s.Set("foo", "bar")
+	if value, err := s.Get("foo", false); err != nil || value != "bar" {
+		t.Errorf("expected value %q, got %q, %v", "bar", value, err)
+	}
+	if ok, _ := s.SetIfNotExists("foo", "baz", 0); ok {
+		t.Error("expected not to be saved")
+	}
+	if err := s.Touch("foo", 2*time.Minute); err != nil {
+		t.Errorf("failed to touch: %s", err)
+	}
+
+	clock.Add(90 * time.Second)
+	s.deleteExpired()
+	if value, err := s.Get("foo", true); err != nil || value != "bar" {
+		t.Errorf("expected value %q, got %q, %v", "bar", value, err)
+	}
+	if ok, _ := s.Exists("foo"); ok {
+		t.Error("expected the captcha to be consumed")
+	}
+
+	s.Set("foo", "bar")
+	clock.Add(2 * time.Minute)
+	if _, err := s.Get("foo", false); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+	if _, ok := s.items.Load("foo"); ok {
+		t.Error("expected expired captcha to be deleted on access")
+	}
+	s.Set("foo", "bar")
+	clock.Add(2 * time.Minute)
+	if ok, _ := s.SetIfNotExists("foo", "baz", 0); !ok {
+		t.Error("expected expired captcha to be replaced")
+	}
+	s.Delete("foo")
+	clock.Add(2 * time.Minute)
+	s.Set("fizz", "buzz")
+	clock.Add(2 * time.Minute)
+	s.deleteExpired()
+	if _, ok := s.items.Load("fizz"); ok {
+		t.Error("expected expired captcha to be deleted")
+	}
+}
+
+func TestReadOptimizedConsume(t *testing.T) {
+	s := New(ReadOptimized(true))
+	s.Set("foo", "bar")
+	var wg sync.WaitGroup
+	var consumed atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Get("foo", true); err == nil {
+				consumed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := consumed.Load(); n != 1 {
+		t.Errorf("expected the captcha to be consumed once, got %d", n)
+	}
+}
+
+func TestReadOptimizedAPIs(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	s := New(ReadOptimized(true), Clock(clock.Now)).(*syncStore)
+	defer s.Close()
+	md := captchas.Metadata{Driver: "digit", ClientIP: "127.0.0.1"}
+	s.SetWithMetadata("foo", "bar", time.Minute, md)
+	s.Set("fizz", "buzz")
+	if got, err := s.GetMetadata("foo"); err != nil || got.Driver != md.Driver || got.ClientIP != md.ClientIP {
+		t.Errorf("expected metadata %v, got %v, %v", md, got, err)
+	}
+	if got, err := s.GetMetadata("fizz"); err != nil || got.Driver != "" {
+		t.Errorf("expected zero metadata, got %v, %v", got, err)
+	}
+	for i := 1; i <= 2; i++ {
+		if n, err := s.Attempt("foo"); err != nil || n != i {
+			t.Errorf("expected attempts %d, got %d, %v", i, n, err)
+		}
+	}
+	if got, _ := s.GetMetadata("foo"); got.Driver != md.Driver {
+		t.Errorf("expected metadata %v to be kept by attempts, got %v", md, got)
+	}
+	if entries, cursor, _ := s.Scan("", 1); len(entries) != 1 || entries[0].ID != "fizz" || cursor != "fizz" {
+		t.Errorf("expected the first page of fizz, got %v, %q", entries, cursor)
+	}
+
+	path := filepath.Join(t.TempDir(), "captchas")
+	if err := s.Persist(path); err != nil {
+		t.Fatalf("failed to persist: %s", err)
+	}
+	restored := New(ReadOptimized(true), Clock(clock.Now)).(*syncStore)
+	defer restored.Close()
+	if err := restored.Restore(path); err != nil {
+		t.Fatalf("failed to restore: %s", err)
+	}
+	if got, err := restored.GetMetadata("foo"); err != nil || got.Driver != md.Driver || got.ClientIP != md.ClientIP {
+		t.Errorf("expected restored metadata %v, got %v, %v", md, got, err)
+	}
+
+	dst := New()
+	if n, err := s.DrainTo(dst); err != nil || n != 2 {
+		t.Errorf("expected 2 drained captchas, got %d, %v", n, err)
+	}
+	if value, err := dst.Get("fizz", false); err != nil || value != "buzz" {
+		t.Errorf("expected value %q, got %q, %v", "buzz", value, err)
+	}
+
+	s.Get("foo", false)
+	s.Get("missing", false)
+	clock.Add(time.Minute + time.Second)
+	s.deleteExpired()
+	stats := s.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Expirations != 1 || stats.Items != 1 || stats.Bytes == 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+// benchmarkReadMostly sets captchas that are read many times and rarely
+// consumed, it compares the sharded and the sync.Map based stores.
+func benchmarkReadMostly(b *testing.B, s captchas.Store) {
+	for i := 0; i < 1000; i++ {
+		s.Set(strconv.Itoa(i), "bar")
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			s.Get(strconv.Itoa(i%1000), false)
+			i++
+		}
+	})
+}
+
+func BenchmarkReadMostlySharded(b *testing.B) {
+	benchmarkReadMostly(b, New())
+}
+
+func BenchmarkReadMostlySyncMap(b *testing.B) {
+	benchmarkReadMostly(b, New(ReadOptimized(true)))
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package metricsstore
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type prometheusMetrics struct {
+	total   *prometheus.CounterVec
+	latency *prometheus.HistogramVec
+}
+
+// NewPrometheus returns metrics that exports the counter
+// "<namespace>_store_operations_total" and the histogram
+// "<namespace>_store_operation_duration_seconds" to the given registerer,
+// both are labeled by "op" and "result".
+func NewPrometheus(reg prometheus.Registerer, namespace string) (Metrics, error) {
+	m := &prometheusMetrics{
+		total: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "store",
+			Name:      "operations_total",
+			Help:      "Total number of captcha store operations.",
+		}, []string{"op", "result"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "store",
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of captcha store operations.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"op", "result"}),
+	}
+	for _, c := range []prometheus.Collector{m.total, m.latency} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Observe implements Metrics.Observe.
+func (m *prometheusMetrics) Observe(op string, latency time.Duration, err error) {
+	result := Result(err)
+	m.total.WithLabelValues(op, result).Inc()
+	m.latency.WithLabelValues(op, result).Observe(latency.Seconds())
+}
@@ -0,0 +1,229 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package metricsstore wraps a store and records the count, outcome and
+// latency of each operation.
+package metricsstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+// Operations.
+const (
+	OpGet     = "get"
+	OpSet     = "set"
+	OpConsume = "consume"
+	OpVerify  = "verify"
+	OpAttempt = "attempt"
+	OpDelete  = "delete"
+	OpTouch   = "touch"
+	OpExists  = "exists"
+)
+
+// Metrics records the metrics of store operations.
+type Metrics interface {
+	// Observe records an operation with its latency and error.
+	Observe(op string, latency time.Duration, err error)
+}
+
+// MetricsFunc is an adapter to allow the use of ordinary functions as metrics.
+type MetricsFunc func(op string, latency time.Duration, err error)
+
+// Observe implements Metrics.Observe.
+func (f MetricsFunc) Observe(op string, latency time.Duration, err error) {
+	f(op, latency, err)
+}
+
+// Result returns the outcome of the given error: "ok", "incorrect", "expired"
+// or "error".
+func Result(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, captchas.ErrIncorrectCaptcha):
+		return "incorrect"
+	case errors.Is(err, captchas.ErrExpiredCaptcha):
+		return "expired"
+	}
+	return "error"
+}
+
+type store struct {
+	store   captchas.Store
+	metrics Metrics
+}
+
+// New returns a store that records metrics of the given store. The returned
+// store implements captchas.Verifier or captchas.MatchVerifier if the given
+// store does.
+func New(s captchas.Store, metrics Metrics) captchas.Store {
+	ms := &store{
+		store:   s,
+		metrics: metrics,
+	}
+	if _, ok := s.(captchas.MatchVerifier); ok {
+		return &matchVerifier{ms}
+	}
+	if _, ok := s.(captchas.Verifier); ok {
+		return &verifier{ms}
+	}
+	return ms
+}
+
+// observe records the operation started at the given time.
+func (s *store) observe(op string, start time.Time, err error) {
+	s.metrics.Observe(op, time.Since(start), err)
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	op := OpGet
+	if clear {
+		op = OpConsume
+	}
+	start := time.Now()
+	answer, err := captchas.GetContext(ctx, s.store, id, clear)
+	s.observe(op, start, err)
+	return answer, err
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	start := time.Now()
+	err := captchas.SetContext(ctx, s.store, id, answer)
+	s.observe(OpSet, start, err)
+	return err
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.SetWithTTLContext(context.Background(), id, answer, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	start := time.Now()
+	err := captchas.SetWithTTLContext(ctx, s.store, id, answer, ttl)
+	s.observe(OpSet, start, err)
+	return err
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *store) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	return s.SetIfNotExistsContext(context.Background(), id, answer, ttl)
+}
+
+// SetIfNotExistsContext implements ContextNXStore.SetIfNotExistsContext.
+func (s *store) SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error) {
+	start := time.Now()
+	ok, err := captchas.SetIfNotExistsContext(ctx, s.store, id, answer, ttl)
+	s.observe(OpSet, start, err)
+	return ok, err
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (s *store) Attempt(id string) (int, error) {
+	return s.AttemptContext(context.Background(), id)
+}
+
+// AttemptContext implements ContextAttemptStore.AttemptContext.
+func (s *store) AttemptContext(ctx context.Context, id string) (int, error) {
+	start := time.Now()
+	n, err := captchas.AttemptContext(ctx, s.store, id)
+	s.observe(OpAttempt, start, err)
+	return n, err
+}
+
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext implements ContextDeleteStore.DeleteContext.
+func (s *store) DeleteContext(ctx context.Context, id string) error {
+	start := time.Now()
+	err := captchas.DeleteContext(ctx, s.store, id)
+	s.observe(OpDelete, start, err)
+	return err
+}
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	return s.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext implements ContextTouchStore.TouchContext.
+func (s *store) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
+	start := time.Now()
+	err := captchas.TouchContext(ctx, s.store, id, ttl)
+	s.observe(OpTouch, start, err)
+	return err
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *store) Exists(id string) (bool, error) {
+	return s.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext implements ContextExistsStore.ExistsContext.
+func (s *store) ExistsContext(ctx context.Context, id string) (bool, error) {
+	start := time.Now()
+	ok, err := captchas.ExistsContext(ctx, s.store, id)
+	s.observe(OpExists, start, err)
+	return ok, err
+}
+
+// Close closes the underlying store if it implements io.Closer.
+func (s *store) Close() error {
+	return captchas.Close(s.store)
+}
+
+type verifier struct {
+	*store
+}
+
+// Verify implements Verifier.Verify.
+func (v *verifier) Verify(id, actual string, clear bool) error {
+	return v.VerifyContext(context.Background(), id, actual, clear)
+}
+
+// VerifyContext implements ContextVerifier.VerifyContext.
+func (v *verifier) VerifyContext(ctx context.Context, id, actual string, clear bool) error {
+	start := time.Now()
+	err := captchas.VerifyContext(ctx, v.store.store.(captchas.Verifier), id, actual, clear)
+	v.observe(OpVerify, start, err)
+	return err
+}
+
+type matchVerifier struct {
+	*store
+}
+
+// VerifyMatch implements MatchVerifier.VerifyMatch.
+func (v *matchVerifier) VerifyMatch(id, actual string, clear bool, match func(actual, answer string) bool) error {
+	return v.VerifyMatchContext(context.Background(), id, actual, clear, match)
+}
+
+// VerifyMatchContext implements ContextMatchVerifier.VerifyMatchContext.
+func (v *matchVerifier) VerifyMatchContext(ctx context.Context, id, actual string, clear bool, match func(actual, answer string) bool) error {
+	start := time.Now()
+	err := captchas.VerifyMatchContext(ctx, v.store.store.(captchas.MatchVerifier), id, actual, clear, match)
+	v.observe(OpVerify, start, err)
+	return err
+}
@@ -0,0 +1,192 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package metricsstore
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/hashedstore"
+	"github.com/clevergo/captchas/memstore"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type observation struct {
+	op     string
+	result string
+}
+
+type testMetrics []observation
+
+func (m *testMetrics) Observe(op string, latency time.Duration, err error) {
+	*m = append(*m, observation{op, Result(err)})
+}
+
+func TestResult(t *testing.T) {
+	tests := map[error]string{
+		nil:                          "ok",
+		captchas.ErrIncorrectCaptcha: "incorrect",
+		captchas.ErrExpiredCaptcha:   "expired",
+		errors.New("unavailable"):    "error",
+	}
+	for err, expected := range tests {
+		if result := Result(err); result != expected {
+			t.Errorf("expected result %q for %v, got %q", expected, err, result)
+		}
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	metrics := &testMetrics{}
+	s := New(memstore.New(), metrics)
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	expected := []observation{
+		{OpConsume, "incorrect"},
+		{OpSet, "ok"},
+		{OpGet, "ok"},
+		{OpConsume, "ok"},
+	}
+	if len(*metrics) != len(expected) {
+		t.Fatalf("expected %d observations, got %d", len(expected), len(*metrics))
+	}
+	for i, o := range *metrics {
+		if o != expected[i] {
+			t.Errorf("expected observation %v, got %v", expected[i], o)
+		}
+	}
+}
+
+func TestStoreVerify(t *testing.T) {
+	metrics := &testMetrics{}
+	if _, ok := New(memstore.New(), metrics).(captchas.Verifier); ok {
+		t.Error("expected a non-verifier store")
+	}
+
+	s := New(hashedstore.New(memstore.New(), []byte("secret")), metrics)
+	s.Set("foo", "bar")
+	if err := s.(captchas.Verifier).Verify("foo", "baz", true); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	if o := (*metrics)[len(*metrics)-1]; o != (observation{OpVerify, "incorrect"}) {
+		t.Errorf("expected observation %v, got %v", observation{OpVerify, "incorrect"}, o)
+	}
+}
+
+func TestPrometheus(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	metrics, err := NewPrometheus(reg, "captchas")
+	if err != nil {
+		t.Fatalf("failed to create metrics: %s", err)
+	}
+	if _, err = NewPrometheus(reg, "captchas"); err == nil {
+		t.Error("expected an error for duplicate registration, got nil")
+	}
+
+	s := New(memstore.New(), metrics)
+	s.Set("foo", "bar")
+	s.Get("foo", true)
+	s.Get("foo", true)
+	expected := `
+# HELP captchas_store_operations_total Total number of captcha store operations.
+# TYPE captchas_store_operations_total counter
+captchas_store_operations_total{op="consume",result="incorrect"} 1
+captchas_store_operations_total{op="consume",result="ok"} 1
+captchas_store_operations_total{op="set",result="ok"} 1
+`
+	if err = testutil.GatherAndCompare(reg, strings.NewReader(expected), "captchas_store_operations_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(reg, "captchas_store_operation_duration_seconds"); n != 3 {
+		t.Errorf("expected %d histograms, got %d", 3, n)
+	}
+}
+
+type testMatchVerifier struct {
+	captchas.Store
+}
+
+func (s testMatchVerifier) VerifyMatch(id, actual string, clear bool, match func(actual, answer string) bool) error {
+	answer, err := s.Get(id, clear)
+	if err != nil {
+		return err
+	}
+	if !match(actual, answer) {
+		return captchas.ErrIncorrectCaptcha
+	}
+	return nil
+}
+
+func TestStoreVerifyMatch(t *testing.T) {
+	metrics := &testMetrics{}
+	s, ok := New(testMatchVerifier{memstore.New()}, metrics).(captchas.MatchVerifier)
+	if !ok {
+		t.Fatal("expected a match verifier store")
+	}
+	s.(captchas.Store).Set("foo", "bar")
+	if err := s.VerifyMatch("foo", "baz", true, strings.EqualFold); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	if o := (*metrics)[len(*metrics)-1]; o != (observation{OpVerify, "incorrect"}) {
+		t.Errorf("expected observation %v, got %v", observation{OpVerify, "incorrect"}, o)
+	}
+}
+
+func TestStoreForwarding(t *testing.T) {
+	metrics := &testMetrics{}
+	mem := memstore.New()
+	s := New(mem, metrics)
+	if ok, err := s.(captchas.NXStore).SetIfNotExists("foo", "bar", time.Minute); err != nil || !ok {
+		t.Fatalf("expected to be saved, got %t, %v", ok, err)
+	}
+	if n, err := s.(captchas.AttemptStore).Attempt("foo"); err != nil || n != 1 {
+		t.Errorf("expected attempts %d, got %d, %v", 1, n, err)
+	}
+	captchas.Touch(s, "foo", time.Hour)
+	if ok, err := captchas.Exists(s, "foo"); err != nil || !ok {
+		t.Errorf("expected the captcha to exist, got %t, %v", ok, err)
+	}
+	s.(captchas.DeleteStore).Delete("foo")
+	if ok, _ := captchas.Exists(mem, "foo"); ok {
+		t.Error("expected the captcha to be deleted")
+	}
+
+	expected := []observation{
+		{OpSet, "ok"},
+		{OpAttempt, "ok"},
+		{OpTouch, "ok"},
+		{OpExists, "ok"},
+		{OpDelete, "ok"},
+	}
+	if len(*metrics) != len(expected) {
+		t.Fatalf("expected %d observations, got %d", len(expected), len(*metrics))
+	}
+	for i, o := range *metrics {
+		if o != expected[i] {
+			t.Errorf("expected observation %v, got %v", expected[i], o)
+		}
+	}
+}
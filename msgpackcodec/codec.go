@@ -0,0 +1,29 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package msgpackcodec provides a MessagePack codec for stores, which is
+// more compact than JSON.
+package msgpackcodec
+
+import (
+	"github.com/clevergo/captchas"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type codec struct{}
+
+// New returns a MessagePack codec.
+func New() captchas.Codec {
+	return codec{}
+}
+
+// Marshal implements Codec.Marshal.
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal implements Codec.Unmarshal.
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
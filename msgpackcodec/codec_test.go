@@ -0,0 +1,34 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package msgpackcodec
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestCodec(t *testing.T) {
+	c := New()
+	md := captchas.Metadata{
+		CreatedAt: time.Unix(1600000000, 0).UTC(),
+		ClientIP:  "127.0.0.1",
+		Labels:    map[string]string{"form": "login"},
+	}
+	data, err := c.Marshal(md)
+	if err != nil {
+		t.Fatalf("failed to marshal: %s", err)
+	}
+	var actual captchas.Metadata
+	if err = c.Unmarshal(data, &actual); err != nil {
+		t.Fatalf("failed to unmarshal: %s", err)
+	}
+	actual.CreatedAt = actual.CreatedAt.UTC()
+	if !reflect.DeepEqual(actual, md) {
+		t.Errorf("expected %v, got %v", md, actual)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package natsstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/clevergo/captchas"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Option is a function that receives a pointer of nats store.
+type Option func(s *store)
+
+// Prefix sets the prefix of key.
+func Prefix(prefix string) Option {
+	return func(s *store) {
+		s.prefix = prefix
+	}
+}
+
+type store struct {
+	kv     jetstream.KeyValue
+	prefix string
+}
+
+// New returns a nats store on top of the given key-value bucket, the
+// expiration of captchas is determined by the bucket's TTL.
+func New(kv jetstream.KeyValue, opts ...Option) captchas.Store {
+	s := &store{
+		kv:     kv,
+		prefix: "captchas",
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	return s
+}
+
+func (s *store) getKey(id string) string {
+	return s.prefix + "." + id
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	key := s.getKey(id)
+	entry, err := s.kv.Get(ctx, key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if clear {
+		// purging with the last revision guarantees that only one of
+		// concurrent consumers is able to delete the captcha.
+		err = s.kv.Purge(ctx, key, jetstream.LastRevision(entry.Revision()))
+		if errors.Is(err, jetstream.ErrKeyRevisionMismatch) {
+			return "", captchas.ErrIncorrectCaptcha
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return string(entry.Value()), nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	_, err := s.kv.PutString(ctx, s.getKey(id), answer)
+	return err
+}
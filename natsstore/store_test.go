@@ -0,0 +1,93 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package natsstore
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+var testKV jetstream.KeyValue
+
+func TestMain(m *testing.M) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(time.Second))
+	if err == nil {
+		js, err := jetstream.New(nc)
+		if err != nil {
+			panic(err)
+		}
+		testKV, err = js.CreateOrUpdateKeyValue(context.Background(), jetstream.KeyValueConfig{
+			Bucket: "captchas",
+			TTL:    time.Minute,
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestPrefixOption(t *testing.T) {
+	s := &store{}
+	prefix := "foo"
+	Prefix(prefix)(s)
+	if s.prefix != prefix {
+		t.Errorf("expected prefix %s, got %s", prefix, s.prefix)
+	}
+}
+
+func TestGetKey(t *testing.T) {
+	prefix := "foo"
+	s := &store{prefix: prefix}
+	key := "bar"
+	if s.getKey(key) != prefix+"."+key {
+		t.Errorf("expected key %s, got %s", prefix+"."+key, s.getKey(key))
+	}
+}
+
+func TestNew(t *testing.T) {
+	prefix := "foo"
+	s, _ := New(testKV, Prefix(prefix)).(*store)
+	if s.prefix != prefix {
+		t.Errorf("expected prefix %s, got %s", prefix, s.prefix)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	if testKV == nil {
+		t.Skip("nats server is unavailable")
+	}
+
+	s := New(testKV)
+	_, err := s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package pebblestore
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/cockroachdb/pebble/v2"
+)
+
+// Option is a function that receives a pointer of pebble store.
+type Option func(s *store)
+
+// Prefix sets the prefix of key.
+func Prefix(prefix string) Option {
+	return func(s *store) {
+		s.prefix = prefix
+	}
+}
+
+// Expiration sets expiration.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+// GCInterval sets garbage collection interval, a non-positive interval
+// disables the garbage collection.
+func GCInterval(interval time.Duration) Option {
+	return func(s *store) {
+		s.gcInterval = interval
+	}
+}
+
+// store saves each captcha as two keys:
+//
+//	<prefix>:<id>                 => <expiration><answer>
+//	<prefix>#<expiration><id>     => (empty)
+//
+// the latter is an index ordered by expiration, so that expired index
+// entries can be dropped by a single range deletion.
+type store struct {
+	// mu serializes writes, so that an answer can't be consumed twice.
+	mu         sync.Mutex
+	db         *pebble.DB
+	prefix     string
+	expiration time.Duration
+	gcInterval time.Duration
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// New returns a pebble store.
+func New(db *pebble.DB, opts ...Option) captchas.Store {
+	s := &store{
+		db:         db,
+		prefix:     "captchas",
+		expiration: 10 * time.Minute,
+		gcInterval: time.Minute,
+		done:       make(chan struct{}),
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	if s.gcInterval > 0 {
+		go s.gc()
+	}
+
+	return s
+}
+
+func (s *store) getKey(id string) []byte {
+	return []byte(s.prefix + ":" + id)
+}
+
+func (s *store) indexPrefix() []byte {
+	return []byte(s.prefix + "#")
+}
+
+func (s *store) getIndexKey(expiration int64, id string) []byte {
+	prefix := s.indexPrefix()
+	key := make([]byte, len(prefix)+8+len(id))
+	copy(key, prefix)
+	binary.BigEndian.PutUint64(key[len(prefix):], uint64(expiration))
+	copy(key[len(prefix)+8:], id)
+	return key
+}
+
+// encode encodes expiration and answer as value, the first 8 bytes
+// is the big endian expiration in nanoseconds.
+func encode(expiration int64, answer string) []byte {
+	value := make([]byte, 8+len(answer))
+	binary.BigEndian.PutUint64(value, uint64(expiration))
+	copy(value[8:], answer)
+	return value
+}
+
+func decode(value []byte) (expiration int64, answer string) {
+	if len(value) < 8 {
+		return 0, ""
+	}
+	return int64(binary.BigEndian.Uint64(value)), string(value[8:])
+}
+
+func (s *store) get(key []byte) (expiration int64, answer string, err error) {
+	value, closer, err := s.db.Get(key)
+	if err != nil {
+		return 0, "", err
+	}
+	expiration, answer = decode(value)
+	return expiration, answer, closer.Close()
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	if clear {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	key := s.getKey(id)
+	expiration, answer, err := s.get(key)
+	if err == pebble.ErrNotFound {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if clear {
+		batch := s.db.NewBatch()
+		batch.Delete(key, nil)
+		batch.Delete(s.getIndexKey(expiration, id), nil)
+		if err = batch.Commit(pebble.NoSync); err != nil {
+			return "", err
+		}
+	}
+
+	if time.Now().UnixNano() > expiration {
+		return "", captchas.ErrExpiredCaptcha
+	}
+
+	return answer, nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetWithTTL(id, answer, s.expiration)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiration := time.Now().Add(ttl).UnixNano()
+	batch := s.db.NewBatch()
+	batch.Set(s.getKey(id), encode(expiration, answer), nil)
+	batch.Set(s.getIndexKey(expiration, id), nil, nil)
+	return batch.Commit(pebble.NoSync)
+}
+
+func (s *store) gc() {
+	ticker := time.NewTicker(s.gcInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.deleteExpired()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Close stops the garbage collection, the database is left open.
+func (s *store) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
+
+func (s *store) deleteExpired() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prefix := s.indexPrefix()
+	lower := prefix
+	upper := s.getIndexKey(time.Now().UnixNano()+1, "")
+	iter, err := s.db.NewIter(&pebble.IterOptions{LowerBound: lower, UpperBound: upper})
+	if err != nil {
+		return err
+	}
+
+	batch := s.db.NewBatch()
+	for iter.First(); iter.Valid(); iter.Next() {
+		indexKey := iter.Key()
+		indexExpiration := int64(binary.BigEndian.Uint64(indexKey[len(prefix):]))
+		key := s.getKey(string(indexKey[len(prefix)+8:]))
+		// the captcha may be overridden with a new expiration.
+		expiration, _, err := s.get(key)
+		if err == nil && expiration == indexExpiration {
+			batch.Delete(key, nil)
+		}
+	}
+	if err = iter.Close(); err != nil {
+		return err
+	}
+
+	batch.DeleteRange(lower, upper, nil)
+	return batch.Commit(pebble.NoSync)
+}
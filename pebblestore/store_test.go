@@ -0,0 +1,130 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package pebblestore
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/cockroachdb/pebble/v2"
+	"github.com/cockroachdb/pebble/v2/vfs"
+)
+
+var testDB *pebble.DB
+
+func TestMain(m *testing.M) {
+	var err error
+	testDB, err = pebble.Open("", &pebble.Options{FS: vfs.NewMem()})
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	testDB.Close()
+	os.Exit(code)
+}
+
+func TestGetKey(t *testing.T) {
+	prefix := "foo"
+	s := &store{prefix: prefix}
+	key := "bar"
+	if string(s.getKey(key)) != prefix+":"+key {
+		t.Errorf("expected key %s, got %s", prefix+":"+key, s.getKey(key))
+	}
+}
+
+func TestNew(t *testing.T) {
+	prefix := "foo"
+	expiration := 5 * time.Minute
+	gcInterval := time.Minute
+	s, _ := New(testDB, Prefix(prefix), Expiration(expiration), GCInterval(gcInterval)).(*store)
+	if s.prefix != prefix {
+		t.Errorf("expected prefix %s, got %s", prefix, s.prefix)
+	}
+	if s.expiration != expiration {
+		t.Errorf("expected expiration %v, got %v", expiration, s.expiration)
+	}
+	if s.gcInterval != gcInterval {
+		t.Errorf("expected gcInterval %v, got %v", gcInterval, s.gcInterval)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	s := New(testDB)
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+func TestStoreDeleteExpired(t *testing.T) {
+	s := &store{db: testDB, prefix: "expired", expiration: -time.Second}
+	for _, id := range []string{"expired", "overridden"} {
+		if err := s.Set(id, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := s.Get("expired", false); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+	s.expiration = time.Minute
+	for _, id := range []string{"active", "overridden"} {
+		if err := s.Set(id, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.deleteExpired(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("expired", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected item %q to be deleted", "expired")
+	}
+	for _, id := range []string{"active", "overridden"} {
+		if _, err := s.Get(id, false); err != nil {
+			t.Errorf("expected item %q to be kept, got %v", id, err)
+		}
+	}
+
+	iter, err := testDB.NewIter(&pebble.IterOptions{
+		LowerBound: s.indexPrefix(),
+		UpperBound: s.getIndexKey(time.Now().UnixNano(), ""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer iter.Close()
+	if iter.First() {
+		t.Errorf("expected expired index entries to be deleted, got %q", iter.Key())
+	}
+}
+
+func TestStoreCloseWithoutGC(t *testing.T) {
+	s := New(testDB, GCInterval(0))
+	if err := captchas.Close(s); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package peerstore shares captchas among a cluster of peers without an
+// external database, each captcha is owned by exactly one peer which is picked
+// by consistent hashing, as same as groupcache does.
+//
+// Unlike groupcache, answers are never replicated or cached by other peers,
+// so that consuming a captcha on its owner invalidates it for the whole cluster.
+package peerstore
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/clevergo/captchas"
+	"github.com/golang/groupcache/consistenthash"
+)
+
+// Option is a function that receives a pointer of peer store.
+type Option func(s *Store)
+
+// BasePath sets the HTTP path prefix of peers' requests.
+func BasePath(path string) Option {
+	return func(s *Store) {
+		s.basePath = path
+	}
+}
+
+// Replicas sets the number of virtual nodes of each peer on the hash ring.
+func Replicas(replicas int) Option {
+	return func(s *Store) {
+		s.replicas = replicas
+	}
+}
+
+// Secret sets the shared secret of peers, which is sent along with every
+// request to other peers, and requests without it are rejected.
+func Secret(secret string) Option {
+	return func(s *Store) {
+		s.secret = secret
+	}
+}
+
+// Insecure allows peers to serve requests without the shared secret, it is
+// meant for tests and networks that are protected otherwise, since anyone
+// who reaches the handler is able to read answers.
+func Insecure() Option {
+	return func(s *Store) {
+		s.insecure = true
+	}
+}
+
+// HTTPClient sets the HTTP client for requesting peers.
+func HTTPClient(client *http.Client) Option {
+	return func(s *Store) {
+		s.client = client
+	}
+}
+
+// headerSecret is the header that carries the shared secret of peers.
+const headerSecret = "X-Peerstore-Secret"
+
+// Store is a peer store, it also is an http.Handler that serves requests from
+// other peers, and should be registered on the base path of an internal
+// listener, since it reads and writes answers.
+type Store struct {
+	self     string
+	local    captchas.Store
+	basePath string
+	secret   string
+	insecure bool
+	replicas int
+	client   *http.Client
+	mu       sync.RWMutex
+	peers    *consistenthash.Map
+}
+
+// New returns a peer store, self is the base URL of current peer,
+// e.g. "http://10.0.0.1:8080", local holds the captchas owned by current peer.
+// It panics if neither a non-empty secret nor Insecure is set.
+func New(self string, local captchas.Store, opts ...Option) *Store {
+	s := &Store{
+		self:     self,
+		local:    local,
+		basePath: "/_captchas/",
+		replicas: 50,
+		client:   http.DefaultClient,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	if s.secret == "" && !s.insecure {
+		panic("peerstore: a shared secret is required, see Secret and Insecure")
+	}
+
+	s.SetPeers(self)
+
+	return s
+}
+
+// SetPeers updates the list of peers, each peer should be a base URL and
+// includes current peer.
+func (s *Store) SetPeers(peers ...string) {
+	m := consistenthash.New(s.replicas, nil)
+	m.Add(peers...)
+
+	s.mu.Lock()
+	s.peers = m
+	s.mu.Unlock()
+}
+
+func (s *Store) pick(id string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.peers.Get(id)
+}
+
+func (s *Store) url(peer, id string) string {
+	return peer + s.basePath + url.PathEscape(id)
+}
+
+// Get implements Store.Get, captchas of other peers are consumed by DELETE
+// requests, so that they are never consumed by safe methods.
+func (s *Store) Get(id string, clear bool) (string, error) {
+	peer := s.pick(id)
+	if peer == s.self {
+		return s.local.Get(id, clear)
+	}
+
+	method := http.MethodGet
+	if clear {
+		method = http.MethodDelete
+	}
+	req, err := s.newRequest(method, s.url(peer, id), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return string(body), nil
+	case http.StatusNotFound:
+		return "", captchas.ErrIncorrectCaptcha
+	case http.StatusGone:
+		return "", captchas.ErrExpiredCaptcha
+	default:
+		return "", fmt.Errorf("peer %s responded %s: %s", peer, resp.Status, body)
+	}
+}
+
+// Set implements Store.Set.
+func (s *Store) Set(id, answer string) error {
+	peer := s.pick(id)
+	if peer == s.self {
+		return s.local.Set(id, answer)
+	}
+
+	req, err := s.newRequest(http.MethodPut, s.url(peer, id), strings.NewReader(answer))
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("peer %s responded %s: %s", peer, resp.Status, body)
+	}
+	return nil
+}
+
+func (s *Store) newRequest(method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if s.secret != "" {
+		req.Header.Set(headerSecret, s.secret)
+	}
+	return req, nil
+}
+
+// Close closes the local store if it implements io.Closer.
+func (s *Store) Close() error {
+	return captchas.Close(s.local)
+}
+
+// ServeHTTP handles the requests from other peers with the local store,
+// requests without the shared secret are rejected unless Insecure is set:
+//
+//	GET    {base path}{id}   returns the answer.
+//	DELETE {base path}{id}   returns the answer and deletes the captcha.
+//	PUT    {base path}{id}   saves the answer of the request body.
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, s.basePath) {
+		http.NotFound(w, r)
+		return
+	}
+	if !s.insecure && subtle.ConstantTimeCompare([]byte(r.Header.Get(headerSecret)), []byte(s.secret)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	id, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), s.basePath))
+	if err != nil || id == "" {
+		http.Error(w, "invalid captcha ID", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodDelete:
+		answer, err := s.local.Get(id, r.Method == http.MethodDelete)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		io.WriteString(w, answer)
+	case http.MethodPut:
+		answer, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err = s.local.Set(id, string(answer)); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, DELETE, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, captchas.ErrIncorrectCaptcha):
+		code = http.StatusNotFound
+	case errors.Is(err, captchas.ErrExpiredCaptcha):
+		code = http.StatusGone
+	}
+	http.Error(w, err.Error(), code)
+}
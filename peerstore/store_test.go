@@ -0,0 +1,156 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package peerstore
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/memstore"
+)
+
+func newTestPeers(t *testing.T, n int, opts ...Option) []*Store {
+	stores := make([]*Store, n)
+	urls := make([]string, n)
+	for i := 0; i < n; i++ {
+		// the handler is not available until the store is created.
+		var s *Store
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.ServeHTTP(w, r)
+		}))
+		t.Cleanup(srv.Close)
+		s = New(srv.URL, memstore.New(), opts...)
+		stores[i] = s
+		urls[i] = srv.URL
+	}
+	for _, s := range stores {
+		s.SetPeers(urls...)
+	}
+	return stores
+}
+
+func TestNew(t *testing.T) {
+	basePath := "/foo/"
+	replicas := 10
+	client := &http.Client{Timeout: time.Second}
+	s := New("http://localhost", memstore.New(), BasePath(basePath), Replicas(replicas), HTTPClient(client), Secret("secret"))
+	if s.basePath != basePath {
+		t.Errorf("expected base path %s, got %s", basePath, s.basePath)
+	}
+	if s.replicas != replicas {
+		t.Errorf("expected replicas %d, got %d", replicas, s.replicas)
+	}
+	if s.client != client {
+		t.Errorf("expected client %v, got %v", client, s.client)
+	}
+	if s.secret != "secret" {
+		t.Errorf("expected secret %q, got %q", "secret", s.secret)
+	}
+	if s.pick("foo") != "http://localhost" {
+		t.Errorf("expected peer %s, got %s", "http://localhost", s.pick("foo"))
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	peers := newTestPeers(t, 3, Secret("secret"))
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("foo%d", i)
+		// sets on one peer and gets on the others.
+		s := peers[i%len(peers)]
+		_, err := s.Get(id, true)
+		if err != captchas.ErrIncorrectCaptcha {
+			t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+		}
+
+		err = s.Set(id, "bar")
+		if err != nil {
+			t.Fatalf("failed to set: %s", err)
+		}
+		for j, clear := range []bool{false, true} {
+			value, err := peers[(i+j+1)%len(peers)].Get(id, clear)
+			if err != nil {
+				t.Fatalf("expected non error, got %s", err)
+			}
+			if value != "bar" {
+				t.Errorf("expected value %q, got %q", "bar", value)
+			}
+		}
+
+		for _, p := range peers {
+			if _, err = p.Get(id, false); err != captchas.ErrIncorrectCaptcha {
+				t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+			}
+		}
+	}
+}
+
+func TestStoreServeHTTP(t *testing.T) {
+	s := New("http://localhost", memstore.New(memstore.Expiration(-time.Second)), Insecure())
+	s.Set("expired", "bar")
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{http.MethodGet, "/foo", http.StatusNotFound},
+		{http.MethodGet, "/_captchas/", http.StatusBadRequest},
+		{http.MethodGet, "/_captchas/foo", http.StatusNotFound},
+		{http.MethodGet, "/_captchas/expired", http.StatusGone},
+		{http.MethodDelete, "/_captchas/foo", http.StatusNotFound},
+		{http.MethodPost, "/_captchas/foo", http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+		if w.Code != test.code {
+			t.Errorf("%s %s: expected status code %d, got %d", test.method, test.path, test.code, w.Code)
+		}
+	}
+}
+
+func TestNewWithoutSecret(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic without secret")
+		}
+	}()
+	New("http://localhost", memstore.New())
+}
+
+func TestStoreServeHTTPSecret(t *testing.T) {
+	s := New("http://localhost", memstore.New(), Secret("secret"))
+	s.Set("foo", "bar")
+	for secret, code := range map[string]int{
+		"":       http.StatusUnauthorized,
+		"wrong":  http.StatusUnauthorized,
+		"secret": http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/_captchas/foo", nil)
+		if secret != "" {
+			r.Header.Set(headerSecret, secret)
+		}
+		s.ServeHTTP(w, r)
+		if w.Code != code {
+			t.Errorf("secret %q: expected status code %d, got %d", secret, code, w.Code)
+		}
+	}
+
+	peers := newTestPeers(t, 2, Secret("secret"))
+	peers[1].secret = "wrong"
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("foo%d", i)
+		if peers[1].pick(id) == peers[1].self {
+			continue
+		}
+		if err := peers[1].Set(id, "bar"); err == nil {
+			t.Errorf("expected an error of the wrong secret")
+		}
+	}
+}
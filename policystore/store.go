@@ -0,0 +1,235 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package policystore wraps a store and decides what happens if the store
+// fails, either accepts submissions (fail-open) or rejects them (fail-closed).
+//
+// The store implements captchas.MatchVerifier, or captchas.Verifier if the
+// given store does, since the answer isn't available while the store is down,
+// therefore, answers are compared inside the store.
+package policystore
+
+import (
+	"context"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+// Policy defines how to handle the failures of store.
+type Policy int
+
+// Policies.
+const (
+	// FailClosed rejects submissions and returns the errors of store.
+	FailClosed Policy = iota
+	// FailOpen accepts submissions and logs the errors of store.
+	FailOpen
+)
+
+// Logger is the interface that logs messages, *log.Logger implements it.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Option is a function that receives a pointer of store.
+type Option func(*store)
+
+// WithLogger sets the logger that logs the errors of store.
+func WithLogger(logger Logger) Option {
+	return func(s *store) {
+		s.logger = logger
+	}
+}
+
+// CaseSensitive is an option that enable or disable case sensitive.
+//
+// Deprecated: answers are compared by manager, the CaseSensitive option of
+// manager takes effect instead.
+func CaseSensitive(v bool) Option {
+	return func(s *store) {}
+}
+
+type store struct {
+	store  captchas.Store
+	policy Policy
+	logger Logger
+}
+
+// New returns a store with the given policy that delegates to the given store.
+// The returned store implements captchas.Verifier if the given store does,
+// otherwise captchas.MatchVerifier.
+func New(s captchas.Store, policy Policy, opts ...Option) captchas.Store {
+	ps := &store{
+		store:  s,
+		policy: policy,
+	}
+
+	for _, f := range opts {
+		f(ps)
+	}
+
+	if _, ok := s.(captchas.Verifier); ok {
+		return &verifier{ps}
+	}
+	return &matchVerifier{ps}
+}
+
+// failed returns nil if the error should be ignored.
+func (s *store) failed(op, id string, err error) error {
+	if err == nil || captchas.IsCaptchaError(err) {
+		return err
+	}
+	if s.logger != nil {
+		s.logger.Printf("captchas: %s %q failed: %s", op, id, err)
+	}
+	if s.policy == FailOpen {
+		return nil
+	}
+	return err
+}
+
+// Get implements Store.Get, errors are returned as is, since there is
+// no answer to return.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	return captchas.GetContext(ctx, s.store, id, clear)
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	return s.failed("set", id, captchas.SetContext(ctx, s.store, id, answer))
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.SetWithTTLContext(context.Background(), id, answer, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	if _, ok := s.store.(captchas.TTLStore); !ok {
+		return captchas.ErrTTLUnsupported
+	}
+	return s.failed("set", id, captchas.SetWithTTLContext(ctx, s.store, id, answer, ttl))
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *store) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	return s.SetIfNotExistsContext(context.Background(), id, answer, ttl)
+}
+
+// SetIfNotExistsContext implements ContextNXStore.SetIfNotExistsContext, the
+// captcha is reported as saved if the failure is ignored, as Set does.
+func (s *store) SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error) {
+	ok, err := captchas.SetIfNotExistsContext(ctx, s.store, id, answer, ttl)
+	if err != nil {
+		err = s.failed("set", id, err)
+		return err == nil, err
+	}
+	return ok, nil
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (s *store) Attempt(id string) (int, error) {
+	return s.AttemptContext(context.Background(), id)
+}
+
+// AttemptContext implements ContextAttemptStore.AttemptContext, attempts
+// are not limited if the failure is ignored.
+func (s *store) AttemptContext(ctx context.Context, id string) (int, error) {
+	if _, ok := s.store.(captchas.AttemptStore); !ok {
+		return 0, captchas.ErrAttemptsUnsupported
+	}
+	n, err := captchas.AttemptContext(ctx, s.store, id)
+	return n, s.failed("attempt", id, err)
+}
+
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext implements ContextDeleteStore.DeleteContext.
+func (s *store) DeleteContext(ctx context.Context, id string) error {
+	return s.failed("delete", id, captchas.DeleteContext(ctx, s.store, id))
+}
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	return s.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext implements ContextTouchStore.TouchContext.
+func (s *store) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
+	if _, ok := s.store.(captchas.TouchStore); !ok {
+		return captchas.ErrTouchUnsupported
+	}
+	return s.failed("touch", id, captchas.TouchContext(ctx, s.store, id, ttl))
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *store) Exists(id string) (bool, error) {
+	return s.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext implements ContextExistsStore.ExistsContext, the captcha is
+// reported as existing if the failure is ignored.
+func (s *store) ExistsContext(ctx context.Context, id string) (bool, error) {
+	ok, err := captchas.ExistsContext(ctx, s.store, id)
+	if err != nil {
+		err = s.failed("exists", id, err)
+		return err == nil, err
+	}
+	return ok, nil
+}
+
+// Close closes the underlying store if it implements io.Closer.
+func (s *store) Close() error {
+	return captchas.Close(s.store)
+}
+
+type verifier struct {
+	*store
+}
+
+// Verify implements Verifier.Verify.
+func (v *verifier) Verify(id, actual string, clear bool) error {
+	return v.VerifyContext(context.Background(), id, actual, clear)
+}
+
+// VerifyContext implements ContextVerifier.VerifyContext.
+func (v *verifier) VerifyContext(ctx context.Context, id, actual string, clear bool) error {
+	return v.failed("verify", id, captchas.VerifyContext(ctx, v.store.store.(captchas.Verifier), id, actual, clear))
+}
+
+type matchVerifier struct {
+	*store
+}
+
+// VerifyMatch implements MatchVerifier.VerifyMatch.
+func (v *matchVerifier) VerifyMatch(id, actual string, clear bool, match func(actual, answer string) bool) error {
+	return v.VerifyMatchContext(context.Background(), id, actual, clear, match)
+}
+
+// VerifyMatchContext implements ContextMatchVerifier.VerifyMatchContext.
+func (v *matchVerifier) VerifyMatchContext(ctx context.Context, id, actual string, clear bool, match func(actual, answer string) bool) error {
+	answer, err := captchas.GetContext(ctx, v.store.store, id, clear)
+	if err != nil {
+		return v.failed("verify", id, err)
+	}
+	if match(actual, answer) {
+		return nil
+	}
+	return captchas.ErrIncorrectCaptcha
+}
@@ -0,0 +1,192 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package policystore
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/hashedstore"
+	"github.com/clevergo/captchas/memstore"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+type errStore struct{}
+
+func (s errStore) Get(id string, clear bool) (string, error) {
+	return "", errUnavailable
+}
+
+func (s errStore) Set(id, answer string) error {
+	return errUnavailable
+}
+
+func (s errStore) Verify(id, actual string, clear bool) error {
+	return errUnavailable
+}
+
+type prefixDriver struct{}
+
+func (d prefixDriver) Generate() (captchas.Captcha, error) {
+	return nil, errors.New("unsupported")
+}
+
+// Match reports whether the actual value starts with the answer.
+func (d prefixDriver) Match(actual, answer string) bool {
+	return strings.HasPrefix(actual, answer)
+}
+
+func TestNew(t *testing.T) {
+	logger := log.New(&bytes.Buffer{}, "", 0)
+	s := New(memstore.New(), FailOpen, WithLogger(logger), CaseSensitive(false))
+	ps, _ := s.(*matchVerifier)
+	if ps.policy != FailOpen {
+		t.Errorf("expected policy %d, got %d", FailOpen, ps.policy)
+	}
+	if ps.logger != logger {
+		t.Errorf("expected logger %v, got %v", logger, ps.logger)
+	}
+	if _, ok := s.(captchas.Verifier); ok {
+		t.Error("expected a non-verifier store")
+	}
+	if _, ok := New(hashedstore.New(memstore.New(), []byte("secret")), FailOpen).(captchas.MatchVerifier); ok {
+		t.Error("expected a non-match-verifier store")
+	}
+}
+
+func TestStoreVerify(t *testing.T) {
+	for _, policy := range []Policy{FailClosed, FailOpen} {
+		for _, caseSensitive := range []bool{true, false} {
+			s := New(memstore.New(), policy)
+			m := captchas.New(s, nil, captchas.CaseSensitive(caseSensitive))
+			if err := m.Verify("foo", "bar", true); err != captchas.ErrIncorrectCaptcha {
+				t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+			}
+
+			if err := s.Set("foo", "bar"); err != nil {
+				t.Fatalf("failed to set: %s", err)
+			}
+			if err := m.Verify("foo", "", false); err != captchas.ErrIncorrectCaptcha {
+				t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+			}
+			err := m.Verify("foo", "BAR", false)
+			if caseSensitive && err != captchas.ErrIncorrectCaptcha {
+				t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+			}
+			if !caseSensitive && err != nil {
+				t.Errorf("expected non error, got %s", err)
+			}
+			if err = m.Verify("foo", "bar", true); err != nil {
+				t.Errorf("expected non error, got %s", err)
+			}
+			if err = m.Verify("foo", "bar", true); err != captchas.ErrIncorrectCaptcha {
+				t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+			}
+		}
+	}
+}
+
+func TestStoreVerifyMatcher(t *testing.T) {
+	s := New(memstore.New(), FailClosed)
+	m := captchas.New(s, prefixDriver{})
+	s.Set("foo", "bar")
+	if err := m.Verify("foo", "barbaz", false); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if err := m.Verify("foo", "baz", true); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
+
+func TestStoreVerifyVerifier(t *testing.T) {
+	s := New(hashedstore.New(memstore.New(), []byte("secret")), FailClosed)
+	s.Set("foo", "bar")
+	if err := s.(captchas.Verifier).Verify("foo", "bar", true); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if err := captchas.New(s, prefixDriver{}).Verify("foo", "bar", true); err != captchas.ErrMatcherUnsupported {
+		t.Errorf("expected error %v, got %v", captchas.ErrMatcherUnsupported, err)
+	}
+}
+
+func TestStoreFailure(t *testing.T) {
+	// errStore is a verifier, hides it to test both paths.
+	stores := []captchas.Store{errStore{}, struct{ captchas.Store }{errStore{}}}
+	for _, st := range stores {
+		buf := &bytes.Buffer{}
+		s := New(st, FailClosed, WithLogger(log.New(buf, "", 0)))
+		if err := s.Set("foo", "bar"); err != errUnavailable {
+			t.Errorf("expected error %v, got %v", errUnavailable, err)
+		}
+		if err := captchas.New(s, nil).Verify("foo", "bar", true); !errors.Is(err, errUnavailable) {
+			t.Errorf("expected error %v, got %v", errUnavailable, err)
+		}
+		if !strings.Contains(buf.String(), `captchas: verify "foo" failed: unavailable`) {
+			t.Errorf("unexpected log: %s", buf.String())
+		}
+
+		s = New(st, FailOpen)
+		if err := s.Set("foo", "bar"); err != nil {
+			t.Errorf("expected non error, got %s", err)
+		}
+		if err := captchas.New(s, nil).Verify("foo", "bar", true); err != nil {
+			t.Errorf("expected non error, got %s", err)
+		}
+		if _, err := s.Get("foo", true); err != errUnavailable {
+			t.Errorf("expected error %v, got %v", errUnavailable, err)
+		}
+	}
+}
+
+func TestStoreForwarding(t *testing.T) {
+	mem := memstore.New()
+	s := New(mem, FailClosed)
+	if ok, err := s.(captchas.NXStore).SetIfNotExists("foo", "bar", time.Minute); err != nil || !ok {
+		t.Fatalf("expected to be saved, got %t, %v", ok, err)
+	}
+	if ok, err := s.(captchas.NXStore).SetIfNotExists("foo", "bar", time.Minute); err != nil || ok {
+		t.Errorf("expected the ID to be taken, got %t, %v", ok, err)
+	}
+	if n, err := s.(captchas.AttemptStore).Attempt("foo"); err != nil || n != 1 {
+		t.Errorf("expected attempts %d, got %d, %v", 1, n, err)
+	}
+	if err := captchas.Touch(s, "foo", time.Hour); err != nil {
+		t.Errorf("failed to touch: %s", err)
+	}
+	if ok, err := captchas.Exists(s, "foo"); err != nil || !ok {
+		t.Errorf("expected the captcha to exist, got %t, %v", ok, err)
+	}
+	if err := s.(captchas.DeleteStore).Delete("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := captchas.Exists(mem, "foo"); ok {
+		t.Error("expected the captcha to be deleted")
+	}
+
+	st := struct{ captchas.Store }{errStore{}}
+	s = New(st, FailOpen)
+	if ok, err := s.(captchas.NXStore).SetIfNotExists("foo", "bar", 0); err != nil || !ok {
+		t.Errorf("expected the failure to be ignored, got %t, %v", ok, err)
+	}
+	if ok, err := captchas.Exists(s, "foo"); err != nil || !ok {
+		t.Errorf("expected the failure to be ignored, got %t, %v", ok, err)
+	}
+	if _, err := s.(captchas.AttemptStore).Attempt("foo"); err != captchas.ErrAttemptsUnsupported {
+		t.Errorf("expected error %v, got %v", captchas.ErrAttemptsUnsupported, err)
+	}
+	if err := captchas.Touch(s, "foo", time.Hour); err != captchas.ErrTouchUnsupported {
+		t.Errorf("expected error %v, got %v", captchas.ErrTouchUnsupported, err)
+	}
+	s = New(st, FailClosed)
+	if err := s.(captchas.DeleteStore).Delete("foo"); err != errUnavailable {
+		t.Errorf("expected error %v, got %v", errUnavailable, err)
+	}
+}
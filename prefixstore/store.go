@@ -0,0 +1,150 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package prefixstore prefixes captcha IDs with a namespace, so that multiple
+// applications or tenants can share a single store safely.
+package prefixstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+type store struct {
+	store  captchas.Store
+	prefix string
+}
+
+// New returns a store that prefixes IDs with the given prefix before
+// delegating to the given store, the prefix should include a separator,
+// such as "app1:". The returned store implements captchas.Verifier or
+// captchas.MatchVerifier if the given store does.
+func New(s captchas.Store, prefix string) captchas.Store {
+	ps := &store{
+		store:  s,
+		prefix: prefix,
+	}
+	if _, ok := s.(captchas.MatchVerifier); ok {
+		return &matchVerifier{ps}
+	}
+	if _, ok := s.(captchas.Verifier); ok {
+		return &verifier{ps}
+	}
+	return ps
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.store.Get(s.prefix+id, clear)
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.store.Set(s.prefix+id, answer)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return captchas.SetWithTTL(s.store, s.prefix+id, answer, ttl)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	return captchas.GetContext(ctx, s.store, s.prefix+id, clear)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	return captchas.SetContext(ctx, s.store, s.prefix+id, answer)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	return captchas.SetWithTTLContext(ctx, s.store, s.prefix+id, answer, ttl)
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *store) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	return s.SetIfNotExistsContext(context.Background(), id, answer, ttl)
+}
+
+// SetIfNotExistsContext implements ContextNXStore.SetIfNotExistsContext.
+func (s *store) SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error) {
+	return captchas.SetIfNotExistsContext(ctx, s.store, s.prefix+id, answer, ttl)
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (s *store) Attempt(id string) (int, error) {
+	return s.AttemptContext(context.Background(), id)
+}
+
+// AttemptContext implements ContextAttemptStore.AttemptContext.
+func (s *store) AttemptContext(ctx context.Context, id string) (int, error) {
+	return captchas.AttemptContext(ctx, s.store, s.prefix+id)
+}
+
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext implements ContextDeleteStore.DeleteContext.
+func (s *store) DeleteContext(ctx context.Context, id string) error {
+	return captchas.DeleteContext(ctx, s.store, s.prefix+id)
+}
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	return s.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext implements ContextTouchStore.TouchContext.
+func (s *store) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
+	return captchas.TouchContext(ctx, s.store, s.prefix+id, ttl)
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *store) Exists(id string) (bool, error) {
+	return s.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext implements ContextExistsStore.ExistsContext.
+func (s *store) ExistsContext(ctx context.Context, id string) (bool, error) {
+	return captchas.ExistsContext(ctx, s.store, s.prefix+id)
+}
+
+// Close closes the underlying store if it implements io.Closer.
+func (s *store) Close() error {
+	return captchas.Close(s.store)
+}
+
+type verifier struct {
+	*store
+}
+
+// Verify implements Verifier.Verify.
+func (v *verifier) Verify(id, actual string, clear bool) error {
+	return v.VerifyContext(context.Background(), id, actual, clear)
+}
+
+// VerifyContext implements ContextVerifier.VerifyContext.
+func (v *verifier) VerifyContext(ctx context.Context, id, actual string, clear bool) error {
+	return captchas.VerifyContext(ctx, v.store.store.(captchas.Verifier), v.prefix+id, actual, clear)
+}
+
+type matchVerifier struct {
+	*store
+}
+
+// VerifyMatch implements MatchVerifier.VerifyMatch.
+func (v *matchVerifier) VerifyMatch(id, actual string, clear bool, match func(actual, answer string) bool) error {
+	return v.VerifyMatchContext(context.Background(), id, actual, clear, match)
+}
+
+// VerifyMatchContext implements ContextMatchVerifier.VerifyMatchContext.
+func (v *matchVerifier) VerifyMatchContext(ctx context.Context, id, actual string, clear bool, match func(actual, answer string) bool) error {
+	return captchas.VerifyMatchContext(ctx, v.store.store.(captchas.MatchVerifier), v.prefix+id, actual, clear, match)
+}
@@ -0,0 +1,136 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package prefixstore
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/hashedstore"
+	"github.com/clevergo/captchas/memstore"
+)
+
+func TestStoreGet(t *testing.T) {
+	mem := memstore.New()
+	s := New(mem, "app1:")
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if value, _ := mem.Get("app1:foo", false); value != "bar" {
+		t.Errorf("expected value %q, got %q", "bar", value)
+	}
+	if _, err = New(mem, "app2:").Get("foo", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
+
+func TestStoreVerify(t *testing.T) {
+	if _, ok := New(memstore.New(), "app1:").(captchas.Verifier); ok {
+		t.Error("expected a non-verifier store")
+	}
+
+	hashed := hashedstore.New(memstore.New(), []byte("secret"))
+	hashed.Set("app1:foo", "bar")
+	s, ok := New(hashed, "app1:").(captchas.Verifier)
+	if !ok {
+		t.Fatal("expected a verifier store")
+	}
+	if err := s.Verify("foo", "bar", true); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+}
+
+type plainStore struct {
+	captchas.Store
+}
+
+func TestStoreSetWithTTL(t *testing.T) {
+	mem := memstore.New()
+	s := New(mem, "app1:").(captchas.TTLStore)
+	if err := s.SetWithTTL("foo", "bar", -time.Second); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if _, err := mem.Get("app1:foo", false); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+
+	s = New(plainStore{mem}, "app1:").(captchas.TTLStore)
+	if err := s.SetWithTTL("foo", "bar", time.Minute); err != captchas.ErrTTLUnsupported {
+		t.Errorf("expected error %v, got %v", captchas.ErrTTLUnsupported, err)
+	}
+}
+
+type testMatchVerifier struct {
+	captchas.Store
+}
+
+func (s testMatchVerifier) VerifyMatch(id, actual string, clear bool, match func(actual, answer string) bool) error {
+	answer, err := s.Get(id, clear)
+	if err != nil {
+		return err
+	}
+	if !match(actual, answer) {
+		return captchas.ErrIncorrectCaptcha
+	}
+	return nil
+}
+
+func TestStoreVerifyMatch(t *testing.T) {
+	mem := memstore.New()
+	s, ok := New(testMatchVerifier{mem}, "app1:").(captchas.MatchVerifier)
+	if !ok {
+		t.Fatal("expected a match verifier store")
+	}
+	mem.Set("app1:foo", "bar")
+	if err := s.VerifyMatch("foo", "BAR", true, strings.EqualFold); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+}
+
+func TestStoreAttempt(t *testing.T) {
+	mem := memstore.New()
+	s := New(mem, "app1:").(captchas.AttemptStore)
+	s.Set("foo", "bar")
+	for i := 1; i <= 2; i++ {
+		if n, err := s.Attempt("foo"); err != nil || n != i {
+			t.Errorf("expected attempts %d, got %d, %v", i, n, err)
+		}
+	}
+	if n, _ := mem.(captchas.AttemptStore).Attempt("app1:foo"); n != 3 {
+		t.Errorf("expected attempts %d, got %d", 3, n)
+	}
+	if _, err := New(testMatchVerifier{mem}, "app1:").(captchas.AttemptStore).Attempt("foo"); err != captchas.ErrAttemptsUnsupported {
+		t.Errorf("expected error %v, got %v", captchas.ErrAttemptsUnsupported, err)
+	}
+
+	if err := captchas.Delete(s, "foo"); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if ok, _ := captchas.Exists(mem, "app1:foo"); ok {
+		t.Error("expected the captcha to be deleted")
+	}
+}
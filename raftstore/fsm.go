@@ -0,0 +1,163 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package raftstore
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/clevergo/captchas"
+	"github.com/hashicorp/raft"
+)
+
+// Operations of commands.
+const (
+	opSet     = "set"
+	opConsume = "consume"
+	opGC      = "gc"
+	opJoin    = "join"
+	opLeave   = "leave"
+)
+
+// command is a replicated operation, all timestamps are decided by the
+// node that creates the command, so that applying is deterministic.
+type command struct {
+	Op         string `json:"op"`
+	ID         string `json:"id,omitempty"`
+	Answer     string `json:"answer,omitempty"`
+	Expiration int64  `json:"expiration,omitempty"`
+	Now        int64  `json:"now,omitempty"`
+	URL        string `json:"url,omitempty"`
+}
+
+type result struct {
+	answer string
+	err    error
+}
+
+type item struct {
+	Answer     string `json:"answer"`
+	Expiration int64  `json:"expiration"`
+}
+
+type state struct {
+	Items map[string]item `json:"items"`
+	// Peers maps server IDs to the URLs of their handlers.
+	Peers map[raft.ServerID]string `json:"peers"`
+}
+
+type fsm struct {
+	mu    sync.RWMutex
+	state state
+}
+
+func newFSM() *fsm {
+	return &fsm{
+		state: state{
+			Items: make(map[string]item),
+			Peers: make(map[raft.ServerID]string),
+		},
+	}
+}
+
+// Apply implements raft.FSM.Apply, it always returns a result.
+func (f *fsm) Apply(log *raft.Log) interface{} {
+	var cmd command
+	if err := json.Unmarshal(log.Data, &cmd); err != nil {
+		return result{err: err}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch cmd.Op {
+	case opSet:
+		f.state.Items[cmd.ID] = item{Answer: cmd.Answer, Expiration: cmd.Expiration}
+	case opConsume:
+		item, ok := f.state.Items[cmd.ID]
+		if !ok {
+			return result{err: captchas.ErrIncorrectCaptcha}
+		}
+		delete(f.state.Items, cmd.ID)
+		if cmd.Now > item.Expiration {
+			return result{err: captchas.ErrExpiredCaptcha}
+		}
+		return result{answer: item.Answer}
+	case opGC:
+		for id, item := range f.state.Items {
+			if cmd.Now > item.Expiration {
+				delete(f.state.Items, id)
+			}
+		}
+	case opJoin:
+		f.state.Peers[raft.ServerID(cmd.ID)] = cmd.URL
+	case opLeave:
+		delete(f.state.Peers, raft.ServerID(cmd.ID))
+	}
+
+	return result{}
+}
+
+func (f *fsm) get(id string) (item, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	item, ok := f.state.Items[id]
+	return item, ok
+}
+
+func (f *fsm) peer(id raft.ServerID) (string, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	url, ok := f.state.Peers[id]
+	return url, ok
+}
+
+// Snapshot implements raft.FSM.Snapshot.
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	data, err := json.Marshal(f.state)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot(data), nil
+}
+
+// Restore implements raft.FSM.Restore.
+func (f *fsm) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	s := state{}
+	if err := json.NewDecoder(rc).Decode(&s); err != nil {
+		return err
+	}
+	if s.Items == nil {
+		s.Items = make(map[string]item)
+	}
+	if s.Peers == nil {
+		s.Peers = make(map[raft.ServerID]string)
+	}
+
+	f.mu.Lock()
+	f.state = s
+	f.mu.Unlock()
+	return nil
+}
+
+type snapshot []byte
+
+// Persist implements raft.FSMSnapshot.Persist.
+func (s snapshot) Persist(sink raft.SnapshotSink) error {
+	if _, err := sink.Write(s); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+// Release implements raft.FSMSnapshot.Release.
+func (s snapshot) Release() {}
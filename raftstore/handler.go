@@ -0,0 +1,109 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package raftstore
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/clevergo/captchas"
+	"github.com/hashicorp/raft"
+)
+
+// headerForwarded marks the requests forwarded to the leader, which must
+// not be forwarded again.
+const headerForwarded = "X-Raftstore-Forwarded"
+
+// headerSecret is the header that carries the shared secret of nodes.
+const headerSecret = "X-Raftstore-Secret"
+
+type joinRequest struct {
+	ID   string `json:"id"`
+	Addr string `json:"addr"`
+	URL  string `json:"url"`
+}
+
+type leaveRequest struct {
+	ID string `json:"id"`
+}
+
+type response struct {
+	Answer string `json:"answer,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ServeHTTP handles the requests that forwarded by followers, and the
+// requests of joining or leaving the cluster, requests without the shared
+// secret are rejected unless Insecure is set. It must not be exposed to the
+// public.
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.insecure && subtle.ConstantTimeCompare([]byte(r.Header.Get(headerSecret)), []byte(s.secret)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, response{Error: http.StatusText(http.StatusUnauthorized)})
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		writeJSON(w, http.StatusMethodNotAllowed, response{Error: http.StatusText(http.StatusMethodNotAllowed)})
+		return
+	}
+	forward := r.Header.Get(headerForwarded) == ""
+
+	var answer string
+	var err error
+	switch strings.TrimPrefix(r.URL.Path, s.basePath) {
+	case "apply":
+		var cmd command
+		if err = json.NewDecoder(r.Body).Decode(&cmd); err != nil {
+			writeJSON(w, http.StatusBadRequest, response{Error: err.Error()})
+			return
+		}
+		if cmd.Op != opSet && cmd.Op != opConsume {
+			writeJSON(w, http.StatusBadRequest, response{Error: "unsupported operation: " + cmd.Op})
+			return
+		}
+		answer, err = s.apply(&cmd, forward)
+	case "join":
+		var req joinRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, response{Error: err.Error()})
+			return
+		}
+		err = s.join(req, forward)
+	case "leave":
+		var req leaveRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, response{Error: err.Error()})
+			return
+		}
+		err = s.leave(req, forward)
+	default:
+		writeJSON(w, http.StatusBadRequest, response{Error: "unsupported path: " + r.URL.Path})
+		return
+	}
+
+	if err != nil {
+		code := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, captchas.ErrIncorrectCaptcha):
+			code = http.StatusNotFound
+		case errors.Is(err, captchas.ErrExpiredCaptcha):
+			code = http.StatusGone
+		case errors.Is(err, raft.ErrNotLeader):
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, response{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, response{Answer: answer})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,331 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package raftstore replicates captchas among a small cluster of nodes by Raft,
+// so that captchas can be verified on any node even if a minority of nodes are
+// down, without any external dependency.
+//
+// Writes and consumption are applied through the leader, followers forward them
+// to the leader via the HTTP handler of the leader, therefore, every node should
+// serve its store under the same base path. Non-destructive reads are served
+// from the local state which may be slightly stale on followers.
+package raftstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/hashicorp/raft"
+)
+
+// Option is a function that receives a pointer of raft store.
+type Option func(s *Store)
+
+// Expiration sets expiration.
+func Expiration(expiration time.Duration) Option {
+	return func(s *Store) {
+		s.expiration = expiration
+	}
+}
+
+// GCInterval sets garbage collection interval, a non-positive interval
+// disables the garbage collection.
+func GCInterval(interval time.Duration) Option {
+	return func(s *Store) {
+		s.gcInterval = interval
+	}
+}
+
+// ApplyTimeout sets the timeout of applying a command.
+func ApplyTimeout(timeout time.Duration) Option {
+	return func(s *Store) {
+		s.timeout = timeout
+	}
+}
+
+// BasePath sets the HTTP path prefix of the handler.
+func BasePath(path string) Option {
+	return func(s *Store) {
+		s.basePath = path
+	}
+}
+
+// Secret sets the shared secret of nodes, which is sent along with every
+// request to other nodes, and requests without it are rejected.
+func Secret(secret string) Option {
+	return func(s *Store) {
+		s.secret = secret
+	}
+}
+
+// Insecure allows nodes to serve requests without the shared secret, it is
+// meant for tests and networks that are protected otherwise, since anyone
+// who reaches the handler is able to apply writes and membership changes.
+func Insecure() Option {
+	return func(s *Store) {
+		s.insecure = true
+	}
+}
+
+// ErrSecretRequired is returned by New if neither a non-empty secret nor
+// Insecure is set.
+var ErrSecretRequired = errors.New("raftstore: a shared secret is required, see Secret and Insecure")
+
+// HTTPClient sets the HTTP client for forwarding requests to the leader.
+func HTTPClient(client *http.Client) Option {
+	return func(s *Store) {
+		s.client = client
+	}
+}
+
+// Store is a raft store, it also is an http.Handler which handles the
+// requests forwarded by followers, and should be registered on the base path
+// of an internal listener, since it applies writes and membership changes.
+type Store struct {
+	raft       *raft.Raft
+	fsm        *fsm
+	id         raft.ServerID
+	addr       raft.ServerAddress
+	url        string
+	basePath   string
+	secret     string
+	insecure   bool
+	expiration time.Duration
+	gcInterval time.Duration
+	timeout    time.Duration
+	client     *http.Client
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// New returns a raft store, the arguments are passed to raft.NewRaft, url is
+// the base URL of current node where the store handler can be reached, e.g.
+// "http://10.0.0.1:8080". It returns ErrSecretRequired if neither a non-empty
+// secret nor Insecure is set.
+func New(conf *raft.Config, logs raft.LogStore, stable raft.StableStore, snaps raft.SnapshotStore, trans raft.Transport, url string, opts ...Option) (*Store, error) {
+	s := &Store{
+		fsm:        newFSM(),
+		id:         conf.LocalID,
+		addr:       trans.LocalAddr(),
+		url:        url,
+		basePath:   "/_raftstore/",
+		expiration: 10 * time.Minute,
+		gcInterval: time.Minute,
+		timeout:    10 * time.Second,
+		client:     http.DefaultClient,
+		done:       make(chan struct{}),
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	if s.secret == "" && !s.insecure {
+		return nil, ErrSecretRequired
+	}
+
+	r, err := raft.NewRaft(conf, s.fsm, logs, stable, snaps, trans)
+	if err != nil {
+		return nil, err
+	}
+	s.raft = r
+
+	go s.run()
+
+	return s, nil
+}
+
+// Raft returns the underlying raft instance.
+func (s *Store) Raft() *raft.Raft {
+	return s.raft
+}
+
+// Bootstrap bootstraps a new cluster with current node as the only server,
+// other nodes should join the cluster by JoinCluster.
+func (s *Store) Bootstrap() error {
+	return s.raft.BootstrapCluster(raft.Configuration{
+		Servers: []raft.Server{
+			{ID: s.id, Address: s.addr},
+		},
+	}).Error()
+}
+
+// JoinCluster asks a node of an existing cluster to add current node.
+func (s *Store) JoinCluster(peerURL string) error {
+	_, err := s.post(peerURL, "join", joinRequest{ID: string(s.id), Addr: string(s.addr), URL: s.url}, false)
+	return err
+}
+
+// Join adds a node to the cluster as a voter.
+func (s *Store) Join(id, addr, url string) error {
+	return s.join(joinRequest{ID: id, Addr: addr, URL: url}, true)
+}
+
+func (s *Store) join(req joinRequest, forward bool) error {
+	if s.raft.State() != raft.Leader {
+		if !forward {
+			return raft.ErrNotLeader
+		}
+		_, err := s.forward("join", req)
+		return err
+	}
+
+	if err := s.raft.AddVoter(raft.ServerID(req.ID), raft.ServerAddress(req.Addr), 0, s.timeout).Error(); err != nil {
+		return err
+	}
+	_, err := s.apply(&command{Op: opJoin, ID: req.ID, URL: req.URL}, false)
+	return err
+}
+
+// Leave removes a node from the cluster.
+func (s *Store) Leave(id string) error {
+	return s.leave(leaveRequest{ID: id}, true)
+}
+
+func (s *Store) leave(req leaveRequest, forward bool) error {
+	if s.raft.State() != raft.Leader {
+		if !forward {
+			return raft.ErrNotLeader
+		}
+		_, err := s.forward("leave", req)
+		return err
+	}
+
+	if err := s.raft.RemoveServer(raft.ServerID(req.ID), 0, s.timeout).Error(); err != nil {
+		return err
+	}
+	_, err := s.apply(&command{Op: opLeave, ID: req.ID}, false)
+	return err
+}
+
+// Close shuts down current node.
+func (s *Store) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	return s.raft.Shutdown().Error()
+}
+
+// run registers the URL of current node once it becomes the leader, and
+// collects expired captchas periodically while it is the leader.
+func (s *Store) run() {
+	var tick <-chan time.Time
+	if s.gcInterval > 0 {
+		ticker := time.NewTicker(s.gcInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-s.done:
+			return
+		case isLeader := <-s.raft.LeaderCh():
+			if isLeader {
+				s.apply(&command{Op: opJoin, ID: string(s.id), URL: s.url}, false)
+			}
+		case <-tick:
+			if s.raft.State() == raft.Leader {
+				s.apply(&command{Op: opGC, Now: time.Now().UnixNano()}, false)
+			}
+		}
+	}
+}
+
+func (s *Store) apply(cmd *command, forward bool) (string, error) {
+	if s.raft.State() != raft.Leader {
+		if !forward {
+			return "", raft.ErrNotLeader
+		}
+		return s.forward("apply", cmd)
+	}
+
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return "", err
+	}
+	f := s.raft.Apply(data, s.timeout)
+	if err = f.Error(); err != nil {
+		return "", err
+	}
+	res := f.Response().(result)
+	return res.answer, res.err
+}
+
+func (s *Store) forward(path string, v interface{}) (string, error) {
+	_, id := s.raft.LeaderWithID()
+	if id == "" {
+		return "", raft.ErrNotLeader
+	}
+	url, ok := s.fsm.peer(id)
+	if !ok {
+		return "", fmt.Errorf("unknown URL of leader %s", id)
+	}
+	return s.post(url, path, v, true)
+}
+
+func (s *Store) post(url, path string, v interface{}, forwarded bool) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest(http.MethodPost, url+s.basePath+path, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if forwarded {
+		req.Header.Set(headerForwarded, "1")
+	}
+	if s.secret != "" {
+		req.Header.Set(headerSecret, s.secret)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body response
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("%s %s: %s", req.Method, req.URL, resp.Status)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body.Answer, nil
+	case http.StatusNotFound:
+		return "", captchas.ErrIncorrectCaptcha
+	case http.StatusGone:
+		return "", captchas.ErrExpiredCaptcha
+	}
+	return "", errors.New(body.Error)
+}
+
+// Get implements Store.Get.
+func (s *Store) Get(id string, clear bool) (string, error) {
+	if clear {
+		return s.apply(&command{Op: opConsume, ID: id, Now: time.Now().UnixNano()}, true)
+	}
+
+	item, ok := s.fsm.get(id)
+	if !ok {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	if time.Now().UnixNano() > item.Expiration {
+		return "", captchas.ErrExpiredCaptcha
+	}
+	return item.Answer, nil
+}
+
+// Set implements Store.Set.
+func (s *Store) Set(id, answer string) error {
+	_, err := s.apply(&command{Op: opSet, ID: id, Answer: answer, Expiration: time.Now().Add(s.expiration).UnixNano()}, true)
+	return err
+}
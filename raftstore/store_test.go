@@ -0,0 +1,264 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package raftstore
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/hashicorp/raft"
+)
+
+func newTestStore(t *testing.T, id string, opts ...Option) (*Store, *raft.InmemTransport) {
+	conf := raft.DefaultConfig()
+	conf.LocalID = raft.ServerID(id)
+	conf.HeartbeatTimeout = 50 * time.Millisecond
+	conf.ElectionTimeout = 50 * time.Millisecond
+	conf.LeaderLeaseTimeout = 50 * time.Millisecond
+	conf.CommitTimeout = 5 * time.Millisecond
+	conf.LogOutput = io.Discard
+
+	_, trans := raft.NewInmemTransport(raft.ServerAddress(id))
+	// the handler is not available until the store is created.
+	var s *Store
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	store := raft.NewInmemStore()
+	opts = append([]Option{Secret("secret")}, opts...)
+	s, err := New(conf, store, store, raft.NewInmemSnapshotStore(), trans, srv.URL, opts...)
+	if err != nil {
+		t.Fatalf("failed to create store: %s", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, trans
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func newTestCluster(t *testing.T, n int, opts ...Option) []*Store {
+	stores := make([]*Store, n)
+	transports := make([]*raft.InmemTransport, n)
+	for i := 0; i < n; i++ {
+		stores[i], transports[i] = newTestStore(t, fmt.Sprintf("node%d", i), opts...)
+	}
+	for i, t1 := range transports {
+		for j, t2 := range transports {
+			if i != j {
+				t1.Connect(t2.LocalAddr(), t2)
+			}
+		}
+	}
+
+	if err := stores[0].Bootstrap(); err != nil {
+		t.Fatalf("failed to bootstrap: %s", err)
+	}
+	waitFor(t, func() bool {
+		_, ok := stores[0].fsm.peer(stores[0].id)
+		return ok
+	})
+	for _, s := range stores[1:] {
+		if err := s.JoinCluster(stores[0].url); err != nil {
+			t.Fatalf("failed to join cluster: %s", err)
+		}
+	}
+	for _, s := range stores {
+		waitFor(t, func() bool {
+			_, ok := s.fsm.peer(stores[len(stores)-1].id)
+			return ok
+		})
+	}
+	return stores
+}
+
+func TestNew(t *testing.T) {
+	expiration := 5 * time.Minute
+	gcInterval := 10 * time.Second
+	timeout := time.Second
+	basePath := "/foo/"
+	client := &http.Client{Timeout: time.Second}
+	s, _ := newTestStore(t, "node", Expiration(expiration), GCInterval(gcInterval), ApplyTimeout(timeout), BasePath(basePath), HTTPClient(client))
+	if s.expiration != expiration {
+		t.Errorf("expected expiration %s, got %s", expiration, s.expiration)
+	}
+	if s.gcInterval != gcInterval {
+		t.Errorf("expected GC interval %s, got %s", gcInterval, s.gcInterval)
+	}
+	if s.timeout != timeout {
+		t.Errorf("expected apply timeout %s, got %s", timeout, s.timeout)
+	}
+	if s.basePath != basePath {
+		t.Errorf("expected base path %s, got %s", basePath, s.basePath)
+	}
+	if s.client != client {
+		t.Errorf("expected client %v, got %v", client, s.client)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	nodes := newTestCluster(t, 3, Secret("secret"))
+	for i := 0; i < 10; i++ {
+		id := fmt.Sprintf("foo%d", i)
+		// sets on one node and consumes on the others.
+		s := nodes[i%len(nodes)]
+		_, err := s.Get(id, true)
+		if err != captchas.ErrIncorrectCaptcha {
+			t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+		}
+
+		err = s.Set(id, "bar")
+		if err != nil {
+			t.Fatalf("failed to set: %s", err)
+		}
+		waitFor(t, func() bool {
+			_, err := s.Get(id, false)
+			return err == nil
+		})
+		value, err := nodes[(i+1)%len(nodes)].Get(id, true)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+
+		if _, err = nodes[(i+2)%len(nodes)].Get(id, true); err != captchas.ErrIncorrectCaptcha {
+			t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+		}
+	}
+}
+
+func TestStoreExpiration(t *testing.T) {
+	nodes := newTestCluster(t, 1, Expiration(-time.Second), GCInterval(10*time.Millisecond))
+	s := nodes[0]
+	if err := s.Set("expired", "bar"); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if _, err := s.Get("expired", false); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+	waitFor(t, func() bool {
+		_, ok := s.fsm.get("expired")
+		return !ok
+	})
+}
+
+func TestStoreLeave(t *testing.T) {
+	nodes := newTestCluster(t, 3)
+	leaving := nodes[2]
+	if err := nodes[1].Leave(string(leaving.id)); err != nil {
+		t.Fatalf("failed to leave: %s", err)
+	}
+	for _, s := range nodes[:2] {
+		waitFor(t, func() bool {
+			_, ok := s.fsm.peer(leaving.id)
+			return !ok
+		})
+	}
+}
+
+func TestFSMSnapshot(t *testing.T) {
+	f := newFSM()
+	f.Apply(&raft.Log{Data: []byte(`{"op":"set","id":"foo","answer":"bar","expiration":1}`)})
+	f.Apply(&raft.Log{Data: []byte(`{"op":"join","id":"node","url":"http://localhost"}`)})
+
+	s, err := f.Snapshot()
+	if err != nil {
+		t.Fatalf("failed to snapshot: %s", err)
+	}
+	f2 := newFSM()
+	if err = f2.Restore(io.NopCloser(bytes.NewReader(s.(snapshot)))); err != nil {
+		t.Fatalf("failed to restore: %s", err)
+	}
+	if item, ok := f2.get("foo"); !ok || item.Answer != "bar" {
+		t.Errorf("expected answer %q, got %q", "bar", item.Answer)
+	}
+	if url, ok := f2.peer("node"); !ok || url != "http://localhost" {
+		t.Errorf("expected URL %q, got %q", "http://localhost", url)
+	}
+}
+
+func TestStoreServeHTTP(t *testing.T) {
+	s, _ := newTestStore(t, "node")
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{http.MethodGet, "/_raftstore/apply", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/_raftstore/foo", "{}", http.StatusBadRequest},
+		{http.MethodPost, "/_raftstore/apply", "invalid", http.StatusBadRequest},
+		{http.MethodPost, "/_raftstore/apply", `{"op":"gc"}`, http.StatusBadRequest},
+		{http.MethodPost, "/_raftstore/apply", `{"op":"set"}`, http.StatusServiceUnavailable},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		r.Header.Set(headerForwarded, "1")
+		r.Header.Set(headerSecret, "secret")
+		s.ServeHTTP(w, r)
+		if w.Code != test.code {
+			t.Errorf("%s %s: expected status code %d, got %d", test.method, test.path, test.code, w.Code)
+		}
+	}
+}
+
+func TestNewWithoutSecret(t *testing.T) {
+	conf := raft.DefaultConfig()
+	conf.LocalID = "node"
+	_, trans := raft.NewInmemTransport("node")
+	store := raft.NewInmemStore()
+	for _, opts := range [][]Option{nil, {Secret("")}} {
+		if _, err := New(conf, store, store, raft.NewInmemSnapshotStore(), trans, "http://localhost", opts...); err != ErrSecretRequired {
+			t.Errorf("expected error %v, got %v", ErrSecretRequired, err)
+		}
+	}
+}
+
+func TestStoreServeHTTPSecret(t *testing.T) {
+	s, _ := newTestStore(t, "node", Secret("secret"))
+	for secret, code := range map[string]int{
+		"":       http.StatusUnauthorized,
+		"wrong":  http.StatusUnauthorized,
+		"secret": http.StatusBadRequest,
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/_raftstore/join", strings.NewReader("invalid"))
+		if secret != "" {
+			r.Header.Set(headerSecret, secret)
+		}
+		s.ServeHTTP(w, r)
+		if w.Code != code {
+			t.Errorf("secret %q: expected status code %d, got %d", secret, code, w.Code)
+		}
+	}
+}
+
+func TestStoreClose(t *testing.T) {
+	s, _ := newTestStore(t, "node", GCInterval(0))
+	for i := 0; i < 2; i++ {
+		if err := s.Close(); err != nil {
+			t.Errorf("expected non error, got %s", err)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package redisstore
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/clevergo/captchas"
+)
+
+// attemptScript increments the attempts and sets the same TTL as the
+// captcha, returns -1 if the captcha doesn't exist.
+var attemptScript = newScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return -1
+end
+local n = redis.call("INCR", KEYS[2])
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl > 0 then
+	redis.call("PEXPIRE", KEYS[2], ttl)
+end
+return n
+`)
+
+func (s *store) getAttemptsKey(id string) string {
+	return s.getKey(id) + ":attempts"
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (s *store) Attempt(id string) (int, error) {
+	return s.AttemptContext(context.Background(), id)
+}
+
+// AttemptContext implements ContextAttemptStore.AttemptContext.
+func (s *store) AttemptContext(ctx context.Context, id string) (int, error) {
+	key := s.getKey(id)
+	reply, err := attemptScript.run(ctx, s.client, []string{key, s.getAttemptsKey(id)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment attempts of key %s: %w", key, err)
+	}
+	n, err := toInt(reply)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return 0, captchas.ErrIncorrectCaptcha
+	}
+	return int(n), nil
+}
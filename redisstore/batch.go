@@ -0,0 +1,73 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package redisstore
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetMulti implements BatchStore.GetMulti, the commands are sent in a single
+// pipeline, each captcha is consumed by a Lua script if clear is true, so
+// that it works on Redis Cluster as well.
+func (s *store) GetMulti(ids []string, clear bool) (map[string]string, error) {
+	return s.GetMultiContext(context.Background(), ids, clear)
+}
+
+// GetMultiContext implements ContextBatchStore.GetMultiContext.
+func (s *store) GetMultiContext(ctx context.Context, ids []string, clear bool) (map[string]string, error) {
+	var results []Result
+	if clear {
+		keys := make([][]string, len(ids))
+		for i, id := range ids {
+			keys[i] = s.consumeKeys(id)
+		}
+		results = consumeScript.runMulti(ctx, s.client, keys, nil)
+	} else {
+		cmds := make([]Command, len(ids))
+		for i, id := range ids {
+			cmds[i] = command("GET", []string{s.getKey(id)})
+		}
+		results = s.client.DoMulti(ctx, cmds...)
+	}
+
+	answers := make(map[string]string, len(ids))
+	for i, res := range results {
+		if res.Err == Nil {
+			continue
+		}
+		if res.Err != nil {
+			return nil, fmt.Errorf("failed to get key %s: %w", s.getKey(ids[i]), res.Err)
+		}
+		val, err := toString(res.Val)
+		if err != nil {
+			return nil, err
+		}
+		answers[ids[i]] = val
+	}
+	return answers, nil
+}
+
+// SetMulti implements BatchStore.SetMulti, the commands are sent in a single
+// pipeline.
+func (s *store) SetMulti(items map[string]string) error {
+	return s.SetMultiContext(context.Background(), items)
+}
+
+// SetMultiContext implements ContextBatchStore.SetMultiContext.
+func (s *store) SetMultiContext(ctx context.Context, items map[string]string) error {
+	keys := make([][]string, 0, len(items))
+	args := make([][]string, 0, len(items))
+	for id, answer := range items {
+		keys = append(keys, s.setKeys(id))
+		args = append(args, s.setArgs(answer, s.expiration))
+	}
+	for _, res := range setScript.runMulti(ctx, s.client, keys, args) {
+		if res.Err != nil {
+			return fmt.Errorf("failed to set keys: %w", res.Err)
+		}
+	}
+	return nil
+}
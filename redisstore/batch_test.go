@@ -0,0 +1,54 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package redisstore
+
+import (
+	"testing"
+
+	"github.com/clevergo/captchas"
+)
+
+func TestStoreMulti(t *testing.T) {
+	s := New(testClient, Prefix("batch")).(*store)
+	items := map[string]string{"foo": "1", "bar": "2"}
+	if err := captchas.SetMulti(s, items); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if err := s.SetWithMetadata("baz", "3", 0, captchas.Metadata{Driver: "digit"}); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+
+	answers, err := captchas.GetMulti(s, []string{"foo", "bar", "baz", "qux"}, false)
+	if err != nil {
+		t.Fatalf("failed to get: %s", err)
+	}
+	if len(answers) != 3 || answers["foo"] != "1" || answers["bar"] != "2" || answers["baz"] != "3" {
+		t.Errorf("unexpected answers %v", answers)
+	}
+
+	answers, err = captchas.GetMulti(s, []string{"foo", "baz", "qux"}, true)
+	if err != nil {
+		t.Fatalf("failed to get: %s", err)
+	}
+	if len(answers) != 2 || answers["foo"] != "1" || answers["baz"] != "3" {
+		t.Errorf("unexpected answers %v", answers)
+	}
+	for _, id := range []string{"foo", "baz"} {
+		if ok, _ := s.Exists(id); ok {
+			t.Errorf("expected %s is consumed", id)
+		}
+	}
+	if n := testClient.Exists(s.getMetadataKey("baz")).Val(); n != 0 {
+		t.Error("expected metadata is deleted")
+	}
+
+	answers, err = captchas.GetMulti(s, []string{"foo", "bar"}, true)
+	if err != nil {
+		t.Fatalf("failed to get: %s", err)
+	}
+	if len(answers) != 1 || answers["bar"] != "2" {
+		t.Errorf("unexpected answers %v", answers)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Nil is the error returned by clients when the reply is nil, such as
+// getting a key that doesn't exist.
+var Nil = errors.New("redisstore: nil")
+
+// Client is a small interface of redis commands, so that the store works
+// with any client library or version, see the goredisv8, goredisv9 and
+// rueidisadapter packages.
+type Client interface {
+	// Do sends the command, and returns the reply, strings, integers and
+	// arrays of them, or Nil if the reply is nil.
+	Do(ctx context.Context, cmd Command) (interface{}, error)
+
+	// DoMulti sends the commands in a pipeline.
+	DoMulti(ctx context.Context, cmds ...Command) []Result
+}
+
+// ClusterClient is a Client of Redis Cluster.
+type ClusterClient interface {
+	Client
+
+	// ForEachNode calls fn with the client of each node concurrently, and
+	// returns the first error. Nodes may be replicas of each other.
+	ForEachNode(ctx context.Context, fn func(ctx context.Context, client Client) error) error
+}
+
+// Command is a redis command, it is sent as tokens, followed by keys and
+// args, such as "EVALSHA sha 1 key arg".
+type Command struct {
+	// Tokens are the name and the leading args of the command.
+	Tokens []string
+
+	// Keys are the keys of the command, which are used for routing on
+	// Redis Cluster.
+	Keys []string
+
+	Args []string
+}
+
+// Strings returns all of tokens, keys and args of the command.
+func (c Command) Strings() []string {
+	s := make([]string, 0, len(c.Tokens)+len(c.Keys)+len(c.Args))
+	s = append(s, c.Tokens...)
+	s = append(s, c.Keys...)
+	return append(s, c.Args...)
+}
+
+// Interfaces is the same as Strings, but returns interfaces, which are
+// accepted by go-redis.
+func (c Command) Interfaces() []interface{} {
+	s := make([]interface{}, 0, len(c.Tokens)+len(c.Keys)+len(c.Args))
+	for _, v := range c.Strings() {
+		s = append(s, v)
+	}
+	return s
+}
+
+// Result is the result of a pipelined command.
+type Result struct {
+	Val interface{}
+	Err error
+}
+
+func command(name string, keys []string, args ...string) Command {
+	return Command{Tokens: []string{name}, Keys: keys, Args: args}
+}
+
+func milliseconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Millisecond), 10)
+}
+
+func toString(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case []byte:
+		return string(val), nil
+	}
+	return "", fmt.Errorf("redisstore: unexpected reply %v of type %T", v, v)
+}
+
+func toInt(v interface{}) (int64, error) {
+	switch val := v.(type) {
+	case int64:
+		return val, nil
+	case string:
+		return strconv.ParseInt(val, 10, 64)
+	}
+	return 0, fmt.Errorf("redisstore: unexpected reply %v of type %T", v, v)
+}
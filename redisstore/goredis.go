@@ -0,0 +1,65 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package redisstore
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v7"
+)
+
+// goredis is the Client of go-redis v7.
+type goredis struct {
+	client redis.UniversalClient
+}
+
+func (c goredis) withContext(ctx context.Context) redis.UniversalClient {
+	switch client := c.client.(type) {
+	case *redis.Client:
+		return client.WithContext(ctx)
+	case *redis.ClusterClient:
+		return client.WithContext(ctx)
+	}
+	return c.client
+}
+
+func (c goredis) Do(ctx context.Context, cmd Command) (interface{}, error) {
+	reply, err := c.withContext(ctx).Do(cmd.Interfaces()...).Result()
+	if err == redis.Nil {
+		err = Nil
+	}
+	return reply, err
+}
+
+func (c goredis) DoMulti(ctx context.Context, cmds ...Command) []Result {
+	pipe := c.client.Pipeline()
+	replies := make([]*redis.Cmd, len(cmds))
+	for i, cmd := range cmds {
+		replies[i] = pipe.Do(cmd.Interfaces()...)
+	}
+	// errors are set to each command.
+	pipe.ExecContext(ctx)
+
+	results := make([]Result, len(cmds))
+	for i, reply := range replies {
+		results[i].Val, results[i].Err = reply.Result()
+		if results[i].Err == redis.Nil {
+			results[i].Err = Nil
+		}
+	}
+	return results
+}
+
+// goredisCluster is the ClusterClient of go-redis v7.
+type goredisCluster struct {
+	goredis
+	cluster *redis.ClusterClient
+}
+
+func (c goredisCluster) ForEachNode(ctx context.Context, fn func(ctx context.Context, client Client) error) error {
+	return c.cluster.ForEachMaster(func(client *redis.Client) error {
+		return fn(ctx, goredis{client})
+	})
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package goredisv8 adapts go-redis v8 clients to redisstore.Client.
+package goredisv8
+
+import (
+	"context"
+
+	"github.com/clevergo/captchas/redisstore"
+	"github.com/go-redis/redis/v8"
+)
+
+// New returns a redisstore.Client of the given client, which is a
+// redisstore.ClusterClient if the given client is a *redis.ClusterClient.
+func New(client redis.UniversalClient) redisstore.Client {
+	if cc, ok := client.(*redis.ClusterClient); ok {
+		return clusterClient{adapter{cc}, cc}
+	}
+	return adapter{client}
+}
+
+type adapter struct {
+	client redis.UniversalClient
+}
+
+func (c adapter) Do(ctx context.Context, cmd redisstore.Command) (interface{}, error) {
+	reply, err := c.client.Do(ctx, cmd.Interfaces()...).Result()
+	if err == redis.Nil {
+		err = redisstore.Nil
+	}
+	return reply, err
+}
+
+func (c adapter) DoMulti(ctx context.Context, cmds ...redisstore.Command) []redisstore.Result {
+	pipe := c.client.Pipeline()
+	replies := make([]*redis.Cmd, len(cmds))
+	for i, cmd := range cmds {
+		replies[i] = pipe.Do(ctx, cmd.Interfaces()...)
+	}
+	// errors are set to each command.
+	pipe.Exec(ctx)
+
+	results := make([]redisstore.Result, len(cmds))
+	for i, reply := range replies {
+		results[i].Val, results[i].Err = reply.Result()
+		if results[i].Err == redis.Nil {
+			results[i].Err = redisstore.Nil
+		}
+	}
+	return results
+}
+
+type clusterClient struct {
+	adapter
+	cluster *redis.ClusterClient
+}
+
+func (c clusterClient) ForEachNode(ctx context.Context, fn func(ctx context.Context, client redisstore.Client) error) error {
+	return c.cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+		return fn(ctx, adapter{client})
+	})
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package redisstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+func (s *store) getMetadataKey(id string) string {
+	return s.getKey(id) + ":metadata"
+}
+
+// setMetadataScript sets the captcha and its metadata with the same TTL,
+// and deletes its attempts.
+var setMetadataScript = newScript(`
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[3])
+redis.call("SET", KEYS[2], ARGV[2], "PX", ARGV[3])
+redis.call("DEL", KEYS[3])
+return 1
+`)
+
+// SetWithMetadata implements MetadataStore.SetWithMetadata, the metadata is
+// encoded by the codec and saved in a separate key of the same TTL.
+func (s *store) SetWithMetadata(id, value string, ttl time.Duration, md captchas.Metadata) error {
+	return s.SetWithMetadataContext(context.Background(), id, value, ttl, md)
+}
+
+// SetWithMetadataContext implements
+// ContextMetadataStore.SetWithMetadataContext.
+func (s *store) SetWithMetadataContext(ctx context.Context, id, value string, ttl time.Duration, md captchas.Metadata) error {
+	if ttl <= 0 {
+		ttl = s.expiration
+	}
+	data, err := s.codec.Marshal(md)
+	if err != nil {
+		return err
+	}
+	key := s.getKey(id)
+	keys := []string{key, s.getMetadataKey(id), s.getAttemptsKey(id)}
+	if _, err = setMetadataScript.run(ctx, s.client, keys, value, string(data), milliseconds(ttl)); err != nil {
+		return fmt.Errorf("failed to set key %s: %w", key, err)
+	}
+	return nil
+}
+
+// getMetadataScript returns the metadata, an empty string if the captcha
+// has no metadata, or nil if the captcha doesn't exist.
+var getMetadataScript = newScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return false
+end
+return redis.call("GET", KEYS[2]) or ""
+`)
+
+// GetMetadata implements MetadataStore.GetMetadata, captchas saved without
+// metadata have zero metadata.
+func (s *store) GetMetadata(id string) (captchas.Metadata, error) {
+	return s.GetMetadataContext(context.Background(), id)
+}
+
+// GetMetadataContext implements ContextMetadataStore.GetMetadataContext.
+func (s *store) GetMetadataContext(ctx context.Context, id string) (captchas.Metadata, error) {
+	var md captchas.Metadata
+	key := s.getKey(id)
+	keys := []string{key, s.getMetadataKey(id)}
+	reply, err := getMetadataScript.run(ctx, s.client, keys)
+	if err == Nil {
+		return md, captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return md, fmt.Errorf("failed to get key %s: %w", key, err)
+	}
+	data, err := toString(reply)
+	if err != nil || data == "" {
+		return md, err
+	}
+	err = s.codec.Unmarshal([]byte(data), &md)
+	return md, err
+}
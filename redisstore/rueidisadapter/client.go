@@ -0,0 +1,78 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package rueidisadapter adapts rueidis clients to redisstore.Client.
+package rueidisadapter
+
+import (
+	"context"
+	"sync"
+
+	"github.com/clevergo/captchas/redisstore"
+	"github.com/redis/rueidis"
+)
+
+// New returns a redisstore.Client of the given client, which is a
+// redisstore.ClusterClient if the given client is in cluster mode.
+func New(client rueidis.Client) redisstore.Client {
+	if client.Mode() == rueidis.ClientModeCluster {
+		return clusterClient{adapter{client}}
+	}
+	return adapter{client}
+}
+
+type adapter struct {
+	client rueidis.Client
+}
+
+func (c adapter) build(cmd redisstore.Command) rueidis.Completed {
+	return c.client.B().Arbitrary(cmd.Tokens...).Keys(cmd.Keys...).Args(cmd.Args...).Build()
+}
+
+func (c adapter) Do(ctx context.Context, cmd redisstore.Command) (interface{}, error) {
+	reply, err := c.client.Do(ctx, c.build(cmd)).ToAny()
+	if rueidis.IsRedisNil(err) {
+		err = redisstore.Nil
+	}
+	return reply, err
+}
+
+func (c adapter) DoMulti(ctx context.Context, cmds ...redisstore.Command) []redisstore.Result {
+	completed := make(rueidis.Commands, len(cmds))
+	for i, cmd := range cmds {
+		completed[i] = c.build(cmd)
+	}
+	results := make([]redisstore.Result, len(cmds))
+	for i, reply := range c.client.DoMulti(ctx, completed...) {
+		results[i].Val, results[i].Err = reply.ToAny()
+		if rueidis.IsRedisNil(results[i].Err) {
+			results[i].Err = redisstore.Nil
+		}
+	}
+	return results
+}
+
+type clusterClient struct {
+	adapter
+}
+
+// ForEachNode calls fn with the client of each node, including replicas.
+func (c clusterClient) ForEachNode(ctx context.Context, fn func(ctx context.Context, client redisstore.Client) error) error {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var err error
+	for _, node := range c.client.Nodes() {
+		wg.Add(1)
+		go func(node rueidis.Client) {
+			defer wg.Done()
+			if e := fn(ctx, adapter{node}); e != nil {
+				once.Do(func() {
+					err = e
+				})
+			}
+		}(node)
+	}
+	wg.Wait()
+	return err
+}
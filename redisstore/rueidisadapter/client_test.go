@@ -0,0 +1,44 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package rueidisadapter
+
+import (
+	"testing"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/redisstore"
+	"github.com/redis/rueidis"
+)
+
+func TestStore(t *testing.T) {
+	client, err := rueidis.NewClient(rueidis.ClientOption{
+		InitAddress:  []string{"localhost:6379"},
+		DisableCache: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %s", err)
+	}
+	defer client.Close()
+	s := redisstore.NewWithClient(New(client), redisstore.Prefix("rueidisadapter"))
+	if _, err := s.Get("foo", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	if err := captchas.SetMulti(s, map[string]string{"foo": "bar", "fizz": "buzz"}); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	answers, err := captchas.GetMulti(s, []string{"foo", "fizz"}, false)
+	if err != nil {
+		t.Fatalf("failed to get: %s", err)
+	}
+	if len(answers) != 2 || answers["foo"] != "bar" || answers["fizz"] != "buzz" {
+		t.Errorf("unexpected answers %v", answers)
+	}
+	if value, err := s.Get("foo", true); err != nil || value != "bar" {
+		t.Errorf("expected value %q, got %q, %v", "bar", value, err)
+	}
+	if _, err := s.Get("foo", true); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
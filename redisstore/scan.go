@@ -0,0 +1,152 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package redisstore
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+// Scan implements ScanStore.Scan by the SCAN command, the cursor is the
+// cursor of Redis and the limit is a hint, a page may contain more or less
+// captchas, and captchas saved during scanning may be missed. On Redis
+// Cluster, all nodes are scanned at once, there is a single page only.
+func (s *store) Scan(cursor string, limit int) ([]captchas.Entry, string, error) {
+	return s.ScanContext(context.Background(), cursor, limit)
+}
+
+// ScanContext implements ContextScanStore.ScanContext.
+func (s *store) ScanContext(ctx context.Context, cursor string, limit int) ([]captchas.Entry, string, error) {
+	keys, next, err := s.scanKeys(ctx, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var ids []string
+	var cmds []Command
+	for _, key := range keys {
+		id, ok := s.parseID(key)
+		if !ok {
+			continue
+		}
+		ids = append(ids, id)
+		cmds = append(cmds, command("PTTL", []string{key}), command("GET", []string{s.getMetadataKey(id)}))
+	}
+	if len(ids) == 0 {
+		return nil, next, nil
+	}
+	results := s.client.DoMulti(ctx, cmds...)
+
+	now := time.Now()
+	entries := make([]captchas.Entry, 0, len(ids))
+	for i, id := range ids {
+		ttl, metadata := results[2*i], results[2*i+1]
+		if ttl.Err != nil {
+			return nil, "", ttl.Err
+		}
+		// the key is deleted or expired, or has no expiration.
+		ms, err := toInt(ttl.Val)
+		if err != nil {
+			return nil, "", err
+		}
+		if ms <= 0 {
+			continue
+		}
+		entry := captchas.Entry{ID: id, Expiration: now.Add(time.Duration(ms) * time.Millisecond)}
+		if metadata.Err == nil {
+			data, err := toString(metadata.Val)
+			if err != nil {
+				return nil, "", err
+			}
+			if err = s.codec.Unmarshal([]byte(data), &entry.Metadata); err != nil {
+				return nil, "", err
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, next, nil
+}
+
+func (s *store) scanKeys(ctx context.Context, cursor string, limit int) ([]string, string, error) {
+	match := s.prefix + ":*"
+	if cc, ok := s.client.(ClusterClient); ok {
+		var mu sync.Mutex
+		seen := make(map[string]bool)
+		var keys []string
+		err := cc.ForEachNode(ctx, func(ctx context.Context, c Client) error {
+			next := "0"
+			for {
+				page, cursor, err := scan(ctx, c, next, match, limit)
+				if err != nil {
+					return err
+				}
+				mu.Lock()
+				for _, key := range page {
+					if !seen[key] {
+						seen[key] = true
+						keys = append(keys, key)
+					}
+				}
+				mu.Unlock()
+				if cursor == "0" {
+					return nil
+				}
+				next = cursor
+			}
+		})
+		return keys, "", err
+	}
+
+	if cursor == "" {
+		cursor = "0"
+	}
+	keys, next, err := scan(ctx, s.client, cursor, match, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	if next == "0" {
+		next = ""
+	}
+	return keys, next, nil
+}
+
+// scan sends a SCAN command, and returns the keys and the next cursor.
+func scan(ctx context.Context, c Client, cursor, match string, limit int) ([]string, string, error) {
+	if _, err := strconv.ParseUint(cursor, 10, 64); err != nil {
+		return nil, "", err
+	}
+	cmd := command("SCAN", nil, "MATCH", match)
+	cmd.Tokens = append(cmd.Tokens, cursor)
+	if limit > 0 {
+		cmd.Args = append(cmd.Args, "COUNT", strconv.Itoa(limit))
+	}
+	reply, err := c.Do(ctx, cmd)
+	if err != nil {
+		return nil, "", err
+	}
+	page, ok := reply.([]interface{})
+	if !ok || len(page) != 2 {
+		return nil, "", fmt.Errorf("redisstore: unexpected reply %v of SCAN", reply)
+	}
+	next, err := toString(page[0])
+	if err != nil {
+		return nil, "", err
+	}
+	values, _ := page[1].([]interface{})
+	keys := make([]string, 0, len(values))
+	for _, v := range values {
+		key, err := toString(v)
+		if err != nil {
+			return nil, "", err
+		}
+		keys = append(keys, key)
+	}
+	return keys, next, nil
+}
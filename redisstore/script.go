@@ -0,0 +1,75 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package redisstore
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// script is a Lua script, which is sent by EVALSHA, and by EVAL if the
+// script is not cached by the server yet.
+type script struct {
+	src string
+	sha string
+}
+
+func newScript(src string) *script {
+	h := sha1.Sum([]byte(src))
+	return &script{src: src, sha: hex.EncodeToString(h[:])}
+}
+
+func (s *script) evalSha(keys []string, args ...string) Command {
+	return Command{
+		Tokens: []string{"EVALSHA", s.sha, strconv.Itoa(len(keys))},
+		Keys:   keys,
+		Args:   args,
+	}
+}
+
+func (s *script) eval(keys []string, args ...string) Command {
+	return Command{
+		Tokens: []string{"EVAL", s.src, strconv.Itoa(len(keys))},
+		Keys:   keys,
+		Args:   args,
+	}
+}
+
+func (s *script) run(ctx context.Context, client Client, keys []string, args ...string) (interface{}, error) {
+	reply, err := client.Do(ctx, s.evalSha(keys, args...))
+	if isNoScript(err) {
+		return client.Do(ctx, s.eval(keys, args...))
+	}
+	return reply, err
+}
+
+// runMulti runs the script with each of the keys and the arguments of the
+// same index in a pipeline, args can be nil if the script takes keys only.
+func (s *script) runMulti(ctx context.Context, client Client, keys [][]string, args [][]string) []Result {
+	argsOf := func(i int) []string {
+		if args == nil {
+			return nil
+		}
+		return args[i]
+	}
+	cmds := make([]Command, len(keys))
+	for i := range keys {
+		cmds[i] = s.evalSha(keys[i], argsOf(i)...)
+	}
+	results := client.DoMulti(ctx, cmds...)
+	for i, res := range results {
+		if isNoScript(res.Err) {
+			results[i].Val, results[i].Err = client.Do(ctx, s.eval(keys[i], argsOf(i)...))
+		}
+	}
+	return results
+}
+
+func isNoScript(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT")
+}
@@ -5,7 +5,10 @@
 package redisstore
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"strings"
 	"time"
 
 	"github.com/clevergo/captchas"
@@ -29,18 +32,96 @@ func Expiration(expiration time.Duration) Option {
 	}
 }
 
+// HashTag reports whether to wrap captcha IDs in hash tags, such as
+// "captchas:{id}", defaults to true for Redis Cluster, and false otherwise.
+func HashTag(v bool) Option {
+	return func(s *store) {
+		s.hashTag = v
+	}
+}
+
+// DB selects the database, the store uses a client derived from the given
+// one, the derived client is closed by Close of the store. It takes effect
+// on the go-redis v7 client of New and NewFailover only.
+func DB(db int) Option {
+	return func(s *store) {
+		c, ok := s.client.(goredis)
+		if !ok {
+			return
+		}
+		client, ok := c.client.(*redis.Client)
+		if !ok {
+			return
+		}
+		opt := *client.Options()
+		opt.DB = db
+		if s.closer != nil {
+			s.closer.Close()
+		}
+		client = redis.NewClient(&opt)
+		s.client = goredis{client}
+		s.closer = client
+	}
+}
+
+// Codec sets the codec of metadata, defaults to JSON.
+func Codec(codec captchas.Codec) Option {
+	return func(s *store) {
+		s.codec = codec
+	}
+}
+
 type store struct {
-	client     *redis.Client
+	client     Client
 	expiration time.Duration
 	prefix     string
+	hashTag    bool
+	codec      captchas.Codec
+	closer     io.Closer
 }
 
-// New returns a redis store.
+// New returns a redis store of a go-redis v7 client.
 func New(client *redis.Client, opts ...Option) captchas.Store {
+	return newStore(goredis{client}, opts)
+}
+
+// NewCluster returns a redis store of a go-redis v7 client of Redis
+// Cluster.
+func NewCluster(client *redis.ClusterClient, opts ...Option) captchas.Store {
+	return newStore(goredisCluster{goredis{client}, client}, opts)
+}
+
+// NewFailover returns a redis store of Redis Sentinel, the client created
+// from the given options follows the master on failover, and retries the
+// commands that fail during failover, such as READONLY and LOADING errors,
+// 3 times unless MaxRetries is set, -1 disables retries. The client is
+// closed by Close of the store.
+func NewFailover(opt *redis.FailoverOptions, opts ...Option) captchas.Store {
+	o := *opt
+	if o.MaxRetries == 0 {
+		o.MaxRetries = 3
+	}
+	client := redis.NewFailoverClient(&o)
+	return newStore(goredis{client}, append([]Option{func(s *store) {
+		s.closer = client
+	}}, opts...))
+}
+
+// NewWithClient returns a redis store of the given client. On Redis
+// Cluster, captcha IDs are wrapped in hash tags, such as "captchas:{id}",
+// so that the keys of a captcha are in the same slot.
+func NewWithClient(client Client, opts ...Option) captchas.Store {
+	return newStore(client, opts)
+}
+
+func newStore(client Client, opts []Option) *store {
+	_, cluster := client.(ClusterClient)
 	s := &store{
 		client:     client,
 		prefix:     "captchas",
 		expiration: 10 * time.Minute,
+		hashTag:    cluster,
+		codec:      captchas.JSONCodec,
 	}
 
 	for _, f := range opts {
@@ -50,43 +131,211 @@ func New(client *redis.Client, opts ...Option) captchas.Store {
 	return s
 }
 
+// Close closes the client if it is created by the store.
+func (s *store) Close() error {
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
+
 func (s *store) getKey(id string) string {
+	if s.hashTag {
+		return s.prefix + ":{" + id + "}"
+	}
 	return s.prefix + ":" + id
 }
 
+// parseID returns the captcha ID of the given key, reports false if the key
+// is not a captcha key, such as the metadata key.
+func (s *store) parseID(key string) (string, bool) {
+	id := strings.TrimPrefix(key, s.prefix+":")
+	if s.hashTag {
+		if len(id) < 2 || id[0] != '{' || id[len(id)-1] != '}' {
+			return "", false
+		}
+		return id[1 : len(id)-1], true
+	}
+	if strings.HasSuffix(id, ":metadata") || strings.HasSuffix(id, ":attempts") {
+		return "", false
+	}
+	return id, true
+}
+
 // Get implements Store.Get.
 func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// consumeScript gets and deletes the captcha atomically along with its
+// metadata and attempts, so that only one of concurrent consumers is able
+// to get the answer.
+var consumeScript = newScript(`
+local value = redis.call("GET", KEYS[1])
+if value then
+	redis.call("DEL", unpack(KEYS))
+end
+return value
+`)
+
+func (s *store) consumeKeys(id string) []string {
+	return []string{s.getKey(id), s.getMetadataKey(id), s.getAttemptsKey(id)}
+}
+
+// GetContext implements ContextStore.GetContext, the captcha is consumed
+// by a Lua script if clear is true.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
 	key := s.getKey(id)
-	tx := s.client.TxPipeline()
-	get := tx.Get(key)
-	var del *redis.IntCmd
+	var reply interface{}
+	var err error
 	if clear {
-		del = tx.Del(key)
+		reply, err = consumeScript.run(ctx, s.client, s.consumeKeys(id))
+	} else {
+		reply, err = s.client.Do(ctx, command("GET", []string{key}))
 	}
-	_, err := tx.Exec()
-	if err != nil {
-		return "", err
+	if err == Nil {
+		return "", captchas.ErrIncorrectCaptcha
 	}
-	val, err := get.Result()
 	if err != nil {
-		return "", fmt.Errorf("failed to get key: %s", key)
+		return "", fmt.Errorf("failed to get key %s: %w", key, err)
 	}
 
-	if clear {
-		if _, err = del.Result(); err != nil {
-			return "", fmt.Errorf("failed to delete key: %s", key)
-		}
-	}
-
-	return val, nil
+	return toString(reply)
 }
 
 // Set implements Store.Set.
 func (s *store) Set(id string, value string) error {
+	return s.SetContext(context.Background(), id, value)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, value string) error {
+	return s.set(ctx, id, value, s.expiration)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, value string, ttl time.Duration) error {
+	return s.SetWithTTLContext(context.Background(), id, value, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, value string, ttl time.Duration) error {
+	return s.set(ctx, id, value, ttl)
+}
+
+// setScript sets the captcha with the arguments of SET, and deletes its
+// attempts, so that a reused ID doesn't inherit them. Returns nil if the
+// captcha isn't set, such as the ID is taken with NX.
+var setScript = newScript(`
+local reply = redis.call("SET", KEYS[1], unpack(ARGV))
+if reply then
+	redis.call("DEL", KEYS[2])
+end
+return reply
+`)
+
+func (s *store) setKeys(id string) []string {
+	return []string{s.getKey(id), s.getAttemptsKey(id)}
+}
+
+func (s *store) setArgs(value string, ttl time.Duration) []string {
+	args := []string{value}
+	if ttl > 0 {
+		args = append(args, "PX", milliseconds(ttl))
+	}
+	return args
+}
+
+func (s *store) set(ctx context.Context, id, value string, ttl time.Duration) error {
+	key := s.getKey(id)
+	if _, err := setScript.run(ctx, s.client, s.setKeys(id), s.setArgs(value, ttl)...); err != nil {
+		return fmt.Errorf("failed to set key %s: %w", key, err)
+	}
+	return nil
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *store) SetIfNotExists(id, value string, ttl time.Duration) (bool, error) {
+	return s.SetIfNotExistsContext(context.Background(), id, value, ttl)
+}
+
+// SetIfNotExistsContext implements ContextNXStore.SetIfNotExistsContext.
+func (s *store) SetIfNotExistsContext(ctx context.Context, id, value string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		ttl = s.expiration
+	}
+	key := s.getKey(id)
+	args := append(s.setArgs(value, ttl), "NX")
+	_, err := setScript.run(ctx, s.client, s.setKeys(id), args...)
+	if err == Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to set key %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// touchScript sets the TTL of the captcha, its metadata and attempts,
+// returns 0 if the captcha doesn't exist.
+var touchScript = newScript(`
+local n = redis.call("PEXPIRE", KEYS[1], ARGV[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[2], ARGV[1])
+	redis.call("PEXPIRE", KEYS[3], ARGV[1])
+end
+return n
+`)
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	return s.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext implements ContextTouchStore.TouchContext.
+func (s *store) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
 	key := s.getKey(id)
-	_, err := s.client.Set(key, value, s.expiration).Result()
+	keys := []string{key, s.getMetadataKey(id), s.getAttemptsKey(id)}
+	reply, err := touchScript.run(ctx, s.client, keys, milliseconds(ttl))
 	if err != nil {
-		return fmt.Errorf("failed to set key: %s", key)
+		return fmt.Errorf("failed to touch key %s: %w", key, err)
+	}
+	n, err := toInt(reply)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return captchas.ErrIncorrectCaptcha
 	}
 	return nil
 }
+
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext implements ContextDeleteStore.DeleteContext.
+func (s *store) DeleteContext(ctx context.Context, id string) error {
+	key := s.getKey(id)
+	if _, err := s.client.Do(ctx, command("DEL", s.consumeKeys(id))); err != nil {
+		return fmt.Errorf("failed to delete key %s: %w", key, err)
+	}
+	return nil
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *store) Exists(id string) (bool, error) {
+	return s.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext implements ContextExistsStore.ExistsContext.
+func (s *store) ExistsContext(ctx context.Context, id string) (bool, error) {
+	key := s.getKey(id)
+	reply, err := s.client.Do(ctx, command("EXISTS", []string{key}))
+	if err != nil {
+		return false, fmt.Errorf("failed to check key %s: %w", key, err)
+	}
+	n, err := toInt(reply)
+	return n > 0, err
+}
@@ -5,9 +5,14 @@
 package redisstore
 
 import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/clevergo/captchas"
 	"github.com/go-redis/redis/v7"
 )
 
@@ -33,6 +38,15 @@ func TestPrefixOption(t *testing.T) {
 		t.Errorf("expected prefix %s, got %s", prefix, s.prefix)
 	}
 }
+
+func TestCodecOption(t *testing.T) {
+	s := &store{}
+	Codec(captchas.JSONCodec)(s)
+	if s.codec != captchas.JSONCodec {
+		t.Errorf("expected codec %v, got %v", captchas.JSONCodec, s.codec)
+	}
+}
+
 func TestGetKey(t *testing.T) {
 	prefix := "foo"
 	s := &store{prefix: prefix}
@@ -84,3 +98,164 @@ func TestStoreGet(t *testing.T) {
 func TestStoreSet(t *testing.T) {
 	// TBD
 }
+
+func TestStoreConsume(t *testing.T) {
+	s := New(testClient)
+	if err := s.Set("foo", "bar"); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	var wg sync.WaitGroup
+	var consumed int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Get("foo", true); err == nil {
+				atomic.AddInt32(&consumed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if consumed != 1 {
+		t.Errorf("expected the captcha to be consumed once, got %d", consumed)
+	}
+}
+
+func TestNewCluster(t *testing.T) {
+	client := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"localhost:7000"}})
+	defer client.Close()
+	s := NewCluster(client, Prefix("foo")).(*store)
+	if key := s.getKey("bar"); key != "foo:{bar}" {
+		t.Errorf("expected key %s, got %s", "foo:{bar}", key)
+	}
+	if key := s.getMetadataKey("bar"); key != "foo:{bar}:metadata" {
+		t.Errorf("expected key %s, got %s", "foo:{bar}:metadata", key)
+	}
+	cases := map[string]string{"foo:{bar}": "bar", "foo:{bar}:metadata": "", "foo:{bar}:attempts": ""}
+	for key, expected := range cases {
+		if id, _ := s.parseID(key); id != expected {
+			t.Errorf("expected ID %q of key %s, got %q", expected, key, id)
+		}
+	}
+}
+
+func TestNewFailover(t *testing.T) {
+	s := NewFailover(&redis.FailoverOptions{
+		MasterName:    "mymaster",
+		SentinelAddrs: []string{"localhost:26379"},
+	}).(*store)
+	if retries := s.client.(goredis).client.(*redis.Client).Options().MaxRetries; retries != 3 {
+		t.Errorf("expected max retries %d, got %d", 3, retries)
+	}
+	if err := captchas.Close(s); err != nil {
+		t.Errorf("failed to close: %s", err)
+	}
+}
+
+func TestHashTag(t *testing.T) {
+	s := New(testClient, HashTag(true)).(*store)
+	if key := s.getKey("foo"); key != "captchas:{foo}" {
+		t.Errorf("expected key %q, got %q", "captchas:{foo}", key)
+	}
+	if id, ok := s.parseID("captchas:{foo}"); !ok || id != "foo" {
+		t.Errorf("expected id %q, got %q", "foo", id)
+	}
+	s = NewCluster(redis.NewClusterClient(&redis.ClusterOptions{}), HashTag(false)).(*store)
+	if key := s.getKey("foo"); key != "captchas:foo" {
+		t.Errorf("expected key %q, got %q", "captchas:foo", key)
+	}
+}
+
+func TestDB(t *testing.T) {
+	s := New(testClient, DB(1)).(*store)
+	if db := s.client.(goredis).client.(*redis.Client).Options().DB; db != 1 {
+		t.Errorf("expected db %d, got %d", 1, db)
+	}
+	if testClient.Options().DB != 0 {
+		t.Error("expected the given client is not changed")
+	}
+	if err := s.Set("foo", "bar"); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("failed to close: %s", err)
+	}
+	if err := testClient.Ping().Err(); err != nil {
+		t.Errorf("expected the given client is not closed: %s", err)
+	}
+}
+
+func TestStoreAttempts(t *testing.T) {
+	s := New(testClient, Prefix("attempts")).(*store)
+	s.Delete("foo")
+	s.Set("foo", "bar")
+	s.Attempt("foo")
+	if n, _ := s.Attempt("foo"); n != 2 {
+		t.Errorf("expected attempts %d, got %d", 2, n)
+	}
+
+	// reusing the ID resets the attempts.
+	s.Set("foo", "bar")
+	if n, _ := s.Attempt("foo"); n != 1 {
+		t.Errorf("expected attempts to be reset, got %d", n)
+	}
+	testClient.Del(s.getKey("foo"))
+	if ok, _ := s.SetIfNotExists("foo", "bar", time.Minute); !ok {
+		t.Fatal("expected the captcha to be set")
+	}
+	if n, _ := s.Attempt("foo"); n != 1 {
+		t.Errorf("expected attempts to be reset, got %d", n)
+	}
+	if ok, _ := s.SetIfNotExists("foo", "bar", time.Minute); ok {
+		t.Fatal("expected the ID to be taken")
+	}
+	if n, _ := s.Attempt("foo"); n != 2 {
+		t.Errorf("expected attempts to be kept, got %d", n)
+	}
+
+	if err := s.Touch("foo", time.Hour); err != nil {
+		t.Fatalf("failed to touch: %s", err)
+	}
+	if ttl := testClient.PTTL(s.getAttemptsKey("foo")).Val(); ttl <= time.Minute {
+		t.Errorf("expected the TTL of attempts to be extended, got %s", ttl)
+	}
+}
+
+func TestStoreContext(t *testing.T) {
+	s := New(testClient, Prefix("context")).(*store)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.SetIfNotExistsContext(ctx, "foo", "bar", time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if err := s.SetWithTTLContext(ctx, "foo", "bar", time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if err := s.SetWithMetadataContext(ctx, "foo", "bar", time.Minute, captchas.Metadata{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if _, err := s.AttemptContext(ctx, "foo"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if _, err := s.GetMetadataContext(ctx, "foo"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if err := s.TouchContext(ctx, "foo", time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if err := s.DeleteContext(ctx, "foo"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if _, err := s.ExistsContext(ctx, "foo"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if _, _, err := s.ScanContext(ctx, "", 10); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if err := s.SetMultiContext(ctx, map[string]string{"foo": "bar"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if _, err := s.GetMultiContext(ctx, []string{"foo"}, true); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package replicatedstore writes captchas to multiple stores, so that
+// captchas can still be verified if some of the stores are down.
+package replicatedstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+// Option is a function that receives a pointer of store.
+type Option func(*store)
+
+// Quorum sets the minimum number of stores that a captcha must be written
+// to, from 1 to the number of stores, defaults to 1.
+func Quorum(n int) Option {
+	return func(s *store) {
+		s.quorum = n
+	}
+}
+
+type store struct {
+	stores []captchas.Store
+	quorum int
+}
+
+// New returns a replicated store, stores are tried in the given order, the
+// first one is the preferred one.
+//
+// It panics if the quorum is less than 1 or greater than the number of
+// stores, since writes could never succeed or never fail.
+func New(stores []captchas.Store, opts ...Option) captchas.Store {
+	s := &store{
+		stores: stores,
+		quorum: 1,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	if s.quorum < 1 || s.quorum > len(stores) {
+		panic(fmt.Sprintf("replicatedstore: quorum %d is out of range [1, %d]", s.quorum, len(stores)))
+	}
+
+	return s
+}
+
+type result struct {
+	answer string
+	n      int
+	err    error
+}
+
+// each calls f on all stores concurrently, and returns the results in the
+// order of stores.
+func (s *store) each(f func(captchas.Store) result) []result {
+	results := make([]result, len(s.stores))
+	var wg sync.WaitGroup
+	for i, store := range s.stores {
+		wg.Add(1)
+		go func(i int, store captchas.Store) {
+			defer wg.Done()
+			results[i] = f(store)
+		}(i, store)
+	}
+	wg.Wait()
+	return results
+}
+
+// Get implements Store.Get, the captcha is consumed from all stores if clear
+// is true, so that it can't be reused on any of them.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	if clear {
+		return pick(s.each(func(store captchas.Store) result {
+			answer, err := captchas.GetContext(ctx, store, id, true)
+			return result{answer: answer, err: err}
+		}))
+	}
+
+	results := make([]result, 0, len(s.stores))
+	for _, store := range s.stores {
+		answer, err := captchas.GetContext(ctx, store, id, false)
+		if err == nil {
+			return answer, nil
+		}
+		results = append(results, result{err: err})
+	}
+	return pick(results)
+}
+
+// pick returns the first answer, otherwise returns the error reported by
+// healthy stores in preference to the failures of stores.
+func pick(results []result) (string, error) {
+	var err error
+	for _, r := range results {
+		if r.err == nil {
+			return r.answer, nil
+		}
+		if err == nil || (captchas.IsCaptchaError(r.err) && !captchas.IsCaptchaError(err)) {
+			err = r.err
+		}
+	}
+	return "", err
+}
+
+// Set implements Store.Set, it returns the first error if the captcha is
+// written to less than quorum stores.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	return s.set(func(store captchas.Store) error {
+		return captchas.SetContext(ctx, store, id, answer)
+	})
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.SetWithTTLContext(context.Background(), id, answer, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	return s.set(func(store captchas.Store) error {
+		return captchas.SetWithTTLContext(ctx, store, id, answer, ttl)
+	})
+}
+
+func (s *store) set(f func(captchas.Store) error) error {
+	return s.check(s.each(func(store captchas.Store) result {
+		return result{err: f(store)}
+	}))
+}
+
+// check returns the first error if less than quorum stores succeeded.
+func (s *store) check(results []result) error {
+	n := 0
+	var err error
+	for _, r := range results {
+		if r.err == nil {
+			n++
+		} else if err == nil {
+			err = r.err
+		}
+	}
+	if n >= s.quorum {
+		return nil
+	}
+	return err
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *store) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	return s.SetIfNotExistsContext(context.Background(), id, answer, ttl)
+}
+
+// SetIfNotExistsContext implements ContextNXStore.SetIfNotExistsContext, the
+// ID is taken if any of the stores reports so, in which case the captcha is
+// deleted from the stores that saved it. Otherwise it returns the first error
+// if the captcha is written to less than quorum stores.
+func (s *store) SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error) {
+	results := s.each(func(store captchas.Store) result {
+		ok, err := captchas.SetIfNotExistsContext(ctx, store, id, answer, ttl)
+		if ok {
+			return result{n: 1, err: err}
+		}
+		return result{err: err}
+	})
+	taken := false
+	for _, r := range results {
+		if r.n == 0 && r.err == nil {
+			taken = true
+		}
+	}
+	if taken {
+		for i, r := range results {
+			if r.n == 1 && r.err == nil {
+				captchas.DeleteContext(ctx, s.stores[i], id)
+			}
+		}
+		return false, nil
+	}
+	if err := s.check(results); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (s *store) Attempt(id string) (int, error) {
+	return s.AttemptContext(context.Background(), id)
+}
+
+// AttemptContext implements ContextAttemptStore.AttemptContext, attempts are
+// counted by all stores, and the maximum count is returned, so that the limit
+// holds while some of the stores are down.
+func (s *store) AttemptContext(ctx context.Context, id string) (int, error) {
+	results := s.each(func(store captchas.Store) result {
+		n, err := captchas.AttemptContext(ctx, store, id)
+		return result{n: n, err: err}
+	})
+	n := -1
+	for _, r := range results {
+		if r.err == nil && r.n > n {
+			n = r.n
+		}
+	}
+	if n >= 0 {
+		return n, nil
+	}
+	_, err := pick(results)
+	return 0, err
+}
+
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext implements ContextDeleteStore.DeleteContext, it returns the
+// first error if the captcha is deleted from less than quorum stores.
+func (s *store) DeleteContext(ctx context.Context, id string) error {
+	return s.set(func(store captchas.Store) error {
+		return captchas.DeleteContext(ctx, store, id)
+	})
+}
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	return s.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext implements ContextTouchStore.TouchContext, it returns the
+// first error if the captcha is touched in less than quorum stores.
+func (s *store) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
+	return s.set(func(store captchas.Store) error {
+		return captchas.TouchContext(ctx, store, id, ttl)
+	})
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *store) Exists(id string) (bool, error) {
+	return s.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext implements ContextExistsStore.ExistsContext, the captcha
+// exists if any of the stores reports so.
+func (s *store) ExistsContext(ctx context.Context, id string) (bool, error) {
+	var err error
+	healthy := false
+	for _, store := range s.stores {
+		ok, e := captchas.ExistsContext(ctx, store, id)
+		if e != nil {
+			if err == nil {
+				err = e
+			}
+			continue
+		}
+		if ok {
+			return true, nil
+		}
+		healthy = true
+	}
+	if healthy {
+		return false, nil
+	}
+	return false, err
+}
+
+// Close closes the stores that implement io.Closer, returns the first error.
+func (s *store) Close() error {
+	var err error
+	for _, store := range s.stores {
+		if err2 := captchas.Close(store); err == nil {
+			err = err2
+		}
+	}
+	return err
+}
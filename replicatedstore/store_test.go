@@ -0,0 +1,151 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package replicatedstore
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/memstore"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+type errStore struct{}
+
+func (s errStore) Get(id string, clear bool) (string, error) {
+	return "", errUnavailable
+}
+
+func (s errStore) Set(id, answer string) error {
+	return errUnavailable
+}
+
+func TestNew(t *testing.T) {
+	stores := []captchas.Store{memstore.New(), memstore.New()}
+	s := New(stores, Quorum(2))
+	rs, _ := s.(*store)
+	if len(rs.stores) != len(stores) {
+		t.Errorf("expected %d stores, got %d", len(stores), len(rs.stores))
+	}
+	if rs.quorum != 2 {
+		t.Errorf("expected quorum %d, got %d", 2, rs.quorum)
+	}
+}
+
+func TestNewInvalidQuorum(t *testing.T) {
+	stores := []captchas.Store{memstore.New(), memstore.New()}
+	for _, n := range []int{0, 3} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected a panic for quorum %d", n)
+				}
+			}()
+			New(stores, Quorum(n))
+		}()
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	stores := []captchas.Store{memstore.New(), errStore{}, memstore.New()}
+	s := New(stores)
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	// consumed from all stores.
+	for _, store := range append(stores, s) {
+		if _, err = store.Get("foo", false); err == nil {
+			t.Error("expected a non-nil error, got nil")
+		}
+	}
+}
+
+func TestStoreGetFailover(t *testing.T) {
+	stores := []captchas.Store{errStore{}, memstore.New(memstore.Expiration(-time.Second)), memstore.New()}
+	s := New(stores)
+	s.Set("foo", "bar")
+	if value, err := s.Get("foo", false); err != nil || value != "bar" {
+		t.Errorf("expected value %q, got %q, %v", "bar", value, err)
+	}
+	if value, err := s.Get("foo", true); err != nil || value != "bar" {
+		t.Errorf("expected value %q, got %q, %v", "bar", value, err)
+	}
+
+	s = New(stores[:2])
+	s.Set("foo", "bar")
+	if _, err := s.Get("foo", false); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+
+	s = New(stores[:1])
+	if _, err := s.Get("foo", true); err != errUnavailable {
+		t.Errorf("expected error %v, got %v", errUnavailable, err)
+	}
+}
+
+func TestStoreSet(t *testing.T) {
+	stores := []captchas.Store{errStore{}, memstore.New(), memstore.New()}
+	if err := New(stores, Quorum(2)).Set("foo", "bar"); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if err := New(stores, Quorum(3)).Set("foo", "bar"); err != errUnavailable {
+		t.Errorf("expected error %v, got %v", errUnavailable, err)
+	}
+}
+
+func TestStoreForwarding(t *testing.T) {
+	first, second := memstore.New(), memstore.New()
+	s := New([]captchas.Store{first, errStore{}, second})
+	nx := s.(captchas.NXStore)
+	if ok, err := nx.SetIfNotExists("foo", "bar", time.Minute); err != nil || !ok {
+		t.Fatalf("expected to be saved, got %t, %v", ok, err)
+	}
+	second.Set("baz", "qux")
+	if ok, err := nx.SetIfNotExists("baz", "bar", time.Minute); err != nil || ok {
+		t.Errorf("expected the ID to be taken, got %t, %v", ok, err)
+	}
+	if ok, _ := captchas.Exists(first, "baz"); ok {
+		t.Error("expected the captcha to be deleted from the stores that saved it")
+	}
+
+	first.(captchas.AttemptStore).Attempt("foo")
+	if n, err := s.(captchas.AttemptStore).Attempt("foo"); err != nil || n != 2 {
+		t.Errorf("expected the maximum attempts %d, got %d, %v", 2, n, err)
+	}
+	if err := captchas.Touch(s, "foo", time.Hour); err != nil {
+		t.Errorf("failed to touch: %s", err)
+	}
+	if ok, err := captchas.Exists(s, "baz"); err != nil || !ok {
+		t.Errorf("expected the captcha to exist, got %t, %v", ok, err)
+	}
+	if err := s.(captchas.DeleteStore).Delete("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := captchas.Exists(s, "foo"); err != nil || ok {
+		t.Errorf("expected the captcha to be deleted, got %t, %v", ok, err)
+	}
+	if _, err := New([]captchas.Store{errStore{}}).(captchas.AttemptStore).Attempt("foo"); err != captchas.ErrAttemptsUnsupported {
+		t.Errorf("expected error %v, got %v", captchas.ErrAttemptsUnsupported, err)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package resilientstore wraps a store, retries transient errors with
+// exponential backoff, and stops calling the store by a circuit breaker
+// while it is down.
+//
+// Errors of store are wrapped with captchas.ErrStoreUnavailable, which can be
+// checked by errors.Is, ErrIncorrectCaptcha and ErrExpiredCaptcha are returned
+// as is and never retried.
+package resilientstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/sony/gobreaker/v2"
+)
+
+// Option is a function that receives a pointer of store.
+type Option func(*store)
+
+// Retries sets the maximum number of retries, defaults to 2.
+func Retries(n int) Option {
+	return func(s *store) {
+		s.retries = n
+	}
+}
+
+// Backoff sets the delay before the first retry, the delay is doubled after
+// each retry, defaults to 50 milliseconds.
+func Backoff(d time.Duration) Option {
+	return func(s *store) {
+		s.backoff = d
+	}
+}
+
+// FailureThreshold sets the number of consecutive failures that trips the
+// circuit breaker, defaults to 5.
+func FailureThreshold(n uint32) Option {
+	return func(s *store) {
+		s.threshold = n
+	}
+}
+
+// OpenTimeout sets the period of the open state of circuit breaker, after
+// which a trial request is allowed, defaults to 30 seconds.
+func OpenTimeout(d time.Duration) Option {
+	return func(s *store) {
+		s.openTimeout = d
+	}
+}
+
+type store struct {
+	store       captchas.Store
+	retries     int
+	backoff     time.Duration
+	threshold   uint32
+	openTimeout time.Duration
+	breaker     *gobreaker.CircuitBreaker[string]
+}
+
+// New returns a resilient store that delegates to the given store. The
+// returned store implements captchas.Verifier or captchas.MatchVerifier if
+// the given store does.
+func New(s captchas.Store, opts ...Option) captchas.Store {
+	rs := &store{
+		store:       s,
+		retries:     2,
+		backoff:     50 * time.Millisecond,
+		threshold:   5,
+		openTimeout: 30 * time.Second,
+	}
+
+	for _, f := range opts {
+		f(rs)
+	}
+
+	rs.breaker = gobreaker.NewCircuitBreaker[string](gobreaker.Settings{
+		Name:    "captchas",
+		Timeout: rs.openTimeout,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= rs.threshold
+		},
+		IsSuccessful: func(err error) bool {
+			return err == nil || captchas.IsCaptchaError(err)
+		},
+	})
+
+	if _, ok := s.(captchas.MatchVerifier); ok {
+		return &matchVerifier{rs}
+	}
+	if _, ok := s.(captchas.Verifier); ok {
+		return &verifier{rs}
+	}
+	return rs
+}
+
+// do calls f until it succeeds, the error is not transient, the retries are
+// exhausted or the context is done.
+func (s *store) do(ctx context.Context, f func() (string, error)) (string, error) {
+	backoff := s.backoff
+	for i := 0; ; i++ {
+		value, err := s.breaker.Execute(f)
+		if err == nil || captchas.IsCaptchaError(err) {
+			return value, err
+		}
+		if err == gobreaker.ErrOpenState || err == gobreaker.ErrTooManyRequests || i >= s.retries {
+			return "", fmt.Errorf("%w: %w", captchas.ErrStoreUnavailable, err)
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("%w: %w", captchas.ErrStoreUnavailable, err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	return s.do(ctx, func() (string, error) {
+		return captchas.GetContext(ctx, s.store, id, clear)
+	})
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	_, err := s.do(ctx, func() (string, error) {
+		return "", captchas.SetContext(ctx, s.store, id, answer)
+	})
+	return err
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.SetWithTTLContext(context.Background(), id, answer, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	if _, ok := s.store.(captchas.TTLStore); !ok {
+		return captchas.ErrTTLUnsupported
+	}
+	_, err := s.do(ctx, func() (string, error) {
+		return "", captchas.SetWithTTLContext(ctx, s.store, id, answer, ttl)
+	})
+	return err
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *store) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	return s.SetIfNotExistsContext(context.Background(), id, answer, ttl)
+}
+
+// SetIfNotExistsContext implements ContextNXStore.SetIfNotExistsContext.
+func (s *store) SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error) {
+	var ok bool
+	_, err := s.do(ctx, func() (string, error) {
+		var err error
+		ok, err = captchas.SetIfNotExistsContext(ctx, s.store, id, answer, ttl)
+		return "", err
+	})
+	return ok && err == nil, err
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (s *store) Attempt(id string) (int, error) {
+	return s.AttemptContext(context.Background(), id)
+}
+
+// AttemptContext implements ContextAttemptStore.AttemptContext.
+func (s *store) AttemptContext(ctx context.Context, id string) (int, error) {
+	if _, ok := s.store.(captchas.AttemptStore); !ok {
+		return 0, captchas.ErrAttemptsUnsupported
+	}
+	var n int
+	_, err := s.do(ctx, func() (string, error) {
+		var err error
+		n, err = captchas.AttemptContext(ctx, s.store, id)
+		return "", err
+	})
+	return n, err
+}
+
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext implements ContextDeleteStore.DeleteContext.
+func (s *store) DeleteContext(ctx context.Context, id string) error {
+	_, err := s.do(ctx, func() (string, error) {
+		return "", captchas.DeleteContext(ctx, s.store, id)
+	})
+	return err
+}
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	return s.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext implements ContextTouchStore.TouchContext.
+func (s *store) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
+	if _, ok := s.store.(captchas.TouchStore); !ok {
+		return captchas.ErrTouchUnsupported
+	}
+	_, err := s.do(ctx, func() (string, error) {
+		return "", captchas.TouchContext(ctx, s.store, id, ttl)
+	})
+	return err
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *store) Exists(id string) (bool, error) {
+	return s.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext implements ContextExistsStore.ExistsContext.
+func (s *store) ExistsContext(ctx context.Context, id string) (bool, error) {
+	var ok bool
+	_, err := s.do(ctx, func() (string, error) {
+		var err error
+		ok, err = captchas.ExistsContext(ctx, s.store, id)
+		return "", err
+	})
+	return ok && err == nil, err
+}
+
+// Close closes the underlying store if it implements io.Closer.
+func (s *store) Close() error {
+	return captchas.Close(s.store)
+}
+
+type verifier struct {
+	*store
+}
+
+// Verify implements Verifier.Verify.
+func (v *verifier) Verify(id, actual string, clear bool) error {
+	return v.VerifyContext(context.Background(), id, actual, clear)
+}
+
+// VerifyContext implements ContextVerifier.VerifyContext.
+func (v *verifier) VerifyContext(ctx context.Context, id, actual string, clear bool) error {
+	_, err := v.do(ctx, func() (string, error) {
+		return "", captchas.VerifyContext(ctx, v.store.store.(captchas.Verifier), id, actual, clear)
+	})
+	return err
+}
+
+type matchVerifier struct {
+	*store
+}
+
+// VerifyMatch implements MatchVerifier.VerifyMatch.
+func (v *matchVerifier) VerifyMatch(id, actual string, clear bool, match func(actual, answer string) bool) error {
+	return v.VerifyMatchContext(context.Background(), id, actual, clear, match)
+}
+
+// VerifyMatchContext implements ContextMatchVerifier.VerifyMatchContext.
+func (v *matchVerifier) VerifyMatchContext(ctx context.Context, id, actual string, clear bool, match func(actual, answer string) bool) error {
+	_, err := v.do(ctx, func() (string, error) {
+		return "", captchas.VerifyMatchContext(ctx, v.store.store.(captchas.MatchVerifier), id, actual, clear, match)
+	})
+	return err
+}
@@ -0,0 +1,214 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package resilientstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/hashedstore"
+	"github.com/clevergo/captchas/memstore"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+// flakyStore fails the first n calls.
+type flakyStore struct {
+	captchas.Store
+	n     int
+	calls int
+}
+
+func (s *flakyStore) fail() bool {
+	s.calls++
+	return s.calls <= s.n
+}
+
+func (s *flakyStore) Get(id string, clear bool) (string, error) {
+	if s.fail() {
+		return "", errUnavailable
+	}
+	return s.Store.Get(id, clear)
+}
+
+func (s *flakyStore) Set(id, answer string) error {
+	if s.fail() {
+		return errUnavailable
+	}
+	return s.Store.Set(id, answer)
+}
+
+func TestNew(t *testing.T) {
+	s := New(memstore.New(), Retries(3), Backoff(time.Second), FailureThreshold(10), OpenTimeout(time.Minute))
+	rs, _ := s.(*store)
+	if rs.retries != 3 {
+		t.Errorf("expected retries %d, got %d", 3, rs.retries)
+	}
+	if rs.backoff != time.Second {
+		t.Errorf("expected backoff %s, got %s", time.Second, rs.backoff)
+	}
+	if rs.threshold != 10 {
+		t.Errorf("expected failure threshold %d, got %d", 10, rs.threshold)
+	}
+	if rs.openTimeout != time.Minute {
+		t.Errorf("expected open timeout %s, got %s", time.Minute, rs.openTimeout)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	flaky := &flakyStore{Store: memstore.New(), n: 2}
+	s := New(flaky, Backoff(time.Millisecond))
+	err := s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if flaky.calls != 3 {
+		t.Errorf("expected %d calls, got %d", 3, flaky.calls)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	// captcha errors are not retried.
+	calls := flaky.calls
+	if _, err = s.Get("foo", true); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	if flaky.calls != calls+1 {
+		t.Errorf("expected %d calls, got %d", calls+1, flaky.calls)
+	}
+}
+
+func TestStoreUnavailable(t *testing.T) {
+	flaky := &flakyStore{Store: memstore.New(), n: 100}
+	s := New(flaky, Retries(1), Backoff(time.Millisecond), FailureThreshold(4), OpenTimeout(50*time.Millisecond))
+	for i := 0; i < 2; i++ {
+		err := s.Set("foo", "bar")
+		if !errors.Is(err, captchas.ErrStoreUnavailable) || !errors.Is(err, errUnavailable) {
+			t.Errorf("expected error %v, got %v", captchas.ErrStoreUnavailable, err)
+		}
+	}
+	if flaky.calls != 4 {
+		t.Errorf("expected %d calls, got %d", 4, flaky.calls)
+	}
+
+	// the circuit breaker is open.
+	if _, err := s.Get("foo", false); !errors.Is(err, captchas.ErrStoreUnavailable) {
+		t.Errorf("expected error %v, got %v", captchas.ErrStoreUnavailable, err)
+	}
+	if flaky.calls != 4 {
+		t.Errorf("expected %d calls, got %d", 4, flaky.calls)
+	}
+
+	// the store recovers.
+	flaky.n = 0
+	time.Sleep(60 * time.Millisecond)
+	if err := s.Set("foo", "bar"); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+}
+
+func TestStoreVerify(t *testing.T) {
+	if _, ok := New(memstore.New()).(captchas.Verifier); ok {
+		t.Error("expected a non-verifier store")
+	}
+
+	s := New(hashedstore.New(memstore.New(), []byte("secret")))
+	s.Set("foo", "bar")
+	if err := s.(captchas.Verifier).Verify("foo", "bar", true); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+}
+
+type testMatchVerifier struct {
+	captchas.Store
+}
+
+func (s testMatchVerifier) VerifyMatch(id, actual string, clear bool, match func(actual, answer string) bool) error {
+	answer, err := s.Get(id, clear)
+	if err != nil {
+		return err
+	}
+	if !match(actual, answer) {
+		return captchas.ErrIncorrectCaptcha
+	}
+	return nil
+}
+
+func TestStoreVerifyMatch(t *testing.T) {
+	s, ok := New(testMatchVerifier{memstore.New()}).(captchas.MatchVerifier)
+	if !ok {
+		t.Fatal("expected a match verifier store")
+	}
+	s.(captchas.Store).Set("foo", "bar")
+	if err := s.VerifyMatch("foo", "BAR", true, strings.EqualFold); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+}
+
+func TestStoreForwarding(t *testing.T) {
+	mem := memstore.New()
+	s := New(mem, Backoff(time.Millisecond))
+	if ok, err := s.(captchas.NXStore).SetIfNotExists("foo", "bar", time.Minute); err != nil || !ok {
+		t.Fatalf("expected to be saved, got %t, %v", ok, err)
+	}
+	if ok, err := s.(captchas.NXStore).SetIfNotExists("foo", "bar", time.Minute); err != nil || ok {
+		t.Errorf("expected the ID to be taken, got %t, %v", ok, err)
+	}
+	if n, err := s.(captchas.AttemptStore).Attempt("foo"); err != nil || n != 1 {
+		t.Errorf("expected attempts %d, got %d, %v", 1, n, err)
+	}
+	if err := captchas.Touch(s, "foo", time.Hour); err != nil {
+		t.Errorf("failed to touch: %s", err)
+	}
+	if ok, err := captchas.Exists(s, "foo"); err != nil || !ok {
+		t.Errorf("expected the captcha to exist, got %t, %v", ok, err)
+	}
+	if err := s.(captchas.DeleteStore).Delete("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := captchas.Exists(mem, "foo"); ok {
+		t.Error("expected the captcha to be deleted")
+	}
+
+	// unsupported operations are neither retried nor count as failures.
+	flaky := &flakyStore{Store: memstore.New(), n: 1}
+	s = New(flaky, Backoff(time.Millisecond))
+	if _, err := s.(captchas.AttemptStore).Attempt("foo"); err != captchas.ErrAttemptsUnsupported {
+		t.Errorf("expected error %v, got %v", captchas.ErrAttemptsUnsupported, err)
+	}
+	if err := captchas.Touch(s, "foo", time.Hour); err != captchas.ErrTouchUnsupported {
+		t.Errorf("expected error %v, got %v", captchas.ErrTouchUnsupported, err)
+	}
+	if flaky.calls != 0 {
+		t.Errorf("expected no calls, got %d", flaky.calls)
+	}
+	if ok, err := captchas.Exists(s, "foo"); err != nil || ok {
+		t.Errorf("expected the failure to be retried, got %t, %v", ok, err)
+	}
+}
+
+func TestStoreContext(t *testing.T) {
+	flaky := &flakyStore{Store: memstore.New(), n: 10}
+	s := New(flaky, Retries(5), Backoff(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := captchas.GetContext(ctx, s, "foo", false); !errors.Is(err, captchas.ErrStoreUnavailable) {
+		t.Errorf("expected error %v, got %v", captchas.ErrStoreUnavailable, err)
+	}
+	if flaky.calls != 1 {
+		t.Errorf("expected retries to stop once the context is done, got %d calls", flaky.calls)
+	}
+}
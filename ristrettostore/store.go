@@ -0,0 +1,79 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package ristrettostore
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/dgraph-io/ristretto/v2"
+)
+
+// Option is a function that receives a pointer of ristretto store.
+type Option func(s *store)
+
+// Expiration sets expiration.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+type store struct {
+	// mu serializes consumption, so that an answer can't be consumed twice.
+	mu         sync.Mutex
+	cache      *ristretto.Cache[string, string]
+	expiration time.Duration
+}
+
+// New returns a ristretto store, the cost of each captcha is the length of
+// ID and answer in bytes, so that MaxCost of the cache is a memory budget.
+func New(cache *ristretto.Cache[string, string], opts ...Option) captchas.Store {
+	s := &store{
+		cache:      cache,
+		expiration: 10 * time.Minute,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	return s
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	if clear {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	answer, ok := s.cache.Get(id)
+	if !ok {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	if clear {
+		s.cache.Del(id)
+	}
+
+	return answer, nil
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetWithTTL(id, answer, s.expiration)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	if !s.cache.SetWithTTL(id, answer, int64(len(id)+len(answer)), ttl) {
+		return fmt.Errorf("failed to set key: %s", id)
+	}
+	// sets are buffered, wait for it to be visible.
+	s.cache.Wait()
+	return nil
+}
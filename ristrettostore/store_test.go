@@ -0,0 +1,78 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package ristrettostore
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/dgraph-io/ristretto/v2"
+)
+
+var testCache *ristretto.Cache[string, string]
+
+func TestMain(m *testing.M) {
+	var err error
+	testCache, err = ristretto.NewCache(&ristretto.Config[string, string]{
+		NumCounters: 1e4,
+		MaxCost:     1 << 20,
+		BufferItems: 64,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	testCache.Close()
+	os.Exit(code)
+}
+
+func TestNew(t *testing.T) {
+	expiration := 5 * time.Minute
+	s, _ := New(testCache, Expiration(expiration)).(*store)
+	if s.expiration != expiration {
+		t.Errorf("expected expiration %v, got %v", expiration, s.expiration)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	s := New(testCache)
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+func TestStoreExpiration(t *testing.T) {
+	s := New(testCache, Expiration(10*time.Millisecond))
+	if err := s.Set("foo", "bar"); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := s.Get("foo", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
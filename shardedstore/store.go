@@ -0,0 +1,165 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package shardedstore partitions captchas across multiple stores by
+// consistent hashing of captcha IDs.
+package shardedstore
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/golang/groupcache/consistenthash"
+)
+
+// Option is a function that receives a pointer of store.
+type Option func(*store)
+
+// Replicas sets the number of virtual nodes of each shard on the hash ring,
+// defaults to 50, non-positive numbers are ignored.
+func Replicas(replicas int) Option {
+	return func(s *store) {
+		if replicas > 0 {
+			s.replicas = replicas
+		}
+	}
+}
+
+type store struct {
+	shards   map[string]captchas.Store
+	replicas int
+	ring     *consistenthash.Map
+}
+
+// New returns a sharded store, shards are keyed by names which are hashed
+// onto the ring, so that adding or removing a shard only moves the captchas
+// of that shard as long as the names of other shards stay the same.
+//
+// It panics if there is no shard or any shard is nil, since every captcha
+// has to be owned by a shard.
+func New(shards map[string]captchas.Store, opts ...Option) captchas.Store {
+	if len(shards) == 0 {
+		panic("shardedstore: no shards")
+	}
+	for name, shard := range shards {
+		if shard == nil {
+			panic("shardedstore: nil shard " + strconv.Quote(name))
+		}
+	}
+
+	s := &store{
+		shards:   shards,
+		replicas: 50,
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	names := make([]string, 0, len(shards))
+	for name := range shards {
+		names = append(names, name)
+	}
+	s.ring = consistenthash.New(s.replicas, nil)
+	s.ring.Add(names...)
+
+	return s
+}
+
+func (s *store) pick(id string) captchas.Store {
+	return s.shards[s.ring.Get(id)]
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.pick(id).Get(id, clear)
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.pick(id).Set(id, answer)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return captchas.SetWithTTL(s.pick(id), id, answer, ttl)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	return captchas.GetContext(ctx, s.pick(id), id, clear)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	return captchas.SetContext(ctx, s.pick(id), id, answer)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	return captchas.SetWithTTLContext(ctx, s.pick(id), id, answer, ttl)
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *store) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	return s.SetIfNotExistsContext(context.Background(), id, answer, ttl)
+}
+
+// SetIfNotExistsContext implements ContextNXStore.SetIfNotExistsContext.
+func (s *store) SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error) {
+	return captchas.SetIfNotExistsContext(ctx, s.pick(id), id, answer, ttl)
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (s *store) Attempt(id string) (int, error) {
+	return s.AttemptContext(context.Background(), id)
+}
+
+// AttemptContext implements ContextAttemptStore.AttemptContext.
+func (s *store) AttemptContext(ctx context.Context, id string) (int, error) {
+	return captchas.AttemptContext(ctx, s.pick(id), id)
+}
+
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext implements ContextDeleteStore.DeleteContext.
+func (s *store) DeleteContext(ctx context.Context, id string) error {
+	return captchas.DeleteContext(ctx, s.pick(id), id)
+}
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	return s.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext implements ContextTouchStore.TouchContext.
+func (s *store) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
+	return captchas.TouchContext(ctx, s.pick(id), id, ttl)
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *store) Exists(id string) (bool, error) {
+	return s.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext implements ContextExistsStore.ExistsContext.
+func (s *store) ExistsContext(ctx context.Context, id string) (bool, error) {
+	return captchas.ExistsContext(ctx, s.pick(id), id)
+}
+
+// Close closes the shards that implement io.Closer, returns the first error.
+func (s *store) Close() error {
+	var err error
+	for _, shard := range s.shards {
+		if err2 := captchas.Close(shard); err == nil {
+			err = err2
+		}
+	}
+	return err
+}
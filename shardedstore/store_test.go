@@ -0,0 +1,147 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package shardedstore
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/memstore"
+)
+
+func newTestShards(n int) map[string]captchas.Store {
+	shards := make(map[string]captchas.Store, n)
+	for i := 0; i < n; i++ {
+		shards[fmt.Sprintf("shard%d", i)] = memstore.New()
+	}
+	return shards
+}
+
+func TestNew(t *testing.T) {
+	s := New(newTestShards(2), Replicas(10))
+	ss, _ := s.(*store)
+	if ss.replicas != 10 {
+		t.Errorf("expected replicas %d, got %d", 10, ss.replicas)
+	}
+	if len(ss.shards) != 2 {
+		t.Errorf("expected %d shards, got %d", 2, len(ss.shards))
+	}
+}
+
+func TestReplicasNonPositive(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		s := New(newTestShards(2), Replicas(n))
+		if ss := s.(*store); ss.replicas != 50 {
+			t.Errorf("expected the default replicas %d for %d, got %d", 50, n, ss.replicas)
+		}
+		if err := s.Set("foo", "bar"); err != nil {
+			t.Errorf("failed to set: %s", err)
+		}
+	}
+}
+
+func TestNewInvalidShards(t *testing.T) {
+	for _, shards := range []map[string]captchas.Store{nil, {}, {"shard0": nil}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected New(%v) to panic", shards)
+				}
+			}()
+			New(shards)
+		}()
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	shards := newTestShards(3)
+	s := New(shards)
+	used := make(map[captchas.Store]bool)
+	for i := 0; i < 30; i++ {
+		id := fmt.Sprintf("foo%d", i)
+		_, err := s.Get(id, true)
+		if err != captchas.ErrIncorrectCaptcha {
+			t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+		}
+
+		err = s.Set(id, "bar")
+		if err != nil {
+			t.Fatalf("failed to set: %s", err)
+		}
+		n := 0
+		for _, shard := range shards {
+			if _, err := shard.Get(id, false); err == nil {
+				n++
+				used[shard] = true
+			}
+		}
+		if n != 1 {
+			t.Errorf("expected captcha on %d shard, got %d", 1, n)
+		}
+		for _, clear := range []bool{false, true} {
+			value, err := s.Get(id, clear)
+			if err != nil {
+				t.Fatalf("expected non error, got %s", err)
+			}
+			if value != "bar" {
+				t.Errorf("expected value %q, got %q", "bar", value)
+			}
+		}
+
+		_, err = s.Get(id, true)
+		if err != captchas.ErrIncorrectCaptcha {
+			t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+		}
+	}
+	if len(used) != len(shards) {
+		t.Errorf("expected %d shards in use, got %d", len(shards), len(used))
+	}
+}
+
+func TestStoreResharding(t *testing.T) {
+	shards := newTestShards(3)
+	s1 := New(shards).(*store)
+	shards["shard3"] = memstore.New()
+	s2 := New(shards).(*store)
+	for i := 0; i < 100; i++ {
+		id := fmt.Sprintf("foo%d", i)
+		// captchas either stay on the same shard, or move to the new one.
+		if name := s2.ring.Get(id); name != "shard3" && name != s1.ring.Get(id) {
+			t.Errorf("%s: expected shard %s, got %s", id, s1.ring.Get(id), name)
+		}
+	}
+}
+
+func TestStoreForwarding(t *testing.T) {
+	shards := newTestShards(3)
+	s := New(shards)
+	if ok, err := s.(captchas.NXStore).SetIfNotExists("foo", "bar", time.Minute); err != nil || !ok {
+		t.Fatalf("expected to be saved, got %t, %v", ok, err)
+	}
+	if ok, _ := s.(captchas.NXStore).SetIfNotExists("foo", "baz", time.Minute); ok {
+		t.Error("expected the ID to be taken")
+	}
+	shard := s.(*store).pick("foo")
+	if value, _ := shard.Get("foo", false); value != "bar" {
+		t.Errorf("expected value %q in the owner shard, got %q", "bar", value)
+	}
+	if n, err := s.(captchas.AttemptStore).Attempt("foo"); err != nil || n != 1 {
+		t.Errorf("expected attempts %d, got %d, %v", 1, n, err)
+	}
+	if err := captchas.Touch(s, "foo", time.Hour); err != nil {
+		t.Errorf("failed to touch: %s", err)
+	}
+	if ok, err := captchas.Exists(s, "foo"); err != nil || !ok {
+		t.Errorf("expected the captcha to exist, got %t, %v", ok, err)
+	}
+	if err := s.(captchas.DeleteStore).Delete("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := captchas.Exists(shard, "foo"); ok {
+		t.Error("expected the captcha to be deleted")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package sqlitestore is a shortcut of sqlstore with the SQLite dialect, see
+// sqlstore for the details. The driver of SQLite is imported by
+// applications.
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/sqlstore"
+)
+
+// Option is an option of sqlstore.
+type Option = sqlstore.Option
+
+// GCStats is the statistics of garbage collection, see sqlstore.GCStats.
+type GCStats = sqlstore.GCStats
+
+// GCStatsReporter is implemented by stores of New, see
+// sqlstore.GCStatsReporter.
+type GCStatsReporter = sqlstore.GCStatsReporter
+
+// Table sets the table name.
+func Table(table string) Option {
+	return sqlstore.Table(table)
+}
+
+// Schema sets the schema name, such as "main" or the name of an attached
+// database, defaults to none, which means the table is searched in the
+// main database and the attached databases.
+func Schema(schema string) Option {
+	return sqlstore.Schema(schema)
+}
+
+// AutoMigrate reports whether New migrates the table, defaults to true,
+// the table can be migrated by Migrate instead.
+func AutoMigrate(v bool) Option {
+	return sqlstore.AutoMigrate(v)
+}
+
+// Expiration sets expiration.
+func Expiration(expiration time.Duration) Option {
+	return sqlstore.Expiration(expiration)
+}
+
+// GCInterval sets garbage collection interval, a non-positive interval
+// disables the garbage collection.
+func GCInterval(interval time.Duration) Option {
+	return sqlstore.GCInterval(interval)
+}
+
+// QueryTimeout sets the maximum duration of queries of each operation, see
+// sqlstore.QueryTimeout.
+func QueryTimeout(timeout time.Duration) Option {
+	return sqlstore.QueryTimeout(timeout)
+}
+
+// GCBatchSize sets the maximum number of expired captchas deleted per
+// garbage collection, see sqlstore.GCBatchSize.
+func GCBatchSize(size int) Option {
+	return sqlstore.GCBatchSize(size)
+}
+
+// Codec sets the codec of metadata, defaults to JSON.
+func Codec(codec captchas.Codec) Option {
+	return sqlstore.Codec(codec)
+}
+
+// New returns a SQLite store, the table is migrated unless AutoMigrate is
+// disabled.
+func New(db *sql.DB, opts ...Option) (captchas.Store, error) {
+	return sqlstore.New(db, sqlstore.SQLite, opts...)
+}
+
+// Migrate creates the table and the expiration index if not exists, and
+// adds the columns missing in tables of older versions.
+func Migrate(ctx context.Context, db *sql.DB, opts ...Option) error {
+	return sqlstore.Migrate(ctx, db, sqlstore.SQLite, opts...)
+}
@@ -0,0 +1,511 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/msgpackcodec"
+	_ "modernc.org/sqlite"
+)
+
+var testDB *sql.DB
+
+func TestMain(m *testing.M) {
+	var err error
+	testDB, err = sql.Open("sqlite", "file::memory:?cache=shared")
+	if err != nil {
+		panic(err)
+	}
+	testDB.SetMaxOpenConns(1)
+
+	code := m.Run()
+	testDB.Close()
+	os.Exit(code)
+}
+
+func TestNew(t *testing.T) {
+	s, err := New(testDB, Table("foo"), Expiration(5*time.Minute), GCInterval(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer captchas.Close(s)
+	for _, ok := range []bool{
+		implements[captchas.TTLStore](s),
+		implements[captchas.NXStore](s),
+		implements[captchas.AttemptStore](s),
+		implements[captchas.MetadataStore](s),
+		implements[captchas.ScanStore](s),
+		implements[GCStatsReporter](s),
+	} {
+		if !ok {
+			t.Errorf("expected the store to implement all of the optional interfaces")
+		}
+	}
+}
+
+func implements[T any](s captchas.Store) bool {
+	_, ok := s.(T)
+	return ok
+}
+
+func TestMigrate(t *testing.T) {
+	opts := []Option{Schema("main"), Table(`migrate "foo"`), AutoMigrate(false)}
+	for i := 0; i < 2; i++ {
+		if err := Migrate(context.Background(), testDB, opts...); err != nil {
+			t.Fatalf("failed to migrate: %s", err)
+		}
+	}
+	var n int
+	query := `SELECT COUNT(*) FROM main.sqlite_master WHERE type = 'index' AND name = 'migrate "foo"_expiration'`
+	if err := testDB.QueryRow(query).Scan(&n); err != nil || n != 1 {
+		t.Errorf("expected the expiration index, got %d, %v", n, err)
+	}
+
+	s, err := New(testDB, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer captchas.Close(s)
+	if err = s.Set("foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if answer, err := s.Get("foo", true); err != nil || answer != "bar" {
+		t.Errorf("expected answer %q, got %q, %v", "bar", answer, err)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	s, err := New(testDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	_, err = s.Get("foo", true)
+	if err == nil {
+		t.Error("expected a non-nil error, got nil")
+	}
+}
+
+func TestStoreGC(t *testing.T) {
+	s, err := New(testDB, Table("gc"), GCInterval(time.Millisecond), GCBatchSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer captchas.Close(s)
+	for i := 0; i < 5; i++ {
+		if err = captchas.SetWithTTL(s, strconv.Itoa(i), "foo", -time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = s.Set("active", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.(GCStatsReporter).GCStats().Deleted < 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 5 deleted captchas, got stats %+v", s.(GCStatsReporter).GCStats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err = s.Get("active", false); err != nil {
+		t.Errorf("expected captcha %q to be kept, got %v", "active", err)
+	}
+}
+
+func TestStoreUpsert(t *testing.T) {
+	s, err := New(testDB, Table("upsert"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer captchas.Close(s)
+	ms := s.(captchas.MetadataStore)
+	if err = ms.SetWithMetadata("foo", "bar", 0, captchas.Metadata{Driver: "digit"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.(captchas.AttemptStore).Attempt("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Set("foo", "baz"); err != nil {
+		t.Fatal(err)
+	}
+	if answer, err := s.Get("foo", false); err != nil || answer != "baz" {
+		t.Errorf("expected answer %q, got %q, %v", "baz", answer, err)
+	}
+	if md, err := ms.GetMetadata("foo"); err != nil || md.Driver != "" {
+		t.Errorf("expected metadata to be reset, got %+v, %v", md, err)
+	}
+	if n, err := s.(captchas.AttemptStore).Attempt("foo"); err != nil || n != 1 {
+		t.Errorf("expected attempts to be reset, got %d, %v", n, err)
+	}
+	if err = captchas.SetMulti(s, map[string]string{"foo": "qux"}); err != nil {
+		t.Fatal(err)
+	}
+	if answer, err := s.Get("foo", false); err != nil || answer != "qux" {
+		t.Errorf("expected answer %q, got %q, %v", "qux", answer, err)
+	}
+}
+
+func TestStoreExpirationIndex(t *testing.T) {
+	s, err := New(testDB, Table("index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer captchas.Close(s)
+	rows, err := testDB.Query(`EXPLAIN QUERY PLAN SELECT id FROM "index" WHERE expiration < ? ORDER BY expiration LIMIT ?`, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var plan string
+	for rows.Next() {
+		var id, parent, unused int
+		var detail string
+		if err = rows.Scan(&id, &parent, &unused, &detail); err != nil {
+			t.Fatal(err)
+		}
+		plan += detail
+	}
+	if !strings.Contains(plan, "index_expiration") {
+		t.Errorf("expected the expiration index to be used, got plan %q", plan)
+	}
+}
+
+func TestStoreContext(t *testing.T) {
+	s, err := New(testDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := s.(captchas.ContextStore)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err = cs.SetContext(ctx, "foo", "bar"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if _, err = cs.GetContext(ctx, "foo", false); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if _, err = s.(captchas.ContextNXStore).SetIfNotExistsContext(ctx, "foo", "bar", 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if err = captchas.SetWithTTLContext(ctx, s, "foo", "bar", time.Minute); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+	if err = captchas.SetWithMetadataContext(ctx, s, "foo", "bar", 0, captchas.Metadata{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected error %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestStoreQueryTimeout(t *testing.T) {
+	s, err := New(testDB, Table("timeout"), QueryTimeout(time.Nanosecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer captchas.Close(s)
+	if err = s.Set("foo", "bar"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	s, err = New(testDB, Table("timeout"), QueryTimeout(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Set("foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if answer, err := s.Get("foo", true); err != nil || answer != "bar" {
+		t.Errorf("expected answer %q, got %q, %v", "bar", answer, err)
+	}
+	if err = captchas.Close(s); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Set("foo", "bar"); err == nil {
+		t.Error("expected an error of closed statements, got nil")
+	}
+}
+
+func TestStoreConsume(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "captchas.db")+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	s, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer captchas.Close(s)
+	if err = s.Set("foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var consumed atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			answer, err := captchas.GetContext(context.Background(), s, "foo", true)
+			if err == nil && answer == "bar" {
+				consumed.Add(1)
+			} else if err != captchas.ErrIncorrectCaptcha {
+				t.Errorf("unexpected error %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := consumed.Load(); n != 1 {
+		t.Errorf("expected the captcha to be consumed once, got %d", n)
+	}
+}
+
+func TestStoreSetWithTTL(t *testing.T) {
+	s, err := New(testDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := s.(captchas.TTLStore)
+	if err = ts.SetWithTTL("foo", "bar", -time.Second); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if _, err = s.Get("foo", true); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+}
+
+func TestStoreBatch(t *testing.T) {
+	s, err := New(testDB, Table("batch"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := make(map[string]string)
+	ids := make([]string, 0, 510)
+	for i := 0; i < 510; i++ {
+		id := strconv.Itoa(i)
+		items[id] = "answer" + id
+		ids = append(ids, id)
+	}
+	if err = captchas.SetMulti(s, items); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+
+	answers, err := captchas.GetMulti(s, append(ids, "missing"), false)
+	if err != nil {
+		t.Fatalf("failed to get: %s", err)
+	}
+	if !reflect.DeepEqual(answers, items) {
+		t.Errorf("expected %d answers, got %d", len(items), len(answers))
+	}
+
+	answers, err = captchas.GetMulti(s, ids[:2], true)
+	if err != nil {
+		t.Fatalf("failed to get: %s", err)
+	}
+	if len(answers) != 2 {
+		t.Errorf("expected 2 answers, got %v", answers)
+	}
+	if _, err = s.Get(ids[0], false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %s, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
+
+func TestStoreSetIfNotExists(t *testing.T) {
+	s, err := New(testDB, Table("nx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nx := s.(captchas.NXStore)
+	if ok, err := nx.SetIfNotExists("foo", "bar", 0); err != nil || !ok {
+		t.Fatalf("expected to be saved, got %t, %v", ok, err)
+	}
+	if ok, err := nx.SetIfNotExists("foo", "baz", 0); err != nil || ok {
+		t.Errorf("expected not to be saved, got %t, %v", ok, err)
+	}
+	if err = captchas.SetWithTTL(s, "foo", "bar", -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := nx.SetIfNotExists("foo", "baz", time.Minute); err != nil || !ok {
+		t.Errorf("expected expired captcha to be replaced, got %t, %v", ok, err)
+	}
+	if value, _ := s.Get("foo", false); value != "baz" {
+		t.Errorf("expected value %q, got %q", "baz", value)
+	}
+}
+
+func TestStoreTouch(t *testing.T) {
+	s, err := New(testDB, Table("touch"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = captchas.Touch(s, "foo", time.Minute); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	if err = captchas.SetWithTTL(s, "foo", "bar", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err = captchas.Touch(s, "foo", -time.Second); err != nil {
+		t.Fatalf("failed to touch: %s", err)
+	}
+	if _, err = s.Get("foo", false); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+}
+
+func TestStoreDelete(t *testing.T) {
+	s, err := New(testDB, Table("delete"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Set("foo", "bar")
+	if err = captchas.Delete(s, "foo"); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if _, err = s.Get("foo", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
+
+func TestStoreExists(t *testing.T) {
+	s, err := New(testDB, Table("exists"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := captchas.Exists(s, "foo"); err != nil || ok {
+		t.Errorf("expected false, got %t, %v", ok, err)
+	}
+	s.Set("foo", "bar")
+	if ok, err := captchas.Exists(s, "foo"); err != nil || !ok {
+		t.Errorf("expected true, got %t, %v", ok, err)
+	}
+}
+
+func TestStoreAttempt(t *testing.T) {
+	// creates a table without the attempts column.
+	if _, err := testDB.Exec(`CREATE TABLE "attempts" (id TEXT PRIMARY KEY, answer TEXT NOT NULL, expiration INTEGER NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(testDB, Table("attempts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	as := s.(captchas.AttemptStore)
+	if _, err = as.Attempt("foo"); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	s.Set("foo", "bar")
+	for i := 1; i <= 3; i++ {
+		if n, err := as.Attempt("foo"); err != nil || n != i {
+			t.Errorf("expected attempts %d, got %d, %v", i, n, err)
+		}
+	}
+
+	m := captchas.New(s, nil, captchas.MaxAttempts(3))
+	if err = m.Verify("foo", "baz", false); err != captchas.ErrTooManyAttempts {
+		t.Errorf("expected error %v, got %v", captchas.ErrTooManyAttempts, err)
+	}
+	if _, err = s.Get("foo", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
+
+func TestStoreMetadata(t *testing.T) {
+	s, err := New(testDB, Table("metadata"), Codec(msgpackcodec.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms := s.(captchas.MetadataStore)
+	md := captchas.Metadata{
+		CreatedAt: time.Unix(1600000000, 0),
+		ClientIP:  "127.0.0.1",
+		Labels:    map[string]string{"form": "login"},
+	}
+	if err = ms.SetWithMetadata("foo", "bar", 0, md); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	actual, err := ms.GetMetadata("foo")
+	if err != nil || !actual.CreatedAt.Equal(md.CreatedAt) || !reflect.DeepEqual(actual.Labels, md.Labels) {
+		t.Errorf("expected metadata %v, got %v, %v", md, actual, err)
+	}
+	if value, _ := s.Get("foo", false); value != "bar" {
+		t.Errorf("expected value %q, got %q", "bar", value)
+	}
+
+	s.Set("foo", "bar")
+	if actual, err = ms.GetMetadata("foo"); err != nil || !reflect.DeepEqual(actual, captchas.Metadata{}) {
+		t.Errorf("expected zero metadata, got %v, %v", actual, err)
+	}
+	if _, err = ms.GetMetadata("missing"); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
+
+func TestStoreScan(t *testing.T) {
+	s, err := New(testDB, Table("scan"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"c", "a", "b"} {
+		s.Set(id, "answer")
+	}
+	captchas.SetWithTTL(s, "expired", "answer", -time.Second)
+	s.(captchas.MetadataStore).SetWithMetadata("d", "answer", 0, captchas.Metadata{ClientIP: "127.0.0.1"})
+
+	ss := s.(captchas.ScanStore)
+	entries, next, err := ss.Scan("", 2)
+	if err != nil || len(entries) != 2 || entries[0].ID != "a" || next != "b" {
+		t.Fatalf("unexpected page %v, %q, %v", entries, next, err)
+	}
+	entries, next, err = ss.Scan(next, 2)
+	if err != nil || len(entries) != 2 || entries[1].ID != "d" || entries[1].Metadata.ClientIP != "127.0.0.1" {
+		t.Fatalf("unexpected page %v, %q, %v", entries, next, err)
+	}
+	if entries, next, err = ss.Scan(next, 2); err != nil || len(entries) != 0 || next != "" {
+		t.Errorf("unexpected page %v, %q, %v", entries, next, err)
+	}
+}
+
+func TestStoreCloseWithoutGC(t *testing.T) {
+	s, err := New(testDB, Table("nogc"), GCInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = captchas.Close(s); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if runs := s.(GCStatsReporter).GCStats().Runs; runs != 0 {
+		t.Errorf("expected no garbage collection, got %d runs", runs)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// batchSize is the maximum number of IDs per statement, it keeps the number
+// of bound parameters below the limits of databases, such as the 1000
+// expressions of lists of Oracle.
+const batchSize = 500
+
+// GetMulti implements BatchStore.GetMulti.
+func (s *store) GetMulti(ids []string, clear bool) (map[string]string, error) {
+	return s.GetMultiContext(context.Background(), ids, clear)
+}
+
+// GetMultiContext implements ContextBatchStore.GetMultiContext, captchas
+// are selected by batches, or consumed one by one if clear is true, so that
+// only one of concurrent consumers is able to get each answer.
+func (s *store) GetMultiContext(ctx context.Context, ids []string, clear bool) (map[string]string, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	answers := make(map[string]string, len(ids))
+	t := now()
+	if clear {
+		for _, id := range ids {
+			answer, exp, err := s.get(ctx, id, true)
+			if err == sql.ErrNoRows {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !t.After(exp.Time) {
+				answers[id] = answer
+			}
+		}
+		return answers, nil
+	}
+
+	for start := 0; start < len(ids); start += batchSize {
+		end := min(start+batchSize, len(ids))
+		args := make([]interface{}, 0, end-start)
+		placeholders := make([]string, 0, end-start)
+		for i, id := range ids[start:end] {
+			args = append(args, id)
+			placeholders = append(placeholders, s.dialect.Placeholder(i+1))
+		}
+		query := fmt.Sprintf(`SELECT id, answer, expiration FROM %s WHERE id IN (%s)`, s.name(), strings.Join(placeholders, ", "))
+
+		rows, err := s.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id, answer string
+			var exp expiration
+			if err = rows.Scan(&id, &answer, &exp); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if !t.After(exp.Time) {
+				answers[id] = answer
+			}
+		}
+		err = rows.Close()
+		if err == nil {
+			err = rows.Err()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return answers, nil
+}
+
+// SetMulti implements BatchStore.SetMulti.
+func (s *store) SetMulti(items map[string]string) error {
+	return s.SetMultiContext(context.Background(), items)
+}
+
+// SetMultiContext implements ContextBatchStore.SetMultiContext, the items
+// are saved by the prepared upsert statement within a transaction.
+func (s *store) SetMultiContext(ctx context.Context, items map[string]string) error {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	expiration := s.timestamp(now().Add(s.expiration))
+	return s.transact(ctx, func(tx *sql.Tx) error {
+		stmt := tx.StmtContext(ctx, s.stmts.set)
+		for id, answer := range items {
+			if _, err := stmt.ExecContext(ctx, id, answer, expiration, []byte(nil)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sqlstore
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CockroachDB is the dialect of CockroachDB, such as the driver
+// github.com/jackc/pgx/v5/stdlib. Captchas are consumed in transactions,
+// which are retried on serialization failures (SQLSTATE 40001). The table
+// enables row-level TTL by the expiration, so that the garbage collection
+// can be disabled by GCInterval(0).
+var CockroachDB Dialect = cockroachDB{}
+
+type cockroachDB struct{}
+
+func (cockroachDB) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (cockroachDB) Quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (d cockroachDB) Migrate(schema, table string) []string {
+	name := qualify(d, schema, table)
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id STRING PRIMARY KEY,
+	answer STRING NOT NULL,
+	expiration TIMESTAMPTZ NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	metadata BYTES
+) WITH (ttl_expiration_expression = 'expiration')`, name),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (expiration)`, d.Quote(table+"_expiration"), name),
+	}
+}
+
+var cockroachDBColumns = map[string]string{
+	"attempts": "INT NOT NULL DEFAULT 0",
+	"metadata": "BYTES",
+}
+
+func (cockroachDB) AddColumn(name, column string) string {
+	return fmt.Sprintf(`ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s`, name, column, cockroachDBColumns[column])
+}
+
+func (cockroachDB) Upsert(name string) string {
+	return fmt.Sprintf(`UPSERT INTO %s (id, answer, expiration, attempts, metadata) VALUES ($1, $2, $3, 0, $4)`, name)
+}
+
+func (cockroachDB) InsertIfNotExists(name string) string {
+	return fmt.Sprintf(`INSERT INTO %s AS target (id, answer, expiration) VALUES ($1, $2, $3)
+ON CONFLICT (id) DO UPDATE SET answer = excluded.answer, expiration = excluded.expiration, attempts = 0, metadata = NULL WHERE target.expiration < $4`, name)
+}
+
+// Consume returns an empty string, so that the captcha is consumed in a
+// transaction that can be retried.
+func (cockroachDB) Consume(name string) string {
+	return ""
+}
+
+func (cockroachDB) Lock(name string) string {
+	return fmt.Sprintf(`SELECT answer, expiration FROM %s WHERE id = $1 FOR UPDATE`, name)
+}
+
+func (cockroachDB) Attempt(name string) string {
+	return fmt.Sprintf(`UPDATE %s SET attempts = attempts + 1 WHERE id = $1 AND expiration >= $2 RETURNING attempts`, name)
+}
+
+func (cockroachDB) DeleteExpired(name string) string {
+	return fmt.Sprintf(`DELETE FROM %s WHERE expiration < $1 ORDER BY expiration LIMIT $2`, name)
+}
+
+func (cockroachDB) Scan(name string) string {
+	return fmt.Sprintf(`SELECT id, expiration, metadata FROM %s WHERE id > $1 AND expiration >= $2 ORDER BY id LIMIT $3`, name)
+}
+
+// sqlStateError is implemented by errors of pgx and lib/pq.
+type sqlStateError interface {
+	SQLState() string
+}
+
+// Retryable implements Retrier.Retryable.
+func (cockroachDB) Retryable(err error) bool {
+	var serr sqlStateError
+	return errors.As(err, &serr) && serr.SQLState() == "40001"
+}
@@ -0,0 +1,96 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type testSQLStateError string
+
+func (e testSQLStateError) Error() string {
+	return "SQLSTATE " + string(e)
+}
+
+func (e testSQLStateError) SQLState() string {
+	return string(e)
+}
+
+func TestCockroachDB(t *testing.T) {
+	if p := CockroachDB.Placeholder(2); p != "$2" {
+		t.Errorf("unexpected placeholder %q", p)
+	}
+	migrations := CockroachDB.Migrate("", "captchas")
+	if len(migrations) != 2 || !strings.Contains(migrations[0], "WITH (ttl_expiration_expression = 'expiration')") {
+		t.Errorf("unexpected migrations %q", migrations)
+	}
+	if q := CockroachDB.AddColumn(`"captchas"`, "attempts"); q != `ALTER TABLE "captchas" ADD COLUMN IF NOT EXISTS attempts INT NOT NULL DEFAULT 0` {
+		t.Errorf("unexpected add column %q", q)
+	}
+	if q := CockroachDB.Upsert(`"captchas"`); q != `UPSERT INTO "captchas" (id, answer, expiration, attempts, metadata) VALUES ($1, $2, $3, 0, $4)` {
+		t.Errorf("unexpected upsert %q", q)
+	}
+	if q := CockroachDB.DeleteExpired(`"captchas"`); q != `DELETE FROM "captchas" WHERE expiration < $1 ORDER BY expiration LIMIT $2` {
+		t.Errorf("unexpected delete expired %q", q)
+	}
+	if q := CockroachDB.Consume(`"captchas"`); q != "" {
+		t.Errorf("expected an empty consume, got %q", q)
+	}
+
+	r := CockroachDB.(Retrier)
+	tests := map[error]bool{
+		testSQLStateError("40001"):                            true,
+		fmt.Errorf("wrapped: %w", testSQLStateError("40001")): true,
+		testSQLStateError("23505"):                            false,
+		errors.New("40001"):                                   false,
+	}
+	for err, expected := range tests {
+		if r.Retryable(err) != expected {
+			t.Errorf("expected %v is retryable %t", err, expected)
+		}
+	}
+}
+
+type retryingDialect struct {
+	sqlite
+}
+
+func (retryingDialect) Retryable(err error) bool {
+	return err == errRetry
+}
+
+var errRetry = errors.New("retry")
+
+func TestStoreRetry(t *testing.T) {
+	s := newTestDialectStore(t, retryingDialect{}, MaxRetries(2))
+	for failures, expected := range map[int]error{2: nil, 3: errRetry} {
+		calls := 0
+		err := s.retry(context.Background(), func(tx *sql.Tx) error {
+			calls++
+			if calls <= failures {
+				return errRetry
+			}
+			return nil
+		})
+		if err != expected || calls != min(failures+1, 3) {
+			t.Errorf("expected error %v after %d calls, got %v after %d calls", expected, min(failures+1, 3), err, calls)
+		}
+	}
+
+	s = newTestStore(t, MaxRetries(2))
+	calls := 0
+	s.retry(context.Background(), func(tx *sql.Tx) error {
+		calls++
+		return errRetry
+	})
+	if calls != 1 {
+		t.Errorf("expected no retries of dialects that aren't retriers, got %d calls", calls)
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sqlstore
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// GCStats is the statistics of garbage collection.
+type GCStats struct {
+	// Runs is the number of garbage collections.
+	Runs uint64
+	// Deleted is the number of expired captchas deleted.
+	Deleted uint64
+	// Errors is the number of failed garbage collections.
+	Errors uint64
+	// LastRun is the time of the last garbage collection.
+	LastRun time.Time
+}
+
+// GCStatsReporter is implemented by SQL stores.
+//
+//	stats := store.(sqlstore.GCStatsReporter).GCStats()
+type GCStatsReporter interface {
+	GCStats() GCStats
+}
+
+type gcCounters struct {
+	runs    atomic.Uint64
+	deleted atomic.Uint64
+	errors  atomic.Uint64
+	lastRun atomic.Int64
+}
+
+// GCStats implements GCStatsReporter.GCStats.
+func (s *store) GCStats() GCStats {
+	stats := GCStats{
+		Runs:    s.gcStats.runs.Load(),
+		Deleted: s.gcStats.deleted.Load(),
+		Errors:  s.gcStats.errors.Load(),
+	}
+	if lastRun := s.gcStats.lastRun.Load(); lastRun > 0 {
+		stats.LastRun = time.Unix(0, lastRun)
+	}
+	return stats
+}
+
+// gc deletes expired captchas periodically, the interval is jittered by
+// up to 10%, so that stores sharing a database don't collect at once.
+func (s *store) gc() {
+	timer := time.NewTimer(s.jitter())
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			s.collect()
+			timer.Reset(s.jitter())
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *store) jitter() time.Duration {
+	delta := int64(s.gcInterval / 10)
+	if delta <= 0 {
+		return s.gcInterval
+	}
+	return s.gcInterval + time.Duration(rand.Int64N(2*delta+1)-delta)
+}
+
+func (s *store) collect() {
+	n, err := s.deleteExpired()
+	s.gcStats.runs.Add(1)
+	s.gcStats.deleted.Add(uint64(n))
+	s.gcStats.lastRun.Store(time.Now().UnixNano())
+	if err != nil {
+		s.gcStats.errors.Add(1)
+	}
+}
+
+// deleteExpired deletes a batch of expired captchas, which are found by the
+// expiration index.
+func (s *store) deleteExpired() (int64, error) {
+	ctx, cancel := s.context(context.Background())
+	defer cancel()
+	limit := s.gcBatchSize
+	if limit <= 0 {
+		limit = math.MaxInt32
+	}
+	res, err := s.db.ExecContext(ctx, s.queries.deleteExpired, s.timestamp(now()), limit)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+// SetWithMetadata implements MetadataStore.SetWithMetadata, the metadata is
+// encoded by the codec.
+func (s *store) SetWithMetadata(id, answer string, ttl time.Duration, md captchas.Metadata) error {
+	return s.SetWithMetadataContext(context.Background(), id, answer, ttl, md)
+}
+
+// SetWithMetadataContext implements
+// ContextMetadataStore.SetWithMetadataContext.
+func (s *store) SetWithMetadataContext(ctx context.Context, id, answer string, ttl time.Duration, md captchas.Metadata) error {
+	if ttl <= 0 {
+		ttl = s.expiration
+	}
+	data, err := s.codec.Marshal(md)
+	if err != nil {
+		return err
+	}
+	return s.set(ctx, id, answer, ttl, data)
+}
+
+// GetMetadata implements MetadataStore.GetMetadata, captchas saved without
+// metadata have zero metadata.
+func (s *store) GetMetadata(id string) (captchas.Metadata, error) {
+	return s.GetMetadataContext(context.Background(), id)
+}
+
+// GetMetadataContext implements ContextMetadataStore.GetMetadataContext.
+func (s *store) GetMetadataContext(ctx context.Context, id string) (captchas.Metadata, error) {
+	var md captchas.Metadata
+	var data []byte
+	var exp expiration
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	err := s.db.QueryRowContext(ctx, s.queries.getMetadata, id).Scan(&data, &exp)
+	if err == sql.ErrNoRows {
+		return md, captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return md, err
+	}
+	if now().After(exp.Time) {
+		return md, captchas.ErrExpiredCaptcha
+	}
+	if data == nil {
+		return md, nil
+	}
+	err = s.codec.Unmarshal(data, &md)
+	return md, err
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sqlstore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MSSQL is the dialect of Microsoft SQL Server, such as the driver
+// github.com/microsoft/go-mssqldb. Expirations are saved as DATETIMEOFFSET,
+// and captchas are saved by MERGE.
+var MSSQL Dialect = mssql{}
+
+type mssql struct{}
+
+func (mssql) Placeholder(n int) string {
+	return "@p" + strconv.Itoa(n)
+}
+
+func (mssql) Quote(identifier string) string {
+	return "[" + strings.ReplaceAll(identifier, "]", "]]") + "]"
+}
+
+func (d mssql) Migrate(schema, table string) []string {
+	name := qualify(d, schema, table)
+	return []string{
+		fmt.Sprintf(`IF OBJECT_ID(N%s, N'U') IS NULL CREATE TABLE %s (
+	id NVARCHAR(255) NOT NULL PRIMARY KEY,
+	answer NVARCHAR(MAX) NOT NULL,
+	expiration DATETIMEOFFSET NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	metadata VARBINARY(MAX)
+)`, literal(name), name),
+		fmt.Sprintf(`IF NOT EXISTS (SELECT * FROM sys.indexes WHERE name = N%s AND object_id = OBJECT_ID(N%s)) CREATE INDEX %s ON %s (expiration)`,
+			literal(table+"_expiration"), literal(name), d.Quote(table+"_expiration"), name),
+	}
+}
+
+var mssqlColumns = map[string]string{
+	"attempts": "INT NOT NULL DEFAULT 0",
+	"metadata": "VARBINARY(MAX)",
+}
+
+func (mssql) AddColumn(name, column string) string {
+	return fmt.Sprintf(`ALTER TABLE %s ADD %s %s`, name, column, mssqlColumns[column])
+}
+
+// Upsert holds the range lock of the ID until the end of statement, so that
+// concurrent MERGE statements of the same ID don't violate the primary key.
+func (mssql) Upsert(name string) string {
+	return fmt.Sprintf(`MERGE INTO %s WITH (HOLDLOCK) AS target
+USING (SELECT @p1 AS id, @p2 AS answer, @p3 AS expiration, @p4 AS metadata) AS source ON target.id = source.id
+WHEN MATCHED THEN UPDATE SET answer = source.answer, expiration = source.expiration, attempts = 0, metadata = source.metadata
+WHEN NOT MATCHED THEN INSERT (id, answer, expiration, metadata) VALUES (source.id, source.answer, source.expiration, source.metadata);`, name)
+}
+
+func (mssql) InsertIfNotExists(name string) string {
+	return fmt.Sprintf(`MERGE INTO %s WITH (HOLDLOCK) AS target
+USING (SELECT @p1 AS id, @p2 AS answer, @p3 AS expiration) AS source ON target.id = source.id
+WHEN MATCHED AND target.expiration < @p4 THEN UPDATE SET answer = source.answer, expiration = source.expiration, attempts = 0, metadata = NULL
+WHEN NOT MATCHED THEN INSERT (id, answer, expiration) VALUES (source.id, source.answer, source.expiration);`, name)
+}
+
+func (mssql) Consume(name string) string {
+	return fmt.Sprintf(`DELETE FROM %s OUTPUT DELETED.answer, DELETED.expiration WHERE id = @p1`, name)
+}
+
+func (mssql) Lock(name string) string {
+	return fmt.Sprintf(`SELECT answer, expiration FROM %s WITH (UPDLOCK, ROWLOCK) WHERE id = @p1`, name)
+}
+
+func (mssql) Attempt(name string) string {
+	return fmt.Sprintf(`UPDATE %s SET attempts = attempts + 1 OUTPUT INSERTED.attempts WHERE id = @p1 AND expiration >= @p2`, name)
+}
+
+func (mssql) DeleteExpired(name string) string {
+	return fmt.Sprintf(`DELETE TOP (@p2) FROM %s WHERE expiration < @p1`, name)
+}
+
+func (mssql) Scan(name string) string {
+	return fmt.Sprintf(`SELECT TOP (@p3) id, expiration, metadata FROM %s WHERE id > @p1 AND expiration >= @p2 ORDER BY id`, name)
+}
+
+// literal returns the string literal of SQL.
+func literal(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
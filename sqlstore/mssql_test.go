@@ -0,0 +1,53 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sqlstore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMSSQL(t *testing.T) {
+	if p := MSSQL.Placeholder(2); p != "@p2" {
+		t.Errorf("unexpected placeholder %q", p)
+	}
+	if q := MSSQL.Quote("foo]bar"); q != "[foo]]bar]" {
+		t.Errorf("unexpected quoted identifier %q", q)
+	}
+	migrations := MSSQL.Migrate("", "it's")
+	if len(migrations) != 2 || !strings.Contains(migrations[0], `OBJECT_ID(N'[it''s]', N'U')`) ||
+		!strings.Contains(migrations[1], `CREATE INDEX [it's_expiration] ON [it's] (expiration)`) {
+		t.Errorf("unexpected migrations %q", migrations)
+	}
+	migrations = MSSQL.Migrate("dbo", "captchas")
+	if !strings.Contains(migrations[0], `OBJECT_ID(N'[dbo].[captchas]', N'U')`) ||
+		!strings.Contains(migrations[1], `CREATE INDEX [captchas_expiration] ON [dbo].[captchas] (expiration)`) {
+		t.Errorf("unexpected migrations %q", migrations)
+	}
+	if q := MSSQL.AddColumn("[captchas]", "attempts"); q != "ALTER TABLE [captchas] ADD attempts INT NOT NULL DEFAULT 0" {
+		t.Errorf("unexpected add column %q", q)
+	}
+	if q := MSSQL.Upsert("[captchas]"); !strings.HasPrefix(q, "MERGE INTO [captchas] WITH (HOLDLOCK)") || !strings.Contains(q, "attempts = 0") {
+		t.Errorf("unexpected upsert %q", q)
+	}
+	if q := MSSQL.InsertIfNotExists("[captchas]"); !strings.Contains(q, "WHEN MATCHED AND target.expiration < @p4 THEN") {
+		t.Errorf("unexpected insert %q", q)
+	}
+	if q := MSSQL.Consume("[captchas]"); q != "DELETE FROM [captchas] OUTPUT DELETED.answer, DELETED.expiration WHERE id = @p1" {
+		t.Errorf("unexpected consume %q", q)
+	}
+	if q := MSSQL.Lock("[captchas]"); q != "SELECT answer, expiration FROM [captchas] WITH (UPDLOCK, ROWLOCK) WHERE id = @p1" {
+		t.Errorf("unexpected lock %q", q)
+	}
+	if q := MSSQL.Attempt("[captchas]"); !strings.Contains(q, "OUTPUT INSERTED.attempts") {
+		t.Errorf("unexpected attempt %q", q)
+	}
+	if q := MSSQL.DeleteExpired("[captchas]"); q != "DELETE TOP (@p2) FROM [captchas] WHERE expiration < @p1" {
+		t.Errorf("unexpected delete expired %q", q)
+	}
+	if q := MSSQL.Scan("[captchas]"); !strings.HasPrefix(q, "SELECT TOP (@p3) id, expiration, metadata FROM [captchas]") {
+		t.Errorf("unexpected scan %q", q)
+	}
+}
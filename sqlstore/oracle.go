@@ -0,0 +1,102 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sqlstore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Oracle is the dialect of Oracle Database, such as the driver
+// github.com/sijms/go-ora/v2. Expirations are saved as TIMESTAMP WITH TIME
+// ZONE, and captchas are saved by MERGE. The captcha IDs are the primary
+// keys, so that neither sequences nor identity columns are required.
+var Oracle Dialect = oracle{}
+
+type oracle struct{}
+
+func (oracle) Placeholder(n int) string {
+	return ":" + strconv.Itoa(n)
+}
+
+func (oracle) Quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// Migrate ignores ORA-00955, which means the name is already used, since
+// Oracle doesn't support IF NOT EXISTS until 23ai.
+func (d oracle) Migrate(schema, table string) []string {
+	name := qualify(d, schema, table)
+	return []string{
+		ignoreExists(fmt.Sprintf(`CREATE TABLE %s (
+	id VARCHAR2(255) PRIMARY KEY,
+	answer VARCHAR2(4000) NOT NULL,
+	expiration TIMESTAMP WITH TIME ZONE NOT NULL,
+	attempts NUMBER(10) DEFAULT 0 NOT NULL,
+	metadata BLOB
+)`, name)),
+		ignoreExists(fmt.Sprintf(`CREATE INDEX %s ON %s (expiration)`, qualify(d, schema, table+"_expiration"), name)),
+	}
+}
+
+func ignoreExists(statement string) string {
+	return fmt.Sprintf(`BEGIN
+	EXECUTE IMMEDIATE %s;
+EXCEPTION
+	WHEN OTHERS THEN
+		IF SQLCODE != -955 THEN
+			RAISE;
+		END IF;
+END;`, literal(statement))
+}
+
+var oracleColumns = map[string]string{
+	"attempts": "NUMBER(10) DEFAULT 0 NOT NULL",
+	"metadata": "BLOB",
+}
+
+func (oracle) AddColumn(name, column string) string {
+	return fmt.Sprintf(`ALTER TABLE %s ADD (%s %s)`, name, column, oracleColumns[column])
+}
+
+func (oracle) Upsert(name string) string {
+	return fmt.Sprintf(`MERGE INTO %s target
+USING (SELECT :1 AS id, :2 AS answer, :3 AS expiration, :4 AS metadata FROM dual) source ON (target.id = source.id)
+WHEN MATCHED THEN UPDATE SET target.answer = source.answer, target.expiration = source.expiration, target.attempts = 0, target.metadata = source.metadata
+WHEN NOT MATCHED THEN INSERT (id, answer, expiration, metadata) VALUES (source.id, source.answer, source.expiration, source.metadata)`, name)
+}
+
+func (oracle) InsertIfNotExists(name string) string {
+	return fmt.Sprintf(`MERGE INTO %s target
+USING (SELECT :1 AS id, :2 AS answer, :3 AS expiration FROM dual) source ON (target.id = source.id)
+WHEN MATCHED THEN UPDATE SET target.answer = source.answer, target.expiration = source.expiration, target.attempts = 0, target.metadata = NULL WHERE target.expiration < :4
+WHEN NOT MATCHED THEN INSERT (id, answer, expiration) VALUES (source.id, source.answer, source.expiration)`, name)
+}
+
+// Consume returns an empty string, the deleted rows are only returned into
+// out parameters by Oracle, so the captcha is selected for update instead.
+func (oracle) Consume(name string) string {
+	return ""
+}
+
+func (oracle) Lock(name string) string {
+	return fmt.Sprintf(`SELECT answer, expiration FROM %s WHERE id = :1 FOR UPDATE`, name)
+}
+
+// Attempt returns an empty string for the same reason as Consume.
+func (oracle) Attempt(name string) string {
+	return ""
+}
+
+func (oracle) DeleteExpired(name string) string {
+	return fmt.Sprintf(`DELETE FROM %s WHERE expiration < :1 AND ROWNUM <= :2`, name)
+}
+
+// Scan compares IDs with CHR(0) on the first page, since Oracle treats the
+// empty cursor as NULL.
+func (oracle) Scan(name string) string {
+	return fmt.Sprintf(`SELECT id, expiration, metadata FROM %s WHERE id > NVL(:1, CHR(0)) AND expiration >= :2 ORDER BY id FETCH FIRST :3 ROWS ONLY`, name)
+}
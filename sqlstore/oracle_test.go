@@ -0,0 +1,49 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sqlstore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOracle(t *testing.T) {
+	if p := Oracle.Placeholder(2); p != ":2" {
+		t.Errorf("unexpected placeholder %q", p)
+	}
+	if q := Oracle.Quote(`foo"bar`); q != `"foo""bar"` {
+		t.Errorf("unexpected quoted identifier %q", q)
+	}
+	migrations := Oracle.Migrate("", "it's")
+	if len(migrations) != 2 || !strings.Contains(migrations[0], `EXECUTE IMMEDIATE 'CREATE TABLE "it''s" (`) ||
+		!strings.Contains(migrations[1], `'CREATE INDEX "it''s_expiration" ON "it''s" (expiration)'`) ||
+		!strings.Contains(migrations[1], "SQLCODE != -955") {
+		t.Errorf("unexpected migrations %q", migrations)
+	}
+	if migrations = Oracle.Migrate("app", "captchas"); !strings.Contains(migrations[1], `'CREATE INDEX "app"."captchas_expiration" ON "app"."captchas" (expiration)'`) {
+		t.Errorf("unexpected migrations %q", migrations)
+	}
+	if q := Oracle.AddColumn(`"captchas"`, "metadata"); q != `ALTER TABLE "captchas" ADD (metadata BLOB)` {
+		t.Errorf("unexpected add column %q", q)
+	}
+	if q := Oracle.Upsert(`"captchas"`); !strings.HasPrefix(q, `MERGE INTO "captchas" target`) || !strings.Contains(q, "FROM dual") {
+		t.Errorf("unexpected upsert %q", q)
+	}
+	if q := Oracle.InsertIfNotExists(`"captchas"`); !strings.Contains(q, "WHERE target.expiration < :4") {
+		t.Errorf("unexpected insert %q", q)
+	}
+	if q := Oracle.Consume(`"captchas"`); q != "" {
+		t.Errorf("expected an empty consume, got %q", q)
+	}
+	if q := Oracle.Attempt(`"captchas"`); q != "" {
+		t.Errorf("expected an empty attempt, got %q", q)
+	}
+	if q := Oracle.Lock(`"captchas"`); q != `SELECT answer, expiration FROM "captchas" WHERE id = :1 FOR UPDATE` {
+		t.Errorf("unexpected lock %q", q)
+	}
+	if q := Oracle.Scan(`"captchas"`); !strings.Contains(q, "id > NVL(:1, CHR(0))") || !strings.HasSuffix(q, "FETCH FIRST :3 ROWS ONLY") {
+		t.Errorf("unexpected scan %q", q)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sqlstore
+
+import (
+	"context"
+	"math"
+
+	"github.com/clevergo/captchas"
+)
+
+// Scan implements ScanStore.Scan, captchas are listed in order of ID, and
+// the cursor is the last ID of the previous page.
+func (s *store) Scan(cursor string, limit int) ([]captchas.Entry, string, error) {
+	return s.ScanContext(context.Background(), cursor, limit)
+}
+
+// ScanContext implements ContextScanStore.ScanContext.
+func (s *store) ScanContext(ctx context.Context, cursor string, limit int) ([]captchas.Entry, string, error) {
+	if limit <= 0 {
+		limit = math.MaxInt32
+	}
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	rows, err := s.db.QueryContext(ctx, s.queries.scan, cursor, s.timestamp(now()), limit)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var entries []captchas.Entry
+	for rows.Next() {
+		var entry captchas.Entry
+		var exp expiration
+		var data []byte
+		if err = rows.Scan(&entry.ID, &exp, &data); err != nil {
+			return nil, "", err
+		}
+		entry.Expiration = exp.Time
+		if data != nil {
+			if err = s.codec.Unmarshal(data, &entry.Metadata); err != nil {
+				return nil, "", err
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	next := ""
+	if len(entries) == limit {
+		next = entries[limit-1].ID
+	}
+	return entries, next, nil
+}
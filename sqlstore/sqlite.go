@@ -0,0 +1,91 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sqlstore
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLite is the dialect of SQLite, such as the pure Go driver
+// modernc.org/sqlite. Expirations are saved as Unix nanoseconds, and
+// captchas are consumed by DELETE ... RETURNING, which requires SQLite
+// 3.35.0 or later.
+var SQLite Dialect = sqlite{}
+
+type sqlite struct{}
+
+func (sqlite) Placeholder(n int) string {
+	return "?" + strconv.Itoa(n)
+}
+
+func (sqlite) Quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// Migrate qualifies the index instead of the table by the schema, as SQLite
+// requires.
+func (d sqlite) Migrate(schema, table string) []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id TEXT PRIMARY KEY,
+	answer TEXT NOT NULL,
+	expiration INTEGER NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	metadata BLOB
+)`, qualify(d, schema, table)),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s ON %s (expiration)`, qualify(d, schema, table+"_expiration"), d.Quote(table)),
+	}
+}
+
+var sqliteColumns = map[string]string{
+	"attempts": "INTEGER NOT NULL DEFAULT 0",
+	"metadata": "BLOB",
+}
+
+func (sqlite) AddColumn(name, column string) string {
+	return fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, name, column, sqliteColumns[column])
+}
+
+// sqliteUpsert is the conflict clause of saving captchas.
+const sqliteUpsert = `ON CONFLICT (id) DO UPDATE SET answer = excluded.answer, expiration = excluded.expiration, attempts = 0, metadata = excluded.metadata`
+
+func (sqlite) Upsert(name string) string {
+	return fmt.Sprintf(`INSERT INTO %s (id, answer, expiration, metadata) VALUES (?1, ?2, ?3, ?4) %s`, name, sqliteUpsert)
+}
+
+func (sqlite) InsertIfNotExists(name string) string {
+	return fmt.Sprintf(`INSERT INTO %s (id, answer, expiration) VALUES (?1, ?2, ?3) %s WHERE expiration < ?4`, name, sqliteUpsert)
+}
+
+func (sqlite) Consume(name string) string {
+	return fmt.Sprintf(`DELETE FROM %s WHERE id = ?1 RETURNING answer, expiration`, name)
+}
+
+// Lock selects the captcha only, since SQLite serializes writes.
+func (sqlite) Lock(name string) string {
+	return fmt.Sprintf(`SELECT answer, expiration FROM %s WHERE id = ?1`, name)
+}
+
+func (sqlite) Attempt(name string) string {
+	return fmt.Sprintf(`UPDATE %s SET attempts = attempts + 1 WHERE id = ?1 AND expiration >= ?2 RETURNING attempts`, name)
+}
+
+// DeleteExpired deletes in order of expiration, which is covered by the
+// expiration index.
+func (sqlite) DeleteExpired(name string) string {
+	return fmt.Sprintf(`DELETE FROM %[1]s WHERE id IN (SELECT id FROM %[1]s WHERE expiration < ?1 ORDER BY expiration LIMIT ?2)`, name)
+}
+
+func (sqlite) Scan(name string) string {
+	return fmt.Sprintf(`SELECT id, expiration, metadata FROM %s WHERE id > ?1 AND expiration >= ?2 ORDER BY id LIMIT ?3`, name)
+}
+
+// EncodeTime implements TimeEncoder.EncodeTime.
+func (sqlite) EncodeTime(t time.Time) interface{} {
+	return t.UnixNano()
+}
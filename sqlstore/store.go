@@ -0,0 +1,601 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package sqlstore is a store of SQL databases, the differences between
+// databases are abstracted by dialects, such as SQLite and MSSQL. The driver
+// of database is imported by applications.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+// Dialect defines the SQL of a database. The table has the columns id,
+// answer, expiration, attempts and metadata, the name of table passed to
+// statements is quoted and qualified by the schema if any.
+type Dialect interface {
+	// Placeholder returns the placeholder of the n-th parameter, n starts
+	// from 1.
+	Placeholder(n int) string
+
+	// Quote quotes the identifier.
+	Quote(identifier string) string
+
+	// Migrate returns the statements that create the table and the index of
+	// expiration if not exist, the schema and the table are unquoted, and
+	// the schema is empty unless it was set by Schema.
+	Migrate(schema, table string) []string
+
+	// AddColumn returns the statement that adds the column attempts or
+	// metadata, which are lacked by the tables created by older versions.
+	AddColumn(name, column string) string
+
+	// Upsert returns the statement that saves the captcha of parameters id,
+	// answer, expiration and metadata, the captcha of the same ID is
+	// replaced, and its attempts are reset.
+	Upsert(name string) string
+
+	// InsertIfNotExists returns the statement that saves the captcha of
+	// parameters id, answer, expiration and now, unless an unexpired captcha
+	// of the same ID exists, in which case no rows are affected. The expired
+	// captcha is replaced as Upsert does, without metadata.
+	InsertIfNotExists(name string) string
+
+	// Consume returns the statement that deletes the captcha of parameter
+	// id, and returns its answer and expiration. An empty string means the
+	// captcha is selected by Lock and then deleted in a transaction, for
+	// databases that can't return deleted rows, such as Oracle.
+	Consume(name string) string
+
+	// Lock returns the statement that selects the answer and expiration of
+	// the captcha of parameter id, and locks the row until the end of
+	// transaction.
+	Lock(name string) string
+
+	// Attempt returns the statement that increases the attempts of the
+	// unexpired captcha of parameters id and now, and returns the attempts.
+	// An empty string means the attempts are increased and then selected in
+	// a transaction.
+	Attempt(name string) string
+
+	// DeleteExpired returns the statement that deletes at most limit
+	// captchas that expired before now, of parameters now and limit.
+	DeleteExpired(name string) string
+
+	// Scan returns the statement that selects the id, expiration and
+	// metadata of at most limit unexpired captchas whose IDs are greater
+	// than the cursor in order of ID, of parameters cursor, now and limit.
+	Scan(name string) string
+}
+
+// Retrier is an optional interface that dialects can implement to retry the
+// transactions of consuming captchas that fail by contention, such as
+// serialization failures of CockroachDB.
+type Retrier interface {
+	// Retryable reports whether the transaction can be retried on the error.
+	Retryable(err error) bool
+}
+
+// TimeEncoder is an optional interface that dialects can implement to save
+// expirations as other types than time.Time, such as the Unix nanoseconds
+// of SQLite. Expirations of type int64 are scanned as Unix nanoseconds.
+type TimeEncoder interface {
+	EncodeTime(t time.Time) interface{}
+}
+
+// Option is a function that receives a pointer of SQL store.
+type Option func(s *store)
+
+// Table sets the table name, defaults to "captchas".
+func Table(table string) Option {
+	return func(s *store) {
+		s.table = table
+	}
+}
+
+// Schema sets the schema name, such as "dbo" or the name of an attached
+// SQLite database, defaults to none, which means the default schema of the
+// connection.
+func Schema(schema string) Option {
+	return func(s *store) {
+		s.schema = schema
+	}
+}
+
+// AutoMigrate reports whether New migrates the table, defaults to true,
+// the table can be migrated by Migrate instead.
+func AutoMigrate(v bool) Option {
+	return func(s *store) {
+		s.autoMigrate = v
+	}
+}
+
+// Expiration sets expiration.
+func Expiration(expiration time.Duration) Option {
+	return func(s *store) {
+		s.expiration = expiration
+	}
+}
+
+// GCInterval sets garbage collection interval, a non-positive interval
+// disables the garbage collection.
+func GCInterval(interval time.Duration) Option {
+	return func(s *store) {
+		s.gcInterval = interval
+	}
+}
+
+// GCBatchSize sets the maximum number of expired captchas deleted per
+// garbage collection, defaults to 1000, so that the table is not locked for
+// long when plenty of captchas expire at once. Non-positive means no limit.
+func GCBatchSize(size int) Option {
+	return func(s *store) {
+		s.gcBatchSize = size
+	}
+}
+
+// QueryTimeout sets the maximum duration of queries of each operation, it
+// takes effect if the context has no earlier deadline, zero means no limit.
+func QueryTimeout(timeout time.Duration) Option {
+	return func(s *store) {
+		s.queryTimeout = timeout
+	}
+}
+
+// MaxRetries sets the maximum number of retries of transactions if the
+// dialect implements Retrier, defaults to 5.
+func MaxRetries(n int) Option {
+	return func(s *store) {
+		s.maxRetries = n
+	}
+}
+
+// Codec sets the codec of metadata, defaults to JSON.
+func Codec(codec captchas.Codec) Option {
+	return func(s *store) {
+		s.codec = codec
+	}
+}
+
+type store struct {
+	db           *sql.DB
+	dialect      Dialect
+	stmts        statements
+	queries      queries
+	codec        captchas.Codec
+	table        string
+	schema       string
+	autoMigrate  bool
+	expiration   time.Duration
+	gcInterval   time.Duration
+	gcBatchSize  int
+	gcStats      gcCounters
+	queryTimeout time.Duration
+	maxRetries   int
+	done         chan struct{}
+	closeOnce    sync.Once
+}
+
+// statements are the statements of hot paths, which are prepared once.
+type statements struct {
+	get     *sql.Stmt
+	consume *sql.Stmt
+	lock    *sql.Stmt
+	set     *sql.Stmt
+	delete  *sql.Stmt
+}
+
+// queries are the other statements.
+type queries struct {
+	nx            string
+	touch         string
+	exists        string
+	attempt       string
+	increase      string
+	attempts      string
+	getMetadata   string
+	deleteExpired string
+	scan          string
+}
+
+// New returns a SQL store of the given dialect, the table will be migrated
+// by Migrate unless AutoMigrate is disabled.
+func New(db *sql.DB, dialect Dialect, opts ...Option) (captchas.Store, error) {
+	s := newStore(db, dialect, opts)
+	if s.autoMigrate {
+		if err := s.migrate(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.prepare(); err != nil {
+		return nil, err
+	}
+
+	if s.gcInterval > 0 {
+		go s.gc()
+	}
+
+	return s, nil
+}
+
+func newStore(db *sql.DB, dialect Dialect, opts []Option) *store {
+	s := &store{
+		db:          db,
+		dialect:     dialect,
+		table:       "captchas",
+		autoMigrate: true,
+		expiration:  10 * time.Minute,
+		gcInterval:  time.Minute,
+		gcBatchSize: 1000,
+		maxRetries:  5,
+		codec:       captchas.JSONCodec,
+		done:        make(chan struct{}),
+	}
+
+	for _, f := range opts {
+		f(s)
+	}
+
+	return s
+}
+
+// Migrate creates the table and the expiration index if not exist, and
+// adds the columns that tables created by older versions lack. The options
+// are the same as New, only Table and Schema matter.
+func Migrate(ctx context.Context, db *sql.DB, dialect Dialect, opts ...Option) error {
+	return newStore(db, dialect, opts).migrate(ctx)
+}
+
+func (s *store) migrate(ctx context.Context) error {
+	for _, query := range s.dialect.Migrate(s.schema, s.table) {
+		if _, err := s.db.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	for _, column := range []string{"attempts", "metadata"} {
+		if err := s.addColumn(ctx, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumn adds the column if it can't be selected.
+func (s *store) addColumn(ctx context.Context, column string) error {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT %s FROM %s WHERE 1 = 0`, column, s.name()))
+	if err == nil {
+		return rows.Close()
+	}
+	_, err = s.db.ExecContext(ctx, s.dialect.AddColumn(s.name(), column))
+	return err
+}
+
+// name returns the quoted name of the table, which is qualified by the
+// schema if any.
+func (s *store) name() string {
+	return qualify(s.dialect, s.schema, s.table)
+}
+
+// qualify quotes the name, and qualifies it by the schema if any.
+func qualify(d Dialect, schema, name string) string {
+	if schema == "" {
+		return d.Quote(name)
+	}
+	return d.Quote(schema) + "." + d.Quote(name)
+}
+
+func (s *store) prepare() (err error) {
+	name, p := s.name(), s.dialect.Placeholder
+	s.queries = queries{
+		nx:            s.dialect.InsertIfNotExists(name),
+		touch:         fmt.Sprintf(`UPDATE %s SET expiration = %s WHERE id = %s AND expiration >= %s`, name, p(1), p(2), p(3)),
+		exists:        fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE id = %s AND expiration >= %s`, name, p(1), p(2)),
+		attempt:       s.dialect.Attempt(name),
+		increase:      fmt.Sprintf(`UPDATE %s SET attempts = attempts + 1 WHERE id = %s AND expiration >= %s`, name, p(1), p(2)),
+		attempts:      fmt.Sprintf(`SELECT attempts FROM %s WHERE id = %s`, name, p(1)),
+		getMetadata:   fmt.Sprintf(`SELECT metadata, expiration FROM %s WHERE id = %s`, name, p(1)),
+		deleteExpired: s.dialect.DeleteExpired(name),
+		scan:          s.dialect.Scan(name),
+	}
+
+	// the captcha is consumed by a single statement, or locked in a
+	// transaction.
+	consume, consumeQuery := &s.stmts.consume, s.dialect.Consume(name)
+	if consumeQuery == "" {
+		consume, consumeQuery = &s.stmts.lock, s.dialect.Lock(name)
+	}
+	queries := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&s.stmts.get, fmt.Sprintf(`SELECT answer, expiration FROM %s WHERE id = %s`, name, p(1))},
+		{consume, consumeQuery},
+		{&s.stmts.set, s.dialect.Upsert(name)},
+		{&s.stmts.delete, fmt.Sprintf(`DELETE FROM %s WHERE id = %s`, name, p(1))},
+	}
+	for _, q := range queries {
+		if *q.stmt, err = s.db.Prepare(q.query); err != nil {
+			s.stmts.close()
+			return err
+		}
+	}
+	return nil
+}
+
+func (stmts statements) close() {
+	for _, stmt := range []*sql.Stmt{stmts.get, stmts.consume, stmts.lock, stmts.set, stmts.delete} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+// context returns a context that is bounded by the query timeout.
+func (s *store) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.queryTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.queryTimeout)
+}
+
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// timestamp returns the parameter of the given time.
+func (s *store) timestamp(t time.Time) interface{} {
+	if e, ok := s.dialect.(TimeEncoder); ok {
+		return e.EncodeTime(t)
+	}
+	return t
+}
+
+// expiration scans the expiration of time.Time, or of Unix nanoseconds.
+type expiration struct {
+	time.Time
+}
+
+func (e *expiration) Scan(v interface{}) error {
+	switch v := v.(type) {
+	case time.Time:
+		e.Time = v
+	case int64:
+		e.Time = time.Unix(0, v)
+	default:
+		return fmt.Errorf("sqlstore: unsupported expiration of type %T", v)
+	}
+	return nil
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext, the captcha is consumed by
+// a single statement or a transaction if clear is true, so that only one of
+// concurrent consumers is able to get the answer.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	answer, exp, err := s.get(ctx, id, clear)
+	if err == sql.ErrNoRows {
+		return "", captchas.ErrIncorrectCaptcha
+	}
+	if err != nil {
+		return "", err
+	}
+	if now().After(exp.Time) {
+		return "", captchas.ErrExpiredCaptcha
+	}
+
+	return answer, nil
+}
+
+func (s *store) get(ctx context.Context, id string, clear bool) (answer string, exp expiration, err error) {
+	switch {
+	case !clear:
+		err = s.stmts.get.QueryRowContext(ctx, id).Scan(&answer, &exp)
+	case s.stmts.consume != nil:
+		err = s.stmts.consume.QueryRowContext(ctx, id).Scan(&answer, &exp)
+	default:
+		err = s.retry(ctx, func(tx *sql.Tx) error {
+			if err := tx.StmtContext(ctx, s.stmts.lock).QueryRowContext(ctx, id).Scan(&answer, &exp); err != nil {
+				return err
+			}
+			_, err := tx.StmtContext(ctx, s.stmts.delete).ExecContext(ctx, id)
+			return err
+		})
+	}
+	return
+}
+
+// transact calls f in a transaction, which is committed if f succeeds.
+func (s *store) transact(ctx context.Context, f func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// retry calls transact until it succeeds, the error is not retryable or the
+// retries are exhausted, the backoff doubles from 10 milliseconds.
+func (s *store) retry(ctx context.Context, f func(tx *sql.Tx) error) error {
+	r, ok := s.dialect.(Retrier)
+	if !ok {
+		return s.transact(ctx, f)
+	}
+	backoff := 10 * time.Millisecond
+	for i := 0; ; i++ {
+		err := s.transact(ctx, f)
+		if err == nil || i >= s.maxRetries || !r.Retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	return s.set(ctx, id, answer, s.expiration, nil)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.SetWithTTLContext(context.Background(), id, answer, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	return s.set(ctx, id, answer, ttl, nil)
+}
+
+// set saves the captcha with the encoded metadata, nil means no metadata.
+func (s *store) set(ctx context.Context, id, answer string, ttl time.Duration, metadata []byte) error {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	_, err := s.stmts.set.ExecContext(ctx, id, answer, s.timestamp(now().Add(ttl)), metadata)
+	return err
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists, the expired captcha
+// of the same ID is replaced.
+func (s *store) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	return s.SetIfNotExistsContext(context.Background(), id, answer, ttl)
+}
+
+// SetIfNotExistsContext implements ContextNXStore.SetIfNotExistsContext.
+func (s *store) SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error) {
+	if ttl <= 0 {
+		ttl = s.expiration
+	}
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	t := now()
+	res, err := s.db.ExecContext(ctx, s.queries.nx, id, answer, s.timestamp(t.Add(ttl)), s.timestamp(t))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	return s.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext implements ContextTouchStore.TouchContext.
+func (s *store) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	t := now()
+	res, err := s.db.ExecContext(ctx, s.queries.touch, s.timestamp(t.Add(ttl)), id, s.timestamp(t))
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return captchas.ErrIncorrectCaptcha
+	}
+	return nil
+}
+
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext implements ContextDeleteStore.DeleteContext.
+func (s *store) DeleteContext(ctx context.Context, id string) error {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	_, err := s.stmts.delete.ExecContext(ctx, id)
+	return err
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (s *store) Attempt(id string) (int, error) {
+	return s.AttemptContext(context.Background(), id)
+}
+
+// AttemptContext implements ContextAttemptStore.AttemptContext.
+func (s *store) AttemptContext(ctx context.Context, id string) (int, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	var n int
+	var err error
+	if s.queries.attempt != "" {
+		err = s.db.QueryRowContext(ctx, s.queries.attempt, id, s.timestamp(now())).Scan(&n)
+	} else {
+		err = s.retry(ctx, func(tx *sql.Tx) error {
+			res, err := tx.ExecContext(ctx, s.queries.increase, id, s.timestamp(now()))
+			if err != nil {
+				return err
+			}
+			affected, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+			if affected == 0 {
+				return sql.ErrNoRows
+			}
+			return tx.QueryRowContext(ctx, s.queries.attempts, id).Scan(&n)
+		})
+	}
+	if err == sql.ErrNoRows {
+		return 0, captchas.ErrIncorrectCaptcha
+	}
+	return n, err
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *store) Exists(id string) (bool, error) {
+	return s.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext implements ContextExistsStore.ExistsContext.
+func (s *store) ExistsContext(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := s.context(ctx)
+	defer cancel()
+	var n int
+	if err := s.db.QueryRowContext(ctx, s.queries.exists, id, s.timestamp(now())).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Close stops the garbage collection and closes the prepared statements,
+// the database is left open.
+func (s *store) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.stmts.close()
+	})
+	return nil
+}
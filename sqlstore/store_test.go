@@ -0,0 +1,539 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/msgpackcodec"
+	_ "modernc.org/sqlite"
+)
+
+// lockingDialect consumes captchas and counts attempts in transactions as
+// Oracle does.
+type lockingDialect struct {
+	sqlite
+}
+
+func (lockingDialect) Consume(name string) string {
+	return ""
+}
+
+func (lockingDialect) Attempt(name string) string {
+	return ""
+}
+
+var testDB *sql.DB
+
+func TestMain(m *testing.M) {
+	var err error
+	testDB, err = sql.Open("sqlite", "file:sqlstore?mode=memory&cache=shared")
+	if err != nil {
+		panic(err)
+	}
+	testDB.SetMaxOpenConns(1)
+
+	code := m.Run()
+	testDB.Close()
+	os.Exit(code)
+}
+
+func newTestStore(t *testing.T, opts ...Option) *store {
+	return newTestDialectStore(t, SQLite, opts...)
+}
+
+func newTestDialectStore(t *testing.T, dialect Dialect, opts ...Option) *store {
+	s, err := New(testDB, dialect, append([]Option{GCInterval(0)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		captchas.Close(s)
+	})
+	return s.(*store)
+}
+
+func TestNew(t *testing.T) {
+	s := newTestStore(t, Table("foo"), Expiration(5*time.Minute), QueryTimeout(time.Second), GCBatchSize(10))
+	if s.table != "foo" || s.expiration != 5*time.Minute || s.queryTimeout != time.Second || s.gcBatchSize != 10 {
+		t.Errorf("unexpected store %+v", s)
+	}
+	s2, err := New(testDB, SQLite, Table("bar"), AutoMigrate(false), GCInterval(0))
+	if err == nil {
+		defer captchas.Close(s2)
+		if err = s2.Set("foo", "bar"); err == nil {
+			t.Error("expected an error of the missing table, got nil")
+		}
+	}
+	var n int
+	if err := testDB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'bar'`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("expected the table is not migrated, got %d, %v", n, err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	opts := []Option{Schema("main"), Table(`migrate "foo"`), AutoMigrate(false), GCInterval(0)}
+	for i := 0; i < 2; i++ {
+		if err := Migrate(context.Background(), testDB, SQLite, opts...); err != nil {
+			t.Fatalf("failed to migrate: %s", err)
+		}
+	}
+	var n int
+	query := `SELECT COUNT(*) FROM main.sqlite_master WHERE type = 'index' AND name = 'migrate "foo"_expiration'`
+	if err := testDB.QueryRow(query).Scan(&n); err != nil || n != 1 {
+		t.Errorf("expected the expiration index, got %d, %v", n, err)
+	}
+
+	s, err := New(testDB, SQLite, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer captchas.Close(s)
+	if err = s.Set("foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if answer, err := s.Get("foo", true); err != nil || answer != "bar" {
+		t.Errorf("expected answer %q, got %q, %v", "bar", answer, err)
+	}
+}
+
+func TestMigrateColumns(t *testing.T) {
+	// creates a table of older versions, which lacks the attempts and the
+	// metadata columns.
+	if _, err := testDB.Exec(`CREATE TABLE "old" (id TEXT PRIMARY KEY, answer TEXT NOT NULL, expiration INTEGER NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	s := newTestStore(t, Table("old"))
+	if err := s.SetWithMetadata("foo", "bar", 0, captchas.Metadata{Driver: "digit"}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.Attempt("foo"); err != nil || n != 1 {
+		t.Errorf("expected attempts 1, got %d, %v", n, err)
+	}
+	if md, err := s.GetMetadata("foo"); err != nil || md.Driver != "digit" {
+		t.Errorf("expected metadata of driver %q, got %+v, %v", "digit", md, err)
+	}
+}
+
+func TestStoreGet(t *testing.T) {
+	for _, dialect := range []Dialect{SQLite, lockingDialect{}} {
+		testStoreGet(t, newTestDialectStore(t, dialect, Table("get")))
+	}
+}
+
+func testStoreGet(t *testing.T, s *store) {
+	if _, err := s.Get("foo", true); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	if err := s.Set("foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("foo", "baz"); err != nil {
+		t.Fatal(err)
+	}
+	for _, clear := range []bool{false, true} {
+		answer, err := s.Get("foo", clear)
+		if err != nil || answer != "baz" {
+			t.Errorf("expected answer %q, got %q, %v", "baz", answer, err)
+		}
+	}
+	if _, err := s.Get("foo", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
+
+func TestStoreExpiration(t *testing.T) {
+	s := newTestStore(t, Table("expiration"))
+	if err := s.SetWithTTL("expired", "bar", -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("expired", false); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+	if ok, err := s.Exists("expired"); ok || err != nil {
+		t.Errorf("expected the captcha doesn't exist, got %t, %v", ok, err)
+	}
+
+	if err := s.SetWithTTL("valid", "bar", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := s.deleteExpired(); err != nil || n != 1 {
+		t.Fatalf("expected 1 deleted captcha, got %d, %v", n, err)
+	}
+	var n int
+	if err := testDB.QueryRow(`SELECT COUNT(*) FROM expiration WHERE id = 'expired'`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("expected the expired captcha is deleted, got %d, %v", n, err)
+	}
+	if ok, err := s.Exists("valid"); !ok || err != nil {
+		t.Errorf("expected the captcha exists, got %t, %v", ok, err)
+	}
+}
+
+func TestStoreGCBatchSize(t *testing.T) {
+	s := newTestStore(t, Table("gc"), GCBatchSize(2))
+	for i := 0; i < 5; i++ {
+		if err := s.SetWithTTL(strconv.Itoa(i), "foo", -time.Second); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Set("active", "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []uint64{2, 4, 5, 5} {
+		s.collect()
+		if stats := s.GCStats(); stats.Deleted != expected {
+			t.Errorf("expected %d deleted captchas, got %d", expected, stats.Deleted)
+		}
+	}
+	stats := s.GCStats()
+	if stats.Runs != 4 || stats.Errors != 0 || stats.LastRun.IsZero() {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if _, err := s.Get("active", false); err != nil {
+		t.Errorf("expected captcha %q to be kept, got %v", "active", err)
+	}
+}
+
+func TestStoreJitter(t *testing.T) {
+	s := &store{gcInterval: time.Minute}
+	for i := 0; i < 100; i++ {
+		if d := s.jitter(); d < 54*time.Second || d > 66*time.Second {
+			t.Fatalf("expected jittered interval within 10%%, got %v", d)
+		}
+	}
+}
+
+func TestStoreExpirationIndex(t *testing.T) {
+	newTestStore(t, Table("index"))
+	rows, err := testDB.Query(`EXPLAIN QUERY PLAN SELECT id FROM "index" WHERE expiration < ? ORDER BY expiration LIMIT ?`, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var plan string
+	for rows.Next() {
+		var id, parent, unused int
+		var detail string
+		if err = rows.Scan(&id, &parent, &unused, &detail); err != nil {
+			t.Fatal(err)
+		}
+		plan += detail
+	}
+	if !strings.Contains(plan, "index_expiration") {
+		t.Errorf("expected the expiration index to be used, got plan %q", plan)
+	}
+}
+
+func TestStoreUpsert(t *testing.T) {
+	s := newTestStore(t, Table("upsert"))
+	if err := s.SetWithMetadata("foo", "bar", 0, captchas.Metadata{Driver: "digit"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Attempt("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("foo", "baz"); err != nil {
+		t.Fatal(err)
+	}
+	if answer, err := s.Get("foo", false); err != nil || answer != "baz" {
+		t.Errorf("expected answer %q, got %q, %v", "baz", answer, err)
+	}
+	if md, err := s.GetMetadata("foo"); err != nil || md.Driver != "" {
+		t.Errorf("expected metadata to be reset, got %+v, %v", md, err)
+	}
+	if n, err := s.Attempt("foo"); err != nil || n != 1 {
+		t.Errorf("expected attempts to be reset, got %d, %v", n, err)
+	}
+	if err := s.SetMulti(map[string]string{"foo": "qux"}); err != nil {
+		t.Fatal(err)
+	}
+	if answer, err := s.Get("foo", false); err != nil || answer != "qux" {
+		t.Errorf("expected answer %q, got %q, %v", "qux", answer, err)
+	}
+}
+
+func TestStoreContext(t *testing.T) {
+	s := newTestStore(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	errs := map[string]error{
+		"set":      s.SetContext(ctx, "foo", "bar"),
+		"ttl":      s.SetWithTTLContext(ctx, "foo", "bar", time.Minute),
+		"metadata": s.SetWithMetadataContext(ctx, "foo", "bar", 0, captchas.Metadata{}),
+		"touch":    s.TouchContext(ctx, "foo", time.Minute),
+		"delete":   s.DeleteContext(ctx, "foo"),
+		"multi":    s.SetMultiContext(ctx, map[string]string{"foo": "bar"}),
+	}
+	_, errs["get"] = s.GetContext(ctx, "foo", false)
+	_, errs["nx"] = s.SetIfNotExistsContext(ctx, "foo", "bar", 0)
+	_, errs["attempt"] = s.AttemptContext(ctx, "foo")
+	_, errs["exists"] = s.ExistsContext(ctx, "foo")
+	_, errs["get metadata"] = s.GetMetadataContext(ctx, "foo")
+	_, _, errs["scan"] = s.ScanContext(ctx, "", 0)
+	_, errs["get multi"] = s.GetMultiContext(ctx, []string{"foo"}, false)
+	for op, err := range errs {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("%s: expected error %v, got %v", op, context.Canceled, err)
+		}
+	}
+}
+
+func TestStoreQueryTimeout(t *testing.T) {
+	s := newTestStore(t, Table("timeout"), QueryTimeout(time.Nanosecond))
+	if err := s.Set("foo", "bar"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	s = newTestStore(t, Table("timeout"), QueryTimeout(time.Minute))
+	if err := s.Set("foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if answer, err := s.Get("foo", true); err != nil || answer != "bar" {
+		t.Errorf("expected answer %q, got %q, %v", "bar", answer, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("foo", "bar"); err == nil {
+		t.Error("expected an error of closed statements, got nil")
+	}
+}
+
+func TestStoreConsume(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "captchas.db")+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, dialect := range []Dialect{SQLite, lockingDialect{}} {
+		s, err := New(db, dialect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = s.Set("foo", "bar"); err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		var consumed atomic.Int32
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				answer, err := captchas.GetContext(context.Background(), s, "foo", true)
+				if err == nil && answer == "bar" {
+					consumed.Add(1)
+				} else if err != captchas.ErrIncorrectCaptcha && !strings.Contains(err.Error(), "SQLITE_BUSY") {
+					t.Errorf("unexpected error %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if n := consumed.Load(); n != 1 {
+			t.Errorf("expected the captcha to be consumed once, got %d", n)
+		}
+		captchas.Close(s)
+	}
+}
+
+func TestStoreBatch(t *testing.T) {
+	for _, dialect := range []Dialect{SQLite, lockingDialect{}} {
+		s := newTestDialectStore(t, dialect, Table("batch"))
+		items := make(map[string]string)
+		ids := make([]string, 0, batchSize+10)
+		for i := 0; i < batchSize+10; i++ {
+			id := strconv.Itoa(i)
+			items[id] = "answer" + id
+			ids = append(ids, id)
+		}
+		if err := s.SetMulti(items); err != nil {
+			t.Fatalf("failed to set: %s", err)
+		}
+
+		answers, err := s.GetMulti(append(ids, "missing"), false)
+		if err != nil {
+			t.Fatalf("failed to get: %s", err)
+		}
+		if !reflect.DeepEqual(answers, items) {
+			t.Errorf("expected %d answers, got %d", len(items), len(answers))
+		}
+
+		answers, err = s.GetMulti([]string{ids[0], ids[1], "missing"}, true)
+		if err != nil {
+			t.Fatalf("failed to get: %s", err)
+		}
+		if len(answers) != 2 {
+			t.Errorf("expected 2 answers, got %v", answers)
+		}
+		if _, err = s.Get(ids[0], false); err != captchas.ErrIncorrectCaptcha {
+			t.Errorf("expected error %s, got %v", captchas.ErrIncorrectCaptcha, err)
+		}
+	}
+}
+
+func TestStoreSetIfNotExists(t *testing.T) {
+	s := newTestStore(t, Table("nx"))
+	if ok, err := s.SetIfNotExists("foo", "bar", 0); err != nil || !ok {
+		t.Fatalf("expected to be saved, got %t, %v", ok, err)
+	}
+	if ok, err := s.SetIfNotExists("foo", "baz", 0); err != nil || ok {
+		t.Errorf("expected not to be saved, got %t, %v", ok, err)
+	}
+	if err := s.SetWithMetadata("foo", "bar", -time.Second, captchas.Metadata{Driver: "digit"}); err != nil {
+		t.Fatal(err)
+	}
+	// the non-positive TTL of SetWithMetadata means the default expiration.
+	if err := s.SetWithTTL("foo", "bar", -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.SetIfNotExists("foo", "baz", time.Minute); err != nil || !ok {
+		t.Errorf("expected expired captcha to be replaced, got %t, %v", ok, err)
+	}
+	if value, _ := s.Get("foo", false); value != "baz" {
+		t.Errorf("expected value %q, got %q", "baz", value)
+	}
+}
+
+func TestStoreTouch(t *testing.T) {
+	s := newTestStore(t, Table("touch"))
+	if err := s.Touch("foo", time.Minute); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	if err := s.SetWithTTL("foo", "bar", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Touch("foo", -time.Second); err != nil {
+		t.Fatalf("failed to touch: %s", err)
+	}
+	if _, err := s.Get("foo", false); err != captchas.ErrExpiredCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrExpiredCaptcha, err)
+	}
+}
+
+func TestStoreDelete(t *testing.T) {
+	s := newTestStore(t, Table("delete"))
+	if err := s.Set("foo", "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := s.Exists("foo"); ok || err != nil {
+		t.Errorf("expected the captcha is deleted, got %t, %v", ok, err)
+	}
+}
+
+func TestStoreAttempt(t *testing.T) {
+	for _, dialect := range []Dialect{SQLite, lockingDialect{}} {
+		s := newTestDialectStore(t, dialect, Table("attempts"))
+		if _, err := s.Attempt("foo"); err != captchas.ErrIncorrectCaptcha {
+			t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+		}
+		s.Set("foo", "bar")
+		for i := 1; i <= 3; i++ {
+			if n, err := s.Attempt("foo"); err != nil || n != i {
+				t.Errorf("expected attempts %d, got %d, %v", i, n, err)
+			}
+		}
+
+		m := captchas.New(s, nil, captchas.MaxAttempts(3))
+		if err := m.Verify("foo", "baz", false); err != captchas.ErrTooManyAttempts {
+			t.Errorf("expected error %v, got %v", captchas.ErrTooManyAttempts, err)
+		}
+		if _, err := s.Get("foo", false); err != captchas.ErrIncorrectCaptcha {
+			t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+		}
+	}
+}
+
+func TestStoreMetadata(t *testing.T) {
+	s := newTestStore(t, Table("metadata"), Codec(msgpackcodec.New()))
+	md := captchas.Metadata{
+		CreatedAt: time.Unix(1600000000, 0),
+		ClientIP:  "127.0.0.1",
+		Labels:    map[string]string{"form": "login"},
+	}
+	if err := s.SetWithMetadata("foo", "bar", 0, md); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	actual, err := s.GetMetadata("foo")
+	if err != nil || !actual.CreatedAt.Equal(md.CreatedAt) || !reflect.DeepEqual(actual.Labels, md.Labels) {
+		t.Errorf("expected metadata %v, got %v, %v", md, actual, err)
+	}
+	if value, _ := s.Get("foo", false); value != "bar" {
+		t.Errorf("expected value %q, got %q", "bar", value)
+	}
+
+	s.Set("foo", "bar")
+	if actual, err = s.GetMetadata("foo"); err != nil || !reflect.DeepEqual(actual, captchas.Metadata{}) {
+		t.Errorf("expected zero metadata, got %v, %v", actual, err)
+	}
+	if _, err = s.GetMetadata("missing"); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
+
+func TestStoreScan(t *testing.T) {
+	s := newTestStore(t, Table("scan"))
+	for _, id := range []string{"c", "a", "b"} {
+		s.Set(id, "answer")
+	}
+	s.SetWithTTL("expired", "answer", -time.Second)
+	s.SetWithMetadata("d", "answer", 0, captchas.Metadata{ClientIP: "127.0.0.1"})
+
+	entries, next, err := s.Scan("", 2)
+	if err != nil || len(entries) != 2 || entries[0].ID != "a" || next != "b" {
+		t.Fatalf("unexpected page %v, %q, %v", entries, next, err)
+	}
+	entries, next, err = s.Scan(next, 2)
+	if err != nil || len(entries) != 2 || entries[1].ID != "d" || entries[1].Metadata.ClientIP != "127.0.0.1" {
+		t.Fatalf("unexpected page %v, %q, %v", entries, next, err)
+	}
+	if entries[0].Expiration.Before(time.Now()) {
+		t.Errorf("expected an unexpired expiration, got %v", entries[0].Expiration)
+	}
+	if entries, next, err = s.Scan(next, 2); err != nil || len(entries) != 0 || next != "" {
+		t.Errorf("unexpected page %v, %q, %v", entries, next, err)
+	}
+	if entries, _, err = s.Scan("", 0); err != nil || len(entries) != 4 {
+		t.Errorf("expected all of 4 captchas without limit, got %v, %v", entries, err)
+	}
+}
+
+func TestStoreClose(t *testing.T) {
+	s, err := New(testDB, SQLite, Table("close"), GCInterval(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := captchas.Close(s); err != nil {
+			t.Errorf("failed to close: %s", err)
+		}
+	}
+
+	s, err = New(testDB, SQLite, Table("close"), GCInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = captchas.Close(s); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if runs := s.(GCStatsReporter).GCStats().Runs; runs != 0 {
+		t.Errorf("expected no garbage collection, got %d runs", runs)
+	}
+}
@@ -4,6 +4,12 @@
 
 package captchas
 
+import (
+	"context"
+	"io"
+	"time"
+)
+
 // Store defines how to save and load captcha information.
 type Store interface {
 	// Get returns the answer of the given captcha ID, returns
@@ -15,3 +21,498 @@ type Store interface {
 	// if failed.
 	Set(id, answer string) error
 }
+
+// ContextStore is an optional interface that stores can implement to honor
+// the deadline and cancellation of context, the context-aware methods of
+// manager use it if the store implements it, and fall back to Store otherwise.
+type ContextStore interface {
+	Store
+
+	// GetContext is the context-aware version of Get.
+	GetContext(ctx context.Context, id string, clear bool) (string, error)
+
+	// SetContext is the context-aware version of Set.
+	SetContext(ctx context.Context, id, answer string) error
+}
+
+// GetContext calls GetContext of the store if it is a ContextStore,
+// otherwise calls Get.
+func GetContext(ctx context.Context, s Store, id string, clear bool) (string, error) {
+	if cs, ok := s.(ContextStore); ok {
+		return cs.GetContext(ctx, id, clear)
+	}
+	return s.Get(id, clear)
+}
+
+// SetContext calls SetContext of the store if it is a ContextStore,
+// otherwise calls Set.
+func SetContext(ctx context.Context, s Store, id, answer string) error {
+	if cs, ok := s.(ContextStore); ok {
+		return cs.SetContext(ctx, id, answer)
+	}
+	return s.Set(id, answer)
+}
+
+// TTLStore is an optional interface that stores can implement to save
+// captchas with different lifetimes, instead of the store-wide expiration.
+type TTLStore interface {
+	Store
+
+	// SetWithTTL saves the captcha ID and answer that expires after the
+	// given TTL, returns error if failed.
+	SetWithTTL(id, answer string, ttl time.Duration) error
+}
+
+// SetWithTTL calls SetWithTTL of the store if it is a TTLStore, otherwise
+// returns ErrTTLUnsupported.
+func SetWithTTL(s Store, id, answer string, ttl time.Duration) error {
+	if ts, ok := s.(TTLStore); ok {
+		return ts.SetWithTTL(id, answer, ttl)
+	}
+	return ErrTTLUnsupported
+}
+
+// ContextTTLStore is an optional interface that TTL stores can implement to
+// honor the deadline and cancellation of context, see ContextStore.
+type ContextTTLStore interface {
+	TTLStore
+
+	// SetWithTTLContext is the context-aware version of SetWithTTL.
+	SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error
+}
+
+// SetWithTTLContext calls SetWithTTLContext of the store if it is a
+// ContextTTLStore, otherwise falls back to SetWithTTL.
+func SetWithTTLContext(ctx context.Context, s Store, id, answer string, ttl time.Duration) error {
+	if ts, ok := s.(ContextTTLStore); ok {
+		return ts.SetWithTTLContext(ctx, id, answer, ttl)
+	}
+	return SetWithTTL(s, id, answer, ttl)
+}
+
+// AttemptStore is an optional interface that stores can implement to count
+// verification attempts of captchas, see the MaxAttempts option of manager.
+type AttemptStore interface {
+	Store
+
+	// Attempt increments the attempts of the captcha atomically and
+	// returns the new count, returns ErrIncorrectCaptcha if the captcha
+	// doesn't exist.
+	Attempt(id string) (int, error)
+}
+
+// ContextAttemptStore is an optional interface that attempt stores can
+// implement to honor the deadline and cancellation of context.
+type ContextAttemptStore interface {
+	AttemptStore
+
+	// AttemptContext is the context-aware version of Attempt.
+	AttemptContext(ctx context.Context, id string) (int, error)
+}
+
+// AttemptContext calls AttemptContext of the store if it is a
+// ContextAttemptStore, otherwise falls back to Attempt, returns
+// ErrAttemptsUnsupported if the store isn't an AttemptStore.
+func AttemptContext(ctx context.Context, s Store, id string) (int, error) {
+	if as, ok := s.(ContextAttemptStore); ok {
+		return as.AttemptContext(ctx, id)
+	}
+	if as, ok := s.(AttemptStore); ok {
+		return as.Attempt(id)
+	}
+	return 0, ErrAttemptsUnsupported
+}
+
+// Metadata is the extra information saved alongside the answer of captcha,
+// such as for analytics, auditing and binding captchas to requesters.
+type Metadata struct {
+	CreatedAt time.Time         `json:"created_at"`
+	Driver    string            `json:"driver,omitempty"`
+	ClientIP  string            `json:"client_ip,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// MetadataStore is an optional interface that stores can implement to save
+// metadata of captchas.
+type MetadataStore interface {
+	Store
+
+	// SetWithMetadata saves the captcha ID, answer and metadata, a
+	// non-positive TTL means the expiration of store.
+	SetWithMetadata(id, answer string, ttl time.Duration, md Metadata) error
+
+	// GetMetadata returns the metadata of the given captcha ID, returns
+	// an error if failed.
+	GetMetadata(id string) (Metadata, error)
+}
+
+// GetMetadata calls GetMetadata of the store if it is a MetadataStore,
+// otherwise returns ErrMetadataUnsupported.
+func GetMetadata(s Store, id string) (Metadata, error) {
+	if ms, ok := s.(MetadataStore); ok {
+		return ms.GetMetadata(id)
+	}
+	return Metadata{}, ErrMetadataUnsupported
+}
+
+// ContextMetadataStore is an optional interface that metadata stores can
+// implement to honor the deadline and cancellation of context.
+type ContextMetadataStore interface {
+	MetadataStore
+
+	// SetWithMetadataContext is the context-aware version of
+	// SetWithMetadata.
+	SetWithMetadataContext(ctx context.Context, id, answer string, ttl time.Duration, md Metadata) error
+
+	// GetMetadataContext is the context-aware version of GetMetadata.
+	GetMetadataContext(ctx context.Context, id string) (Metadata, error)
+}
+
+// SetWithMetadataContext calls SetWithMetadataContext of the store if it is
+// a ContextMetadataStore, otherwise falls back to SetWithMetadata, returns
+// ErrMetadataUnsupported if the store isn't a MetadataStore.
+func SetWithMetadataContext(ctx context.Context, s Store, id, answer string, ttl time.Duration, md Metadata) error {
+	if ms, ok := s.(ContextMetadataStore); ok {
+		return ms.SetWithMetadataContext(ctx, id, answer, ttl, md)
+	}
+	if ms, ok := s.(MetadataStore); ok {
+		return ms.SetWithMetadata(id, answer, ttl, md)
+	}
+	return ErrMetadataUnsupported
+}
+
+// GetMetadataContext calls GetMetadataContext of the store if it is a
+// ContextMetadataStore, otherwise falls back to GetMetadata.
+func GetMetadataContext(ctx context.Context, s Store, id string) (Metadata, error) {
+	if ms, ok := s.(ContextMetadataStore); ok {
+		return ms.GetMetadataContext(ctx, id)
+	}
+	return GetMetadata(s, id)
+}
+
+// Entry is a pending captcha listed by ScanStore, the answer is never
+// exposed.
+type Entry struct {
+	ID         string
+	Expiration time.Time
+	Metadata   Metadata
+}
+
+// ScanStore is an optional interface that stores can implement to list
+// pending captchas, such as for admin dashboards.
+type ScanStore interface {
+	Store
+
+	// Scan returns up to limit unexpired captchas after the cursor, and
+	// the cursor of next page, an empty cursor starts from the beginning,
+	// and an empty next cursor means the end.
+	Scan(cursor string, limit int) (entries []Entry, next string, err error)
+}
+
+// ContextScanStore is an optional interface that scan stores can implement
+// to honor the deadline and cancellation of context.
+type ContextScanStore interface {
+	ScanStore
+
+	// ScanContext is the context-aware version of Scan.
+	ScanContext(ctx context.Context, cursor string, limit int) (entries []Entry, next string, err error)
+}
+
+// ExistsStore is an optional interface that stores can implement to check
+// whether captchas are valid without fetching the answers.
+type ExistsStore interface {
+	Store
+
+	// Exists reports whether the captcha exists and is not expired.
+	Exists(id string) (bool, error)
+}
+
+// Exists calls Exists of the store if it is an ExistsStore, otherwise
+// peeks the captcha by Get without clearing it.
+func Exists(s Store, id string) (bool, error) {
+	if es, ok := s.(ExistsStore); ok {
+		return es.Exists(id)
+	}
+	_, err := s.Get(id, false)
+	if IsCaptchaError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ContextExistsStore is an optional interface that exists stores can
+// implement to honor the deadline and cancellation of context.
+type ContextExistsStore interface {
+	ExistsStore
+
+	// ExistsContext is the context-aware version of Exists.
+	ExistsContext(ctx context.Context, id string) (bool, error)
+}
+
+// ExistsContext is the context-aware version of Exists, it prefers
+// ContextExistsStore, and peeks the captcha by GetContext at last.
+func ExistsContext(ctx context.Context, s Store, id string) (bool, error) {
+	if es, ok := s.(ContextExistsStore); ok {
+		return es.ExistsContext(ctx, id)
+	}
+	if es, ok := s.(ExistsStore); ok {
+		return es.Exists(id)
+	}
+	_, err := GetContext(ctx, s, id, false)
+	if IsCaptchaError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// DeleteStore is an optional interface that stores can implement to delete
+// captchas directly.
+type DeleteStore interface {
+	Store
+
+	// Delete deletes the captcha, it is not an error if the captcha
+	// doesn't exist.
+	Delete(id string) error
+}
+
+// Delete calls Delete of the store if it is a DeleteStore, otherwise
+// consumes the captcha by Get.
+func Delete(s Store, id string) error {
+	if ds, ok := s.(DeleteStore); ok {
+		return ds.Delete(id)
+	}
+	_, err := s.Get(id, true)
+	if IsCaptchaError(err) {
+		return nil
+	}
+	return err
+}
+
+// ContextDeleteStore is an optional interface that delete stores can
+// implement to honor the deadline and cancellation of context.
+type ContextDeleteStore interface {
+	DeleteStore
+
+	// DeleteContext is the context-aware version of Delete.
+	DeleteContext(ctx context.Context, id string) error
+}
+
+// DeleteContext is the context-aware version of Delete, it prefers
+// ContextDeleteStore, and consumes the captcha by GetContext at last.
+func DeleteContext(ctx context.Context, s Store, id string) error {
+	if ds, ok := s.(ContextDeleteStore); ok {
+		return ds.DeleteContext(ctx, id)
+	}
+	if ds, ok := s.(DeleteStore); ok {
+		return ds.Delete(id)
+	}
+	_, err := GetContext(ctx, s, id, true)
+	if IsCaptchaError(err) {
+		return nil
+	}
+	return err
+}
+
+// TouchStore is an optional interface that stores can implement to extend
+// the lifetime of captchas, so that users can keep solving the captcha
+// without regenerating it.
+type TouchStore interface {
+	Store
+
+	// Touch resets the expiration of the captcha to the given TTL from
+	// now, returns ErrIncorrectCaptcha if the captcha doesn't exist.
+	Touch(id string, ttl time.Duration) error
+}
+
+// Touch calls Touch of the store if it is a TouchStore, otherwise returns
+// ErrTouchUnsupported.
+func Touch(s Store, id string, ttl time.Duration) error {
+	if ts, ok := s.(TouchStore); ok {
+		return ts.Touch(id, ttl)
+	}
+	return ErrTouchUnsupported
+}
+
+// ContextTouchStore is an optional interface that touch stores can
+// implement to honor the deadline and cancellation of context.
+type ContextTouchStore interface {
+	TouchStore
+
+	// TouchContext is the context-aware version of Touch.
+	TouchContext(ctx context.Context, id string, ttl time.Duration) error
+}
+
+// TouchContext calls TouchContext of the store if it is a
+// ContextTouchStore, otherwise falls back to Touch.
+func TouchContext(ctx context.Context, s Store, id string, ttl time.Duration) error {
+	if ts, ok := s.(ContextTouchStore); ok {
+		return ts.TouchContext(ctx, id, ttl)
+	}
+	return Touch(s, id, ttl)
+}
+
+// NXStore is an optional interface that stores can implement to save
+// captchas atomically only if the ID is not taken by an unexpired captcha,
+// manager uses it to detect ID collisions and regenerate captchas.
+type NXStore interface {
+	Store
+
+	// SetIfNotExists saves the captcha ID and answer if the ID doesn't
+	// exist, reports whether the captcha was saved. A non-positive TTL
+	// means the expiration of store.
+	SetIfNotExists(id, answer string, ttl time.Duration) (bool, error)
+}
+
+// ContextNXStore is an optional interface that NX stores can implement to
+// honor the deadline and cancellation of context.
+type ContextNXStore interface {
+	NXStore
+
+	// SetIfNotExistsContext is the context-aware version of SetIfNotExists.
+	SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error)
+}
+
+// SetIfNotExistsContext calls SetIfNotExistsContext of the store if it is a
+// ContextNXStore, otherwise falls back to SetIfNotExists. If the store isn't
+// an NXStore, the captcha is saved regardless and reported as saved, as
+// manager does without NXStore.
+func SetIfNotExistsContext(ctx context.Context, s Store, id, answer string, ttl time.Duration) (bool, error) {
+	if ns, ok := s.(ContextNXStore); ok {
+		return ns.SetIfNotExistsContext(ctx, id, answer, ttl)
+	}
+	if ns, ok := s.(NXStore); ok {
+		return ns.SetIfNotExists(id, answer, ttl)
+	}
+	if ttl > 0 {
+		return true, SetWithTTLContext(ctx, s, id, answer, ttl)
+	}
+	return true, SetContext(ctx, s, id, answer)
+}
+
+// BatchStore is an optional interface that stores can implement to save and
+// load multiple captchas efficiently, such as by pipelining.
+type BatchStore interface {
+	Store
+
+	// GetMulti returns the answers of the given captcha IDs, missing and
+	// expired captchas are omitted. Clear indicates whether delete the
+	// captchas after fetching.
+	GetMulti(ids []string, clear bool) (map[string]string, error)
+
+	// SetMulti saves the captcha IDs and answers, returns error if failed.
+	SetMulti(items map[string]string) error
+}
+
+// ContextBatchStore is an optional interface that batch stores can
+// implement to honor the deadline and cancellation of context.
+type ContextBatchStore interface {
+	BatchStore
+
+	// GetMultiContext is the context-aware version of GetMulti.
+	GetMultiContext(ctx context.Context, ids []string, clear bool) (map[string]string, error)
+
+	// SetMultiContext is the context-aware version of SetMulti.
+	SetMultiContext(ctx context.Context, items map[string]string) error
+}
+
+// GetMulti calls GetMulti of the store if it is a BatchStore, otherwise
+// calls Get for each ID.
+func GetMulti(s Store, ids []string, clear bool) (map[string]string, error) {
+	if bs, ok := s.(BatchStore); ok {
+		return bs.GetMulti(ids, clear)
+	}
+	answers := make(map[string]string, len(ids))
+	for _, id := range ids {
+		answer, err := s.Get(id, clear)
+		if IsCaptchaError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		answers[id] = answer
+	}
+	return answers, nil
+}
+
+// SetMulti calls SetMulti of the store if it is a BatchStore, otherwise
+// calls Set for each item.
+func SetMulti(s Store, items map[string]string) error {
+	if bs, ok := s.(BatchStore); ok {
+		return bs.SetMulti(items)
+	}
+	for id, answer := range items {
+		if err := s.Set(id, answer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the store if it implements io.Closer, stores that run
+// background goroutines, such as memstore, implement it to stop them.
+func Close(s Store) error {
+	if c, ok := s.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// Verifier is an optional interface that stores can implement to compare
+// the candidate answer by themselves, such as stores that keep hashes of
+// answers only. Manager.Verify delegates to it if the store implements it,
+// in which case the CaseSensitive option of manager takes no effect, and
+// drivers that implement Matcher are rejected with ErrMatcherUnsupported.
+type Verifier interface {
+	// Verify verifies whether the given actual value matches the answer
+	// of captcha, returns an error if failed. Clear indicates whether
+	// delete the captcha after verifying.
+	Verify(id, actual string, clear bool) error
+}
+
+// MatchVerifier is an optional interface that stores can implement to
+// verify captchas by themselves, but compare the answers with the given
+// match function, such as stores that handle their own failures. Manager.Verify
+// prefers it to Verifier, and passes the comparison of manager, which honors
+// the CaseSensitive option and Matcher drivers.
+type MatchVerifier interface {
+	// VerifyMatch is as same as Verifier.Verify, except that the answer is
+	// compared by match.
+	VerifyMatch(id, actual string, clear bool, match func(actual, answer string) bool) error
+}
+
+// ContextVerifier is an optional interface that verifiers can implement to
+// honor the deadline and cancellation of context.
+type ContextVerifier interface {
+	Verifier
+
+	// VerifyContext is the context-aware version of Verify.
+	VerifyContext(ctx context.Context, id, actual string, clear bool) error
+}
+
+// ContextMatchVerifier is an optional interface that match verifiers can
+// implement to honor the deadline and cancellation of context.
+type ContextMatchVerifier interface {
+	MatchVerifier
+
+	// VerifyMatchContext is the context-aware version of VerifyMatch.
+	VerifyMatchContext(ctx context.Context, id, actual string, clear bool, match func(actual, answer string) bool) error
+}
+
+// VerifyContext calls VerifyContext of the verifier if it is a
+// ContextVerifier, otherwise calls Verify.
+func VerifyContext(ctx context.Context, v Verifier, id, actual string, clear bool) error {
+	if cv, ok := v.(ContextVerifier); ok {
+		return cv.VerifyContext(ctx, id, actual, clear)
+	}
+	return v.Verify(id, actual, clear)
+}
+
+// VerifyMatchContext calls VerifyMatchContext of the verifier if it is a
+// ContextMatchVerifier, otherwise calls VerifyMatch.
+func VerifyMatchContext(ctx context.Context, v MatchVerifier, id, actual string, clear bool, match func(actual, answer string) bool) error {
+	if cv, ok := v.(ContextMatchVerifier); ok {
+		return cv.VerifyMatchContext(ctx, id, actual, clear, match)
+	}
+	return v.VerifyMatch(id, actual, clear, match)
+}
@@ -0,0 +1,272 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package tenantstore isolates captchas of tenants sharing a single store,
+// with per-tenant counters and purge.
+package tenantstore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/prefixstore"
+)
+
+// ErrScanUnsupported is returned by Purge if the underlying store doesn't
+// implement captchas.ScanStore.
+var ErrScanUnsupported = errors.New("tenantstore: store doesn't support scan")
+
+// Stats is the statistics of a tenant.
+type Stats struct {
+	// Sets is the number of saved captchas.
+	Sets uint64
+	// Hits is the number of lookups that found the captcha.
+	Hits uint64
+	// Misses is the number of lookups that didn't find the captcha, or
+	// found an expired one, verifications by the store count as lookups.
+	Misses uint64
+}
+
+type counters struct {
+	sets   atomic.Uint64
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// Store is a multi-tenant store.
+type Store struct {
+	store    captchas.Store
+	mu       sync.RWMutex
+	counters map[string]*counters
+}
+
+// New returns a multi-tenant store that delegates to the given store.
+func New(s captchas.Store) *Store {
+	return &Store{
+		store:    s,
+		counters: make(map[string]*counters),
+	}
+}
+
+// WithTenant returns a view of the given tenant, captcha IDs are prefixed
+// with the length and ID of tenant by prefixstore, so that tenants cannot
+// access captchas of each other whatever their IDs are. The view implements
+// captchas.Verifier or captchas.MatchVerifier if the underlying store does.
+func (s *Store) WithTenant(tenant string) captchas.Store {
+	v := &view{
+		store:    prefixstore.New(s.store, prefix(tenant)),
+		counters: s.getCounters(tenant),
+	}
+	switch v.store.(type) {
+	case captchas.MatchVerifier:
+		return &matchVerifier{v}
+	case captchas.Verifier:
+		return &verifier{v}
+	}
+	return v
+}
+
+func prefix(tenant string) string {
+	return strconv.Itoa(len(tenant)) + ":" + tenant + ":"
+}
+
+func (s *Store) getCounters(tenant string) *counters {
+	s.mu.RLock()
+	c, ok := s.counters[tenant]
+	s.mu.RUnlock()
+	if ok {
+		return c
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok = s.counters[tenant]; !ok {
+		c = &counters{}
+		s.counters[tenant] = c
+	}
+	return c
+}
+
+// Stats returns the statistics of the given tenant since the store was
+// created.
+func (s *Store) Stats(tenant string) Stats {
+	c := s.getCounters(tenant)
+	return Stats{
+		Sets:   c.sets.Load(),
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+// Purge deletes all captchas of the given tenant, the underlying store must
+// implement captchas.ScanStore, returns the number of deleted captchas.
+func (s *Store) Purge(tenant string) (int, error) {
+	ss, ok := s.store.(captchas.ScanStore)
+	if !ok {
+		return 0, ErrScanUnsupported
+	}
+	p := prefix(tenant)
+	n := 0
+	cursor := ""
+	for {
+		entries, next, err := ss.Scan(cursor, 1000)
+		if err != nil {
+			return n, err
+		}
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.ID, p) {
+				continue
+			}
+			if err = captchas.Delete(s.store, entry.ID); err != nil {
+				return n, err
+			}
+			n++
+		}
+		if next == "" {
+			return n, nil
+		}
+		cursor = next
+	}
+}
+
+// Close closes the underlying store if it implements io.Closer.
+func (s *Store) Close() error {
+	return captchas.Close(s.store)
+}
+
+type view struct {
+	store    captchas.Store
+	counters *counters
+}
+
+func (v *view) count(err error) {
+	if err != nil {
+		v.counters.misses.Add(1)
+	} else {
+		v.counters.hits.Add(1)
+	}
+}
+
+// Get implements Store.Get.
+func (v *view) Get(id string, clear bool) (string, error) {
+	return v.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (v *view) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	answer, err := captchas.GetContext(ctx, v.store, id, clear)
+	v.count(err)
+	return answer, err
+}
+
+// Set implements Store.Set.
+func (v *view) Set(id, answer string) error {
+	return v.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (v *view) SetContext(ctx context.Context, id, answer string) error {
+	v.counters.sets.Add(1)
+	return captchas.SetContext(ctx, v.store, id, answer)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (v *view) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return v.SetWithTTLContext(context.Background(), id, answer, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (v *view) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	v.counters.sets.Add(1)
+	return captchas.SetWithTTLContext(ctx, v.store, id, answer, ttl)
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (v *view) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	return v.SetIfNotExistsContext(context.Background(), id, answer, ttl)
+}
+
+// SetIfNotExistsContext implements ContextNXStore.SetIfNotExistsContext.
+func (v *view) SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error) {
+	v.counters.sets.Add(1)
+	return captchas.SetIfNotExistsContext(ctx, v.store, id, answer, ttl)
+}
+
+// Delete implements DeleteStore.Delete.
+func (v *view) Delete(id string) error {
+	return v.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext implements ContextDeleteStore.DeleteContext.
+func (v *view) DeleteContext(ctx context.Context, id string) error {
+	return captchas.DeleteContext(ctx, v.store, id)
+}
+
+// Exists implements ExistsStore.Exists.
+func (v *view) Exists(id string) (bool, error) {
+	return v.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext implements ContextExistsStore.ExistsContext.
+func (v *view) ExistsContext(ctx context.Context, id string) (bool, error) {
+	return captchas.ExistsContext(ctx, v.store, id)
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (v *view) Attempt(id string) (int, error) {
+	return v.AttemptContext(context.Background(), id)
+}
+
+// AttemptContext implements ContextAttemptStore.AttemptContext.
+func (v *view) AttemptContext(ctx context.Context, id string) (int, error) {
+	return captchas.AttemptContext(ctx, v.store, id)
+}
+
+// Touch implements TouchStore.Touch.
+func (v *view) Touch(id string, ttl time.Duration) error {
+	return v.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext implements ContextTouchStore.TouchContext.
+func (v *view) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
+	return captchas.TouchContext(ctx, v.store, id, ttl)
+}
+
+type verifier struct {
+	*view
+}
+
+// Verify implements Verifier.Verify.
+func (v *verifier) Verify(id, actual string, clear bool) error {
+	return v.VerifyContext(context.Background(), id, actual, clear)
+}
+
+// VerifyContext implements ContextVerifier.VerifyContext.
+func (v *verifier) VerifyContext(ctx context.Context, id, actual string, clear bool) error {
+	err := captchas.VerifyContext(ctx, v.store.(captchas.Verifier), id, actual, clear)
+	v.count(err)
+	return err
+}
+
+type matchVerifier struct {
+	*view
+}
+
+// VerifyMatch implements MatchVerifier.VerifyMatch.
+func (v *matchVerifier) VerifyMatch(id, actual string, clear bool, match func(actual, answer string) bool) error {
+	return v.VerifyMatchContext(context.Background(), id, actual, clear, match)
+}
+
+// VerifyMatchContext implements ContextMatchVerifier.VerifyMatchContext.
+func (v *matchVerifier) VerifyMatchContext(ctx context.Context, id, actual string, clear bool, match func(actual, answer string) bool) error {
+	err := captchas.VerifyMatchContext(ctx, v.store.(captchas.MatchVerifier), id, actual, clear, match)
+	v.count(err)
+	return err
+}
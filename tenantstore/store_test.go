@@ -0,0 +1,108 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package tenantstore
+
+import (
+	"testing"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/hashedstore"
+	"github.com/clevergo/captchas/memstore"
+	"github.com/clevergo/captchas/policystore"
+)
+
+func TestWithTenant(t *testing.T) {
+	s := New(memstore.New())
+	acme := s.WithTenant("acme")
+	// the tenant and ID are ambiguous without the length prefix.
+	other := s.WithTenant("acme:foo")
+	if err := acme.Set("foo:bar", "answer"); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if _, err := other.Get("bar", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	if value, err := acme.Get("foo:bar", true); err != nil || value != "answer" {
+		t.Errorf("expected value %q, got %q, %v", "answer", value, err)
+	}
+
+	stats := s.Stats("acme")
+	if stats.Sets != 1 || stats.Hits != 1 || stats.Misses != 0 {
+		t.Errorf("unexpected stats of acme %+v", stats)
+	}
+	if stats = s.Stats("acme:foo"); stats.Misses != 1 {
+		t.Errorf("unexpected stats of acme:foo %+v", stats)
+	}
+}
+
+func TestWithTenantVerifier(t *testing.T) {
+	s := New(hashedstore.New(memstore.New(), []byte("secret")))
+	acme := s.WithTenant("acme")
+	if _, ok := acme.(captchas.Verifier); !ok {
+		t.Fatal("expected a verifier view")
+	}
+	m := captchas.New(acme, nil)
+	acme.Set("foo", "bar")
+	if err := m.Verify("foo", "baz", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	if err := m.Verify("foo", "bar", true); err != nil {
+		t.Errorf("expected non error, got %s", err)
+	}
+	if err := captchas.New(s.WithTenant("globex"), nil).Verify("foo", "bar", true); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	if stats := s.Stats("acme"); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("unexpected stats of acme %+v", stats)
+	}
+
+	if _, ok := New(policystore.New(memstore.New(), policystore.FailClosed)).WithTenant("acme").(captchas.MatchVerifier); !ok {
+		t.Error("expected a match verifier view")
+	}
+}
+
+func TestWithTenantAttempt(t *testing.T) {
+	s := New(memstore.New())
+	m := captchas.New(s.WithTenant("acme"), nil, captchas.MaxAttempts(1))
+	s.WithTenant("acme").Set("foo", "bar")
+	if err := m.Verify("foo", "baz", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+	if err := m.Verify("foo", "bar", false); err != captchas.ErrTooManyAttempts {
+		t.Errorf("expected error %v, got %v", captchas.ErrTooManyAttempts, err)
+	}
+
+	m = captchas.New(New(testStore{memstore.New()}).WithTenant("acme"), nil, captchas.MaxAttempts(1))
+	if err := m.Verify("foo", "bar", false); err != captchas.ErrAttemptsUnsupported {
+		t.Errorf("expected error %v, got %v", captchas.ErrAttemptsUnsupported, err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	s := New(memstore.New())
+	acme := s.WithTenant("acme")
+	globex := s.WithTenant("globex")
+	for _, id := range []string{"foo", "bar"} {
+		acme.Set(id, "answer")
+		globex.Set(id, "answer")
+	}
+	if n, err := s.Purge("acme"); err != nil || n != 2 {
+		t.Fatalf("expected %d captchas to be purged, got %d, %v", 2, n, err)
+	}
+	if ok, _ := captchas.Exists(acme, "foo"); ok {
+		t.Error("expected captchas of acme to be purged")
+	}
+	if ok, _ := captchas.Exists(globex, "foo"); !ok {
+		t.Error("expected captchas of globex to be kept")
+	}
+
+	if _, err := New(testStore{}).Purge("acme"); err != ErrScanUnsupported {
+		t.Errorf("expected error %v, got %v", ErrScanUnsupported, err)
+	}
+}
+
+type testStore struct {
+	captchas.Store
+}
@@ -0,0 +1,158 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+// Package tieredstore combines a local store, such as memstore, and a remote
+// store, such as redisstore, captchas are written to both of them and are
+// read from the local store first.
+//
+// The remote store is the source of truth, so that consumption always reaches
+// the remote store, otherwise a captcha may be used once on each node.
+package tieredstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/clevergo/captchas"
+)
+
+type store struct {
+	local  captchas.Store
+	remote captchas.Store
+}
+
+// New returns a tiered store, the local store should expire captchas
+// no later than the remote store.
+func New(local, remote captchas.Store) captchas.Store {
+	return &store{
+		local:  local,
+		remote: remote,
+	}
+}
+
+// Get implements Store.Get.
+func (s *store) Get(id string, clear bool) (string, error) {
+	return s.GetContext(context.Background(), id, clear)
+}
+
+// GetContext implements ContextStore.GetContext.
+func (s *store) GetContext(ctx context.Context, id string, clear bool) (string, error) {
+	if clear {
+		captchas.GetContext(ctx, s.local, id, true)
+		return captchas.GetContext(ctx, s.remote, id, true)
+	}
+
+	answer, err := captchas.GetContext(ctx, s.local, id, false)
+	if errors.Is(err, captchas.ErrIncorrectCaptcha) {
+		return captchas.GetContext(ctx, s.remote, id, false)
+	}
+	return answer, err
+}
+
+// Set implements Store.Set.
+func (s *store) Set(id, answer string) error {
+	return s.SetContext(context.Background(), id, answer)
+}
+
+// SetContext implements ContextStore.SetContext.
+func (s *store) SetContext(ctx context.Context, id, answer string) error {
+	if err := captchas.SetContext(ctx, s.remote, id, answer); err != nil {
+		return err
+	}
+	return captchas.SetContext(ctx, s.local, id, answer)
+}
+
+// SetWithTTL implements TTLStore.SetWithTTL.
+func (s *store) SetWithTTL(id, answer string, ttl time.Duration) error {
+	return s.SetWithTTLContext(context.Background(), id, answer, ttl)
+}
+
+// SetWithTTLContext implements ContextTTLStore.SetWithTTLContext.
+func (s *store) SetWithTTLContext(ctx context.Context, id, answer string, ttl time.Duration) error {
+	if err := captchas.SetWithTTLContext(ctx, s.remote, id, answer, ttl); err != nil {
+		return err
+	}
+	return captchas.SetWithTTLContext(ctx, s.local, id, answer, ttl)
+}
+
+// SetIfNotExists implements NXStore.SetIfNotExists.
+func (s *store) SetIfNotExists(id, answer string, ttl time.Duration) (bool, error) {
+	return s.SetIfNotExistsContext(context.Background(), id, answer, ttl)
+}
+
+// SetIfNotExistsContext implements ContextNXStore.SetIfNotExistsContext, the
+// ID is claimed in the remote store, and then the captcha is saved to the
+// local store.
+func (s *store) SetIfNotExistsContext(ctx context.Context, id, answer string, ttl time.Duration) (bool, error) {
+	ok, err := captchas.SetIfNotExistsContext(ctx, s.remote, id, answer, ttl)
+	if !ok || err != nil {
+		return ok, err
+	}
+	if ttl > 0 {
+		return true, captchas.SetWithTTLContext(ctx, s.local, id, answer, ttl)
+	}
+	return true, captchas.SetContext(ctx, s.local, id, answer)
+}
+
+// Attempt implements AttemptStore.Attempt.
+func (s *store) Attempt(id string) (int, error) {
+	return s.AttemptContext(context.Background(), id)
+}
+
+// AttemptContext implements ContextAttemptStore.AttemptContext, attempts are
+// counted by the remote store.
+func (s *store) AttemptContext(ctx context.Context, id string) (int, error) {
+	return captchas.AttemptContext(ctx, s.remote, id)
+}
+
+// Delete implements DeleteStore.Delete.
+func (s *store) Delete(id string) error {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext implements ContextDeleteStore.DeleteContext.
+func (s *store) DeleteContext(ctx context.Context, id string) error {
+	captchas.DeleteContext(ctx, s.local, id)
+	return captchas.DeleteContext(ctx, s.remote, id)
+}
+
+// Touch implements TouchStore.Touch.
+func (s *store) Touch(id string, ttl time.Duration) error {
+	return s.TouchContext(context.Background(), id, ttl)
+}
+
+// TouchContext implements ContextTouchStore.TouchContext, the local copy is
+// deleted if it can't be touched, so that reads fall back to the remote store.
+func (s *store) TouchContext(ctx context.Context, id string, ttl time.Duration) error {
+	if err := captchas.TouchContext(ctx, s.remote, id, ttl); err != nil {
+		return err
+	}
+	if err := captchas.TouchContext(ctx, s.local, id, ttl); err != nil {
+		captchas.DeleteContext(ctx, s.local, id)
+	}
+	return nil
+}
+
+// Exists implements ExistsStore.Exists.
+func (s *store) Exists(id string) (bool, error) {
+	return s.ExistsContext(context.Background(), id)
+}
+
+// ExistsContext implements ContextExistsStore.ExistsContext.
+func (s *store) ExistsContext(ctx context.Context, id string) (bool, error) {
+	if ok, err := captchas.ExistsContext(ctx, s.local, id); ok && err == nil {
+		return true, nil
+	}
+	return captchas.ExistsContext(ctx, s.remote, id)
+}
+
+// Close closes both of the local and remote stores if they implement io.Closer.
+func (s *store) Close() error {
+	err := captchas.Close(s.local)
+	if err2 := captchas.Close(s.remote); err == nil {
+		err = err2
+	}
+	return err
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 CleverGo. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be found
+// in the LICENSE file.
+
+package tieredstore
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/clevergo/captchas"
+	"github.com/clevergo/captchas/memstore"
+)
+
+type errStore struct {
+	err error
+}
+
+func (s errStore) Get(id string, clear bool) (string, error) {
+	return "", s.err
+}
+
+func (s errStore) Set(id, answer string) error {
+	return s.err
+}
+
+func TestStoreGet(t *testing.T) {
+	local, remote := memstore.New(), memstore.New()
+	s := New(local, remote)
+	_, err := s.Get("foo", true)
+	if err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+
+	err = s.Set("foo", "bar")
+	if err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	for _, store := range []captchas.Store{local, remote} {
+		if value, _ := store.Get("foo", false); value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+	for _, clear := range []bool{false, true} {
+		value, err := s.Get("foo", clear)
+		if err != nil {
+			t.Fatalf("expected non error, got %s", err)
+		}
+		if value != "bar" {
+			t.Errorf("expected value %q, got %q", "bar", value)
+		}
+	}
+
+	for _, store := range []captchas.Store{s, local, remote} {
+		if _, err = store.Get("foo", false); err != captchas.ErrIncorrectCaptcha {
+			t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+		}
+	}
+}
+
+func TestStoreGetFallback(t *testing.T) {
+	// the captcha was generated on another node.
+	remote := memstore.New()
+	remote.Set("foo", "bar")
+	s := New(memstore.New(), remote)
+	if value, err := s.Get("foo", false); err != nil || value != "bar" {
+		t.Errorf("expected value %q, got %q, %v", "bar", value, err)
+	}
+
+	// the captcha was consumed on another node.
+	local := memstore.New()
+	local.Set("fizz", "buzz")
+	s = New(local, memstore.New())
+	if _, err := s.Get("fizz", true); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
+
+func TestStoreSet(t *testing.T) {
+	expected := errors.New("unavailable")
+	local := memstore.New()
+	s := New(local, errStore{expected})
+	if err := s.Set("foo", "bar"); err != expected {
+		t.Errorf("expected error %v, got %v", expected, err)
+	}
+	if _, err := local.Get("foo", false); err != captchas.ErrIncorrectCaptcha {
+		t.Errorf("expected error %v, got %v", captchas.ErrIncorrectCaptcha, err)
+	}
+}
+
+func TestStoreClose(t *testing.T) {
+	expected := errors.New("close")
+	closed := 0
+	closer := closerStore{Store: memstore.New(), close: func() error {
+		closed++
+		return expected
+	}}
+	s := New(closer, closer).(io.Closer)
+	if err := s.Close(); err != expected {
+		t.Errorf("expected error %v, got %v", expected, err)
+	}
+	if closed != 2 {
+		t.Errorf("expected %d stores closed, got %d", 2, closed)
+	}
+}
+
+type closerStore struct {
+	captchas.Store
+	close func() error
+}
+
+func (s closerStore) Close() error {
+	return s.close()
+}
+
+func TestStoreForwarding(t *testing.T) {
+	local, remote := memstore.New(), memstore.New()
+	s := New(local, remote)
+	if ok, err := s.(captchas.NXStore).SetIfNotExists("foo", "bar", time.Minute); err != nil || !ok {
+		t.Fatalf("expected to be saved, got %t, %v", ok, err)
+	}
+	remote.Set("baz", "qux")
+	if ok, _ := s.(captchas.NXStore).SetIfNotExists("baz", "bar", time.Minute); ok {
+		t.Error("expected the ID taken in the remote store to be rejected")
+	}
+	if value, _ := local.Get("foo", false); value != "bar" {
+		t.Errorf("expected value %q in the local store, got %q", "bar", value)
+	}
+	if n, err := s.(captchas.AttemptStore).Attempt("foo"); err != nil || n != 1 {
+		t.Errorf("expected attempts %d, got %d, %v", 1, n, err)
+	}
+	if n, _ := remote.(captchas.AttemptStore).Attempt("foo"); n != 2 {
+		t.Errorf("expected attempts to be counted by the remote store, got %d", n)
+	}
+	if err := captchas.Touch(s, "foo", time.Hour); err != nil {
+		t.Errorf("failed to touch: %s", err)
+	}
+	if ok, err := captchas.Exists(s, "baz"); err != nil || !ok {
+		t.Errorf("expected the captcha of the remote store to exist, got %t, %v", ok, err)
+	}
+	if err := s.(captchas.DeleteStore).Delete("foo"); err != nil {
+		t.Fatal(err)
+	}
+	for _, store := range []captchas.Store{local, remote} {
+		if ok, _ := captchas.Exists(store, "foo"); ok {
+			t.Error("expected the captcha to be deleted")
+		}
+	}
+}